@@ -218,6 +218,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 		ReadMimeType:  true,
 		WriteMimeType: true,
 		BucketBased:   true,
+		MaxPathLength: 1024,
 	}).Fill(f)
 	if f.root != "" {
 		f.root += "/"
@@ -165,6 +165,20 @@ type ListFileNamesResponse struct {
 	NextFileID   *string `json:"nextFileId"`   // What to pass in to startFileId for the next search to continue where this one left off, or null if there are no more files.
 }
 
+// ListUnfinishedLargeFilesRequest is passed to b2_list_unfinished_large_files
+type ListUnfinishedLargeFilesRequest struct {
+	BucketID     string `json:"bucketId"`               // required - The bucket to look for file names in.
+	NamePrefix   string `json:"namePrefix,omitempty"`   // optional - Only return files whose names match this prefix.
+	StartFileID  string `json:"startFileId,omitempty"`  // optional - The first upload to return.
+	MaxFileCount int    `json:"maxFileCount,omitempty"` // optional - The maximum number of files to return from this call. The default value is 100, and the maximum allowed is 100.
+}
+
+// ListUnfinishedLargeFilesResponse is as received from b2_list_unfinished_large_files
+type ListUnfinishedLargeFilesResponse struct {
+	Files      []File  `json:"files"`      // An array of objects, each one describing one unfinished file.
+	NextFileID *string `json:"nextFileId"` // What to pass in to startFileId for the next search to continue where this one left off, or null if there are no more files.
+}
+
 // GetUploadURLRequest is passed to b2_get_upload_url
 type GetUploadURLRequest struct {
 	BucketID string `json:"bucketId"` // The ID of the bucket that you want to upload to.
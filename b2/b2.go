@@ -260,6 +260,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 		ReadMimeType:  true,
 		WriteMimeType: true,
 		BucketBased:   true,
+		MaxPathLength: 1024,
 	}).Fill(f)
 	// Set the test flag if required
 	if *b2TestMode != "" {
@@ -913,6 +914,64 @@ func (f *Fs) CleanUp() error {
 	return f.purge(true)
 }
 
+// ListMultipartUploads lists the unfinished large files under the root
+func (f *Fs) ListMultipartUploads() (uploads []fs.MultipartUpload, err error) {
+	bucketID, err := f.getBucketID()
+	if err != nil {
+		return nil, err
+	}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/b2_list_unfinished_large_files",
+	}
+	var request = api.ListUnfinishedLargeFilesRequest{
+		BucketID:   bucketID,
+		NamePrefix: f.root,
+	}
+	for {
+		var response api.ListUnfinishedLargeFilesResponse
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err := f.srv.CallJSON(&opts, &request, &response)
+			return f.shouldRetry(resp, err)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list unfinished large files")
+		}
+		for _, file := range response.Files {
+			if !strings.HasPrefix(file.Name, f.root) {
+				fs.Logf(f, "Odd name received %q", file.Name)
+				continue
+			}
+			uploads = append(uploads, fs.MultipartUpload{
+				Remote:    file.Name[len(f.root):],
+				ID:        file.ID,
+				Initiated: time.Time(file.UploadTimestamp),
+			})
+		}
+		if response.NextFileID == nil {
+			break
+		}
+		request.StartFileID = *response.NextFileID
+	}
+	return uploads, nil
+}
+
+// AbortMultipartUpload cancels the unfinished large file passed in
+func (f *Fs) AbortMultipartUpload(upload fs.MultipartUpload) error {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/b2_cancel_large_file",
+	}
+	var request = api.CancelLargeFileRequest{
+		ID: upload.ID,
+	}
+	var response api.CancelLargeFileResponse
+	return f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(&opts, &request, &response)
+		return f.shouldRetry(resp, err)
+	})
+}
+
 // Hashes returns the supported hash sets.
 func (f *Fs) Hashes() fs.HashSet {
 	return fs.HashSet(fs.HashSHA1)
@@ -1404,11 +1463,13 @@ func (o *Object) MimeType() string {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs          = &Fs{}
-	_ fs.Purger      = &Fs{}
-	_ fs.PutStreamer = &Fs{}
-	_ fs.CleanUpper  = &Fs{}
-	_ fs.ListRer     = &Fs{}
-	_ fs.Object      = &Object{}
-	_ fs.MimeTyper   = &Object{}
+	_ fs.Fs               = &Fs{}
+	_ fs.Purger           = &Fs{}
+	_ fs.PutStreamer      = &Fs{}
+	_ fs.CleanUpper       = &Fs{}
+	_ fs.ListRer          = &Fs{}
+	_ fs.MultipartLister  = &Fs{}
+	_ fs.MultipartAborter = &Fs{}
+	_ fs.Object           = &Object{}
+	_ fs.MimeTyper        = &Object{}
 )
@@ -239,6 +239,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 	f.features = (&fs.Features{
 		CaseInsensitive:         true,
 		CanHaveEmptyDirectories: true,
+		MaxNameLength:           255,
 	}).Fill(f)
 	f.srv.SetErrorHandler(errorHandler)
 
@@ -5,9 +5,11 @@ import (
 	// Active commands
 	_ "github.com/ncw/rclone/cmd"
 	_ "github.com/ncw/rclone/cmd/authorize"
+	_ "github.com/ncw/rclone/cmd/benchmark"
 	_ "github.com/ncw/rclone/cmd/cat"
 	_ "github.com/ncw/rclone/cmd/check"
 	_ "github.com/ncw/rclone/cmd/cleanup"
+	_ "github.com/ncw/rclone/cmd/cleanupuploads"
 	_ "github.com/ncw/rclone/cmd/cmount"
 	_ "github.com/ncw/rclone/cmd/config"
 	_ "github.com/ncw/rclone/cmd/copy"
@@ -17,6 +19,7 @@ import (
 	_ "github.com/ncw/rclone/cmd/dbhashsum"
 	_ "github.com/ncw/rclone/cmd/dedupe"
 	_ "github.com/ncw/rclone/cmd/delete"
+	_ "github.com/ncw/rclone/cmd/fixmarkers"
 	_ "github.com/ncw/rclone/cmd/genautocomplete"
 	_ "github.com/ncw/rclone/cmd/gendocs"
 	_ "github.com/ncw/rclone/cmd/info"
@@ -42,6 +45,8 @@ import (
 	_ "github.com/ncw/rclone/cmd/sha1sum"
 	_ "github.com/ncw/rclone/cmd/size"
 	_ "github.com/ncw/rclone/cmd/sync"
+	_ "github.com/ncw/rclone/cmd/touchsync"
 	_ "github.com/ncw/rclone/cmd/tree"
+	_ "github.com/ncw/rclone/cmd/treediff"
 	_ "github.com/ncw/rclone/cmd/version"
 )
@@ -0,0 +1,88 @@
+package benchmark
+
+import (
+	"os"
+	"strings"
+
+	"github.com/ncw/rclone/cmd"
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Globals
+var (
+	sizes = "1k,1M,10M"
+	count = 10
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefintion)
+	flags := commandDefintion.Flags()
+	flags.StringVarP(&sizes, "sizes", "", sizes, "Comma separated list of object sizes to test.")
+	flags.IntVarP(&count, "count", "", count, "Number of objects of each size to test.")
+}
+
+var commandDefintion = &cobra.Command{
+	Use:   "benchmark remote:path",
+	Short: `Benchmark the upload, download and list speed of a remote.`,
+	Long: `
+rclone benchmark uploads, lists, downloads and deletes test objects
+on the remote and reports the throughput, the latency of each
+operation and the operations per second.  This is useful for choosing
+settings such as --transfers and --checkers.
+
+The test objects are written to a new directory under remote:path
+which is removed again when the benchmark finishes, even if it fails.
+
+Use --sizes to set the object sizes to test (default "1k,1M,10M") and
+--count to set how many objects of each size are used (default 10).
+The operations are done one at a time so the figures are for a single
+stream.
+
+For example
+
+    rclone benchmark --sizes 1M,100M --count 5 remote:
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		fdst := cmd.NewFsDst(args)
+		cmd.Run(false, false, command, func() error {
+			sizeList, err := parseSizes(sizes)
+			if err != nil {
+				return err
+			}
+			if count <= 0 {
+				return errors.New("--count must be at least 1")
+			}
+			results, err := Benchmark(fdst, sizeList, count)
+			if err != nil {
+				return err
+			}
+			return results.Write(os.Stdout)
+		})
+	},
+}
+
+// parseSizes parses a comma separated list of sizes
+func parseSizes(in string) (out []fs.SizeSuffix, err error) {
+	for _, s := range strings.Split(in, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		var size fs.SizeSuffix
+		err = size.Set(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad size %q in --sizes", s)
+		}
+		if size < 0 {
+			return nil, errors.Errorf("bad size %q in --sizes", s)
+		}
+		out = append(out, size)
+	}
+	if len(out) == 0 {
+		return nil, errors.Errorf("no sizes in --sizes %q", in)
+	}
+	return out, nil
+}
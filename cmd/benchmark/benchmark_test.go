@@ -0,0 +1,114 @@
+package benchmark
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/fstest"
+	_ "github.com/ncw/rclone/local"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain drives the tests
+func TestMain(m *testing.M) {
+	fstest.TestMain(m)
+}
+
+func TestParseSizes(t *testing.T) {
+	got, err := parseSizes("1k, 1M,,10M")
+	require.NoError(t, err)
+	assert.Equal(t, []fs.SizeSuffix{1024, 1024 * 1024, 10 * 1024 * 1024}, got)
+
+	for _, bad := range []string{"", ",", "potato", "1k,-1"} {
+		_, err = parseSizes(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// checkEmpty checks the remote has no objects or directories left
+func checkEmpty(t *testing.T, f fs.Fs) {
+	fstest.CheckListingWithPrecision(t, f, nil, []string{}, fs.Config.ModifyWindow)
+}
+
+func TestBenchmark(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.Mkdir(r.Fremote)
+
+	sizes := []fs.SizeSuffix{0, 1024, 64 * 1024}
+	results, err := Benchmark(r.Fremote, sizes, 3)
+	require.NoError(t, err)
+
+	var ops []string
+	for _, result := range results {
+		ops = append(ops, result.Op)
+		wantCount := 3
+		if result.Op == "remove" {
+			wantCount = 9
+		}
+		assert.Equal(t, wantCount, result.Count, result.Op)
+		assert.True(t, result.Total > 0, result.Op)
+		assert.True(t, result.MinLatency <= result.AvgLatency(), result.Op)
+		assert.True(t, result.AvgLatency() <= result.MaxLatency, result.Op)
+		assert.True(t, result.OpsPerSecond() > 0, result.Op)
+	}
+	assert.Equal(t, []string{"put 0", "get 0", "put 1k", "get 1k", "put 64k", "get 64k", "list 9", "remove"}, ops)
+	for i, size := range sizes {
+		for _, result := range results[2*i : 2*i+2] {
+			assert.Equal(t, 3*int64(size), result.Bytes, result.Op)
+		}
+	}
+	assert.Equal(t, float64(3*64*1024)/results[4].Total.Seconds(), results[4].BytesPerSecond())
+	assert.Equal(t, float64(0), results[6].BytesPerSecond())
+
+	// Check the report
+	var buf bytes.Buffer
+	require.NoError(t, results.Write(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, len(results)+1, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], "Operation"))
+	assert.True(t, strings.HasPrefix(lines[5], "put 64k"))
+
+	checkEmpty(t, r.Fremote)
+}
+
+// failGetFs is an Fs whose objects can't be downloaded
+type failGetFs struct {
+	fs.Fs
+}
+
+// failGetObject is an Object which can't be downloaded
+type failGetObject struct {
+	fs.Object
+}
+
+// Open fails
+func (o *failGetObject) Open(options ...fs.OpenOption) (io.ReadCloser, error) {
+	return nil, errors.New("download failed")
+}
+
+// Put uploads the object returning a failGetObject
+func (f *failGetFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	o, err := f.Fs.Put(in, src, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &failGetObject{Object: o}, nil
+}
+
+func TestBenchmarkCleansUpOnError(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.Mkdir(r.Fremote)
+
+	_, err := Benchmark(&failGetFs{Fs: r.Fremote}, []fs.SizeSuffix{1024}, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "download failed")
+
+	checkEmpty(t, r.Fremote)
+}
@@ -0,0 +1,212 @@
+// Run the benchmark and report the results
+
+package benchmark
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"path"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// Result holds the timings for one kind of operation
+type Result struct {
+	Op         string        // name of the operation, eg "put 1M"
+	Count      int           // number of operations done
+	Bytes      int64         // total bytes transferred
+	Total      time.Duration // total time for all the operations
+	MinLatency time.Duration // fastest operation
+	MaxLatency time.Duration // slowest operation
+}
+
+// add records an operation which took dt and transferred n bytes
+func (r *Result) add(dt time.Duration, n int64) {
+	if r.Count == 0 || dt < r.MinLatency {
+		r.MinLatency = dt
+	}
+	if dt > r.MaxLatency {
+		r.MaxLatency = dt
+	}
+	r.Count++
+	r.Bytes += n
+	r.Total += dt
+}
+
+// AvgLatency returns the average time for each operation
+func (r *Result) AvgLatency() time.Duration {
+	if r.Count == 0 {
+		return 0
+	}
+	return r.Total / time.Duration(r.Count)
+}
+
+// OpsPerSecond returns the number of operations per second
+func (r *Result) OpsPerSecond() float64 {
+	if r.Total <= 0 {
+		return 0
+	}
+	return float64(r.Count) / r.Total.Seconds()
+}
+
+// BytesPerSecond returns the throughput in bytes per second
+func (r *Result) BytesPerSecond() float64 {
+	if r.Total <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Total.Seconds()
+}
+
+// Results is the output of a benchmark run
+type Results []*Result
+
+// Write the results as a table to out
+func (rs Results) Write(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Operation\tCount\tThroughput\tOps/s\tAvg latency\tMin latency\tMax latency\t")
+	for _, r := range rs {
+		throughput := "-"
+		if r.Bytes > 0 {
+			throughput = fs.SizeSuffix(r.BytesPerSecond()).Unit("Bytes/s")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%.2f\t%v\t%v\t%v\t\n",
+			r.Op, r.Count, throughput, r.OpsPerSecond(),
+			roundDuration(r.AvgLatency()), roundDuration(r.MinLatency), roundDuration(r.MaxLatency))
+	}
+	return w.Flush()
+}
+
+// roundDuration rounds d to a sensible precision for display
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d > time.Second:
+		return d - d%time.Millisecond
+	case d > time.Millisecond:
+		return d - d%time.Microsecond
+	}
+	return d
+}
+
+// benchmarkDir makes the name of the directory to use for the test
+// objects
+func benchmarkDir() string {
+	return fmt.Sprintf("rclone-benchmark-%d", time.Now().UnixNano())
+}
+
+// Benchmark uploads, lists, downloads and removes count objects of
+// each of sizes in a new directory on f, returning the timings.
+//
+// The directory and any objects left in it are removed before
+// returning, even on error.
+func Benchmark(f fs.Fs, sizes []fs.SizeSuffix, count int) (results Results, err error) {
+	dir := benchmarkDir()
+	var objects []fs.Object
+	defer func() {
+		cleanUp(f, dir, objects)
+	}()
+	err = f.Mkdir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make benchmark directory")
+	}
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, size := range sizes {
+		data := make([]byte, int64(size))
+		_, _ = rng.Read(data)
+
+		// Upload
+		put := &Result{Op: fmt.Sprintf("put %v", size)}
+		var sizeObjects []fs.Object
+		for i := 0; i < count; i++ {
+			remote := path.Join(dir, fmt.Sprintf("%v-%d", size, i))
+			src := fs.NewStaticObjectInfo(remote, time.Now(), int64(size), true, nil, f)
+			start := time.Now()
+			o, err := f.Put(bytes.NewReader(data), src)
+			put.add(time.Since(start), int64(size))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to upload %q", remote)
+			}
+			sizeObjects = append(sizeObjects, o)
+			objects = append(objects, o)
+		}
+		results = append(results, put)
+
+		// Download
+		get := &Result{Op: fmt.Sprintf("get %v", size)}
+		for _, o := range sizeObjects {
+			start := time.Now()
+			n, err := download(o)
+			get.add(time.Since(start), n)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to download %q", o.Remote())
+			}
+			if n != int64(size) {
+				return nil, errors.Errorf("downloaded %d bytes from %q, expecting %d", n, o.Remote(), int64(size))
+			}
+		}
+		results = append(results, get)
+	}
+
+	// List the directory with all the objects in
+	list := &Result{Op: fmt.Sprintf("list %d", len(objects))}
+	for i := 0; i < count; i++ {
+		start := time.Now()
+		entries, err := f.List(dir)
+		list.add(time.Since(start), 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list")
+		}
+		if len(entries) != len(objects) {
+			fs.Logf(f, "Listed %d objects, expecting %d - remote may be eventually consistent", len(entries), len(objects))
+		}
+	}
+	results = append(results, list)
+
+	// Remove
+	remove := &Result{Op: "remove"}
+	for len(objects) > 0 {
+		o := objects[0]
+		start := time.Now()
+		err := o.Remove()
+		remove.add(time.Since(start), 0)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to remove %q", o.Remote())
+		}
+		objects = objects[1:]
+	}
+	results = append(results, remove)
+	return results, nil
+}
+
+// download reads the whole of o returning the number of bytes read
+func download(o fs.Object) (n int64, err error) {
+	in, err := o.Open()
+	if err != nil {
+		return 0, err
+	}
+	n, err = io.Copy(ioutil.Discard, in)
+	closeErr := in.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// cleanUp removes objects and dir from f logging any errors
+func cleanUp(f fs.Fs, dir string, objects []fs.Object) {
+	for _, o := range objects {
+		err := o.Remove()
+		if err != nil {
+			fs.Errorf(o, "Failed to remove benchmark object: %v", err)
+		}
+	}
+	err := fs.TryRmdir(f, dir)
+	if err != nil {
+		fs.Errorf(f, "Failed to remove benchmark directory %q: %v", dir, err)
+	}
+}
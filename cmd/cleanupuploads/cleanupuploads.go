@@ -0,0 +1,45 @@
+package cleanupuploads
+
+import (
+	"time"
+
+	"github.com/ncw/rclone/cmd"
+	"github.com/ncw/rclone/fs"
+	"github.com/spf13/cobra"
+)
+
+// Globals
+var (
+	olderThan = 24 * time.Hour
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefintion)
+	commandDefintion.Flags().DurationVarP(&olderThan, "older-than", "", olderThan, "Only abort uploads started longer ago than this.")
+}
+
+var commandDefintion = &cobra.Command{
+	Use:   "cleanup-uploads remote:path",
+	Short: `Abort incomplete multipart uploads on the remote.`,
+	Long: `
+Failed or interrupted multipart uploads can leave parts on the remote
+which aren't visible in listings but are still charged for.
+
+This command lists the incomplete multipart uploads and aborts any
+which were started longer ago than --older-than (default 24h), freeing
+the storage used by their parts.  Be careful not to make --older-than
+so short that it aborts uploads which are still in progress.
+
+Use --dry-run to see which uploads would be aborted.
+
+This is only supported by remotes which support multipart uploads, eg
+s3 and b2.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		fsrc := cmd.NewFsSrc(args)
+		cmd.Run(true, false, command, func() error {
+			return fs.CleanUpUploads(fsrc, olderThan)
+		})
+	},
+}
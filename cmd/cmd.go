@@ -173,6 +173,18 @@ func NewFsSrcDst(args []string) (fs.Fs, fs.Fs) {
 	return fsrc, fdst
 }
 
+// NewFsSrcDsts creates a new src fs from the first argument and dst
+// fses from the rest
+func NewFsSrcDsts(args []string) (fs.Fs, []fs.Fs) {
+	fsrc, _ := newFsSrc(args[0])
+	var fdsts []fs.Fs
+	for _, arg := range args[1:] {
+		fdsts = append(fdsts, newFsDst(arg))
+	}
+	fs.CalculateModifyWindow(append(fdsts, fsrc)...)
+	return fsrc, fdsts
+}
+
 // NewFsSrcDstFiles creates a new src and dst fs from the arguments
 // If src is a file then srcFileName and dstFileName will be non-empty
 func NewFsSrcDstFiles(args []string) (fsrc fs.Fs, srcFileName string, fdst fs.Fs, dstFileName string) {
@@ -270,6 +282,7 @@ func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 	if showStats {
 		close(stopStats)
 	}
+	fs.CloseEventSocket()
 	if err != nil {
 		log.Fatalf("Failed to %s: %v", cmd.Name(), err)
 	}
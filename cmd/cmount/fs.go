@@ -153,6 +153,8 @@ func (fsys *FS) stat(node vfs.Node, stat *fuse.Stat_t) (errc int) {
 	Mode := node.Mode().Perm()
 	if node.IsDir() {
 		Mode |= fuse.S_IFDIR
+	} else if node.Mode()&os.ModeSymlink != 0 {
+		Mode |= fuse.S_IFLNK
 	} else {
 		Mode |= fuse.S_IFREG
 	}
@@ -226,13 +228,10 @@ func (fsys *FS) Readdir(dirPath string,
 		return translateError(err)
 	}
 
-	// Optionally, create a struct stat that describes the file as
-	// for getattr (but FUSE only looks at st_ino and the
-	// file-type bits of st_mode).
-	//
-	// FIXME If you call host.SetCapReaddirPlus() then WinFsp will
-	// use the full stat information - a Useful optimization on
-	// Windows.
+	// Pass the full stat of each entry, made from the directory
+	// listing, as for getattr.  FUSE only looks at st_ino and the
+	// file-type bits of st_mode, but WinFsp uses all of it as
+	// SetCapReaddirPlus is set, saving a Getattr per entry.
 	//
 	// NB we are using the first mode for readdir: The readdir
 	// implementation ignores the offset parameter, and passes
@@ -244,8 +243,9 @@ func (fsys *FS) Readdir(dirPath string,
 	for _, item := range items {
 		node, ok := item.(vfs.Node)
 		if ok {
-			name := path.Base(node.DirEntry().Remote())
-			fill(name, nil, 0)
+			var stat fuse.Stat_t
+			_ = fsys.stat(node, &stat)
+			fill(node.Name(), &stat, 0)
 		}
 	}
 	itemsRead = len(items)
@@ -274,6 +274,14 @@ func (fsys *FS) Statfs(path string, stat *fuse.Statfs_t) (errc int) {
 	stat.Bsize = blockSize  // Block size
 	stat.Namemax = 255      // Maximum file name length?
 	stat.Frsize = blockSize // Fragment size, smallest addressable data size in the file system.
+	total, _, free := fsys.VFS.Statfs()
+	if total >= 0 {
+		stat.Blocks = uint64(total) / blockSize
+	}
+	if free >= 0 {
+		stat.Bfree = uint64(free) / blockSize
+		stat.Bavail = stat.Bfree
+	}
 	return 0
 }
 
@@ -298,7 +306,13 @@ func (fsys *FS) Create(filePath string, flags int, mode uint32) (errc int, fh ui
 	if errc != 0 {
 		return errc, fhUnset
 	}
-	_, handle, err := parentDir.Create(leaf)
+	var handle *vfs.WriteFileHandle
+	var err error
+	if flags&fuse.O_EXCL != 0 {
+		_, handle, err = parentDir.CreateExclusive(leaf)
+	} else {
+		_, handle, err = parentDir.Create(leaf)
+	}
 	if err != nil {
 		return translateError(err), fhUnset
 	}
@@ -308,10 +322,23 @@ func (fsys *FS) Create(filePath string, flags int, mode uint32) (errc int, fh ui
 // Truncate truncates a file to size
 func (fsys *FS) Truncate(path string, size int64, fh uint64) (errc int) {
 	defer fs.Trace(path, "size=%d, fh=0x%X", size, fh)("errc=%d", &errc)
+	if fh != fhUnset {
+		// Let the open handle truncate if it can
+		handle, handleErrc := fsys.getHandle(fh)
+		if handleErrc != 0 {
+			return handleErrc
+		}
+		if err := handle.Truncate(size); err != vfs.ENOSYS {
+			return translateError(err)
+		}
+	}
 	node, errc := fsys.getNode(path, fh)
 	if errc != 0 {
 		return errc
 	}
+	if file, ok := node.(*vfs.File); ok {
+		return translateError(file.Truncate(size))
+	}
 	// Read the size so far
 	currentSize := node.Size()
 	fs.Debugf(path, "truncate to %d, currentSize %d", size, currentSize)
@@ -439,22 +466,46 @@ func (fsys *FS) Fsync(path string, datasync bool, fh uint64) (errc int) {
 	return 0
 }
 
-// Link creates a hard link to a file.
+// Link creates a hard link to a file.  As remotes can't have hard
+// links this is a copy of the file.
 func (fsys *FS) Link(oldpath string, newpath string) (errc int) {
 	defer fs.Trace(oldpath, "newpath=%q", newpath)("errc=%d", &errc)
-	return -fuse.ENOSYS
+	oldLeaf, oldParentDir, errc := fsys.lookupParentDir(oldpath)
+	if errc != 0 {
+		return errc
+	}
+	newLeaf, newParentDir, errc := fsys.lookupParentDir(newpath)
+	if errc != 0 {
+		return errc
+	}
+	_, err := oldParentDir.Link(oldLeaf, newLeaf, newParentDir)
+	return translateError(err)
 }
 
 // Symlink creates a symbolic link.
 func (fsys *FS) Symlink(target string, newpath string) (errc int) {
 	defer fs.Trace(target, "newpath=%q", newpath)("errc=%d", &errc)
-	return -fuse.ENOSYS
+	leaf, parentDir, errc := fsys.lookupParentDir(newpath)
+	if errc != 0 {
+		return errc
+	}
+	_, err := parentDir.Symlink(leaf, target)
+	return translateError(err)
 }
 
 // Readlink reads the target of a symbolic link.
 func (fsys *FS) Readlink(path string) (errc int, linkPath string) {
 	defer fs.Trace(path, "")("linkPath=%q, errc=%d", &linkPath, &errc)
-	return -fuse.ENOSYS, ""
+	node, err := fsys.VFS.Stat(path)
+	if err != nil {
+		return translateError(err), ""
+	}
+	file, ok := node.(*vfs.File)
+	if !ok {
+		return -fuse.EINVAL, ""
+	}
+	linkPath, err = file.Readlink()
+	return translateError(err), linkPath
 }
 
 // Chmod changes the permission bits of a file.
@@ -485,24 +536,64 @@ func (fsys *FS) Fsyncdir(path string, datasync bool, fh uint64) (errc int) {
 	return 0
 }
 
+// lookup a File to use its extended attributes returning ENOTSUP
+// for directories
+func (fsys *FS) lookupXattrFile(path string) (file *vfs.File, errc int) {
+	file, errc = fsys.lookupFile(path)
+	if errc == -fuse.EISDIR {
+		return nil, -fuse.ENOTSUP
+	}
+	return file, errc
+}
+
 // Setxattr sets extended attributes.
 func (fsys *FS) Setxattr(path string, name string, value []byte, flags int) (errc int) {
-	return -fuse.ENOSYS
+	defer fs.Trace(path, "name=%q", name)("errc=%d", &errc)
+	file, errc := fsys.lookupXattrFile(path)
+	if errc != 0 {
+		return errc
+	}
+	return translateError(file.Setxattr(name, value))
 }
 
 // Getxattr gets extended attributes.
 func (fsys *FS) Getxattr(path string, name string) (errc int, value []byte) {
-	return -fuse.ENOSYS, nil
+	defer fs.Trace(path, "name=%q", name)("errc=%d", &errc)
+	file, errc := fsys.lookupXattrFile(path)
+	if errc != 0 {
+		return errc, nil
+	}
+	value, err := file.Getxattr(name)
+	return translateError(err), value
 }
 
 // Removexattr removes extended attributes.
 func (fsys *FS) Removexattr(path string, name string) (errc int) {
-	return -fuse.ENOSYS
+	defer fs.Trace(path, "name=%q", name)("errc=%d", &errc)
+	file, errc := fsys.lookupXattrFile(path)
+	if errc != 0 {
+		return errc
+	}
+	return translateError(file.Removexattr(name))
 }
 
 // Listxattr lists extended attributes.
 func (fsys *FS) Listxattr(path string, fill func(name string) bool) (errc int) {
-	return -fuse.ENOSYS
+	defer fs.Trace(path, "")("errc=%d", &errc)
+	file, errc := fsys.lookupXattrFile(path)
+	if errc != 0 {
+		return errc
+	}
+	names, err := file.Listxattr()
+	if err != nil {
+		return translateError(err)
+	}
+	for _, name := range names {
+		if !fill(name) {
+			return -fuse.ERANGE
+		}
+	}
+	return 0
 }
 
 // Translate errors from mountlib
@@ -531,6 +622,16 @@ func translateError(err error) (errc int) {
 		return -fuse.EROFS
 	case vfs.ENOSYS:
 		return -fuse.ENOSYS
+	case vfs.EINVAL:
+		return -fuse.EINVAL
+	case vfs.EMFILE:
+		return -fuse.EMFILE
+	case vfs.ENOTSUP:
+		return -fuse.ENOTSUP
+	case vfs.ENOATTR:
+		return -fuse.ENOATTR
+	case vfs.ENAMETOOLONG:
+		return -fuse.ENAMETOOLONG
 	}
 	fs.Errorf(nil, "IO error: %v", err)
 	return -fuse.EIO
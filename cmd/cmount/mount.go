@@ -109,6 +109,7 @@ func mount(f fs.Fs, mountpoint string) (*vfs.VFS, <-chan error, func() error, er
 	// Create underlying FS
 	fsys := NewFS(f)
 	host := fuse.NewFileSystemHost(fsys)
+	host.SetCapReaddirPlus(true)
 
 	// Create options
 	options := mountOptions(f.Name()+":"+f.Root(), mountpoint)
@@ -186,7 +187,7 @@ waitloop:
 		// umount triggered outside the app
 		case err = <-errChan:
 			break waitloop
-		// user sent SIGHUP to clear the cache
+		// user sent SIGHUP to clear the cache and reload the options
 		case <-sigHup:
 			root, err := FS.Root()
 			if err != nil {
@@ -194,6 +195,7 @@ waitloop:
 			} else {
 				root.ForgetAll()
 			}
+			vfsflags.Reload(FS)
 		}
 	}
 
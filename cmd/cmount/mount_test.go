@@ -29,6 +29,8 @@ func TestDirCacheFlush(t *testing.T)              { notWin(t); mounttest.TestDir
 func TestDirCacheFlushOnDirRename(t *testing.T)   { notWin(t); mounttest.TestDirCacheFlushOnDirRename(t) }
 func TestFileModTime(t *testing.T)                { notWin(t); mounttest.TestFileModTime(t) }
 func TestFileModTimeWithOpenWriters(t *testing.T) {} // FIXME mounttest.TestFileModTimeWithOpenWriters(t)
+func TestFileModTimeOverwrite(t *testing.T)       { notWin(t); mounttest.TestFileModTimeOverwrite(t) }
+func TestFileSymlink(t *testing.T)                { notWin(t); mounttest.TestFileSymlink(t) }
 func TestMount(t *testing.T)                      { notWin(t); mounttest.TestMount(t) }
 func TestRoot(t *testing.T)                       { notWin(t); mounttest.TestRoot(t) }
 func TestReadByByte(t *testing.T)                 { notWin(t); mounttest.TestReadByByte(t) }
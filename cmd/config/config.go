@@ -16,6 +16,7 @@ func init() {
 	configCommand.AddCommand(configCreateCommand)
 	configCommand.AddCommand(configUpdateCommand)
 	configCommand.AddCommand(configDeleteCommand)
+	configCommand.AddCommand(configRenameCommand)
 }
 
 var configCommand = &cobra.Command{
@@ -121,3 +122,19 @@ var configDeleteCommand = &cobra.Command{
 		fs.DeleteRemote(args[0])
 	},
 }
+
+var configRenameCommand = &cobra.Command{
+	Use:   "rename <name> <newname>",
+	Short: `Rename an existing remote <name> to <newname>.`,
+	Long: `
+Rename an existing remote of <name> to <newname>.
+
+Any crypt remotes which wrap it with ` + "`remote = name:path`" + ` will
+be updated to refer to <newname>.  Other values in the config file
+aren't changed.
+`,
+	RunE: func(command *cobra.Command, args []string) error {
+		cmd.CheckArgs(2, 2, command, args)
+		return fs.RenameRemoteTo(args[0], args[1])
+	},
+}
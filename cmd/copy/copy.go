@@ -11,7 +11,7 @@ func init() {
 }
 
 var commandDefintion = &cobra.Command{
-	Use:   "copy source:path dest:path",
+	Use:   "copy source:path dest:path [dest:path...]",
 	Short: `Copy files from source to dest, skipping already copied`,
 	Long: `
 Copy the source to the destination.  Doesn't transfer
@@ -52,9 +52,26 @@ source or destination.
 
 See the ` + "`--no-traverse`" + ` option for controlling whether rclone lists
 the destination directory or not.
+
+If more than one destination is given then the source is copied to
+all of them at once, reading each file from the source only once.
+
+    rclone copy source:path dest1:path dest2:path
+
+This is useful for replicating to several places without the cost of
+reading the source several times.  A failure to copy to one
+destination doesn't stop the copies to the others.  Empty directories
+aren't copied in this mode.
 `,
 	Run: func(command *cobra.Command, args []string) {
-		cmd.CheckArgs(2, 2, command, args)
+		cmd.CheckArgs(2, 1E9, command, args)
+		if len(args) > 2 {
+			fsrc, fdsts := cmd.NewFsSrcDsts(args)
+			cmd.Run(true, true, command, func() error {
+				return fs.CopyDirMulti(fdsts, fsrc)
+			})
+			return
+		}
 		fsrc, fdst := cmd.NewFsSrcDst(args)
 		cmd.Run(true, true, command, func() error {
 			return fs.CopyDir(fdst, fsrc)
@@ -0,0 +1,46 @@
+package fixmarkers
+
+import (
+	"github.com/ncw/rclone/cmd"
+	"github.com/ncw/rclone/fs"
+	"github.com/spf13/cobra"
+)
+
+// Globals
+var (
+	removeEmpty = false
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefintion)
+	commandDefintion.Flags().BoolVarP(&removeEmpty, "remove-empty", "", removeEmpty, "Remove the markers of directories with no objects in, deleting the empty directories.")
+}
+
+var commandDefintion = &cobra.Command{
+	Use:   "fix-markers remote:path",
+	Short: `Remove duplicate directory markers on the remote.`,
+	Long: `
+Some object stores, eg swift, can store directories as zero length
+marker objects.  If these are created or deleted by other tools they
+can get out of step with the objects stored.
+
+This command removes the extra markers of directories which have more
+than one, eg both ` + "`dir`" + ` and ` + "`dir/`" + `.  Directories which
+contain objects exist whether they have a marker or not so no markers
+are created for them.
+
+The marker of a directory which contains no objects is what keeps
+that empty directory in existence, so these are left alone.  If
+--remove-empty is set then they are removed too, which deletes all
+the empty directories and any phantom ones left behind by other tools.
+
+Use --dry-run to see which markers would be removed.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		fsrc := cmd.NewFsSrc(args)
+		cmd.Run(true, false, command, func() error {
+			return fs.FixDirMarkers(fsrc, removeEmpty)
+		})
+	},
+}
@@ -16,6 +16,7 @@ import (
 var (
 	recurse   bool
 	showHash  bool
+	showClass bool
 	noModTime bool
 )
 
@@ -23,17 +24,54 @@ func init() {
 	cmd.Root.AddCommand(commandDefintion)
 	commandDefintion.Flags().BoolVarP(&recurse, "recursive", "R", false, "Recurse into the listing.")
 	commandDefintion.Flags().BoolVarP(&showHash, "hash", "", false, "Include hashes in the output (may take longer).")
+	commandDefintion.Flags().BoolVarP(&showClass, "show-class", "", false, "Include the storage class of objects in the output if known.")
 	commandDefintion.Flags().BoolVarP(&noModTime, "no-modtime", "", false, "Don't read the modification time (can speed things up).")
 }
 
 // lsJSON in the struct which gets marshalled for each line
 type lsJSON struct {
-	Path    string
-	Name    string
-	Size    int64
-	ModTime Timestamp //`json:",omitempty"`
-	IsDir   bool
-	Hashes  map[string]string `json:",omitempty"`
+	Path         string
+	Name         string
+	Size         int64
+	ModTime      Timestamp //`json:",omitempty"`
+	IsDir        bool
+	Hashes       map[string]string `json:",omitempty"`
+	StorageClass string            `json:",omitempty"`
+}
+
+// newItem makes the lsJSON for entry
+func newItem(entry fs.DirEntry) lsJSON {
+	item := lsJSON{
+		Path: entry.Remote(),
+		Name: path.Base(entry.Remote()),
+		Size: entry.Size(),
+	}
+	if !noModTime {
+		item.ModTime = Timestamp(entry.ModTime())
+	}
+	switch x := entry.(type) {
+	case fs.Directory:
+		item.IsDir = true
+	case fs.Object:
+		item.IsDir = false
+		if showHash {
+			item.Hashes = make(map[string]string)
+			for _, hashType := range x.Fs().Hashes().Array() {
+				hash, err := x.Hash(hashType)
+				if err != nil {
+					fs.Errorf(x, "Failed to read hash: %v", err)
+				} else if hash != "" {
+					item.Hashes[hashType.String()] = hash
+				}
+			}
+		}
+		if showClass {
+			item.StorageClass = fs.StorageClass(x)
+		}
+	default:
+		fs.Errorf(nil, "Unknown type %T in listing", entry)
+	}
+	return item
 }
 
 // Timestamp a time in RFC3339 format with Nanosecond precision secongs
@@ -70,6 +108,10 @@ The output is an array of Items, where each Item looks like this
 
 If --hash is not specified the the Hashes property won't be emitted.
 
+If --show-class is specified then the storage class of each object,
+eg STANDARD or GLACIER, is emitted as StorageClass for remotes which
+support it.
+
 If --no-modtime is specified then ModTime will be blank.
 
 The time is in RFC3339 format with nanosecond precision.
@@ -90,33 +132,7 @@ can be processed line by line as each item is written one to a line.
 					return nil
 				}
 				for _, entry := range entries {
-					item := lsJSON{
-						Path: entry.Remote(),
-						Name: path.Base(entry.Remote()),
-						Size: entry.Size(),
-					}
-					if !noModTime {
-						item.ModTime = Timestamp(entry.ModTime())
-					}
-					switch x := entry.(type) {
-					case fs.Directory:
-						item.IsDir = true
-					case fs.Object:
-						item.IsDir = false
-						if showHash {
-							item.Hashes = make(map[string]string)
-							for _, hashType := range x.Fs().Hashes().Array() {
-								hash, err := x.Hash(hashType)
-								if err != nil {
-									fs.Errorf(x, "Failed to read hash: %v", err)
-								} else if hash != "" {
-									item.Hashes[hashType.String()] = hash
-								}
-							}
-						}
-					default:
-						fs.Errorf(nil, "Unknown type %T in listing", entry)
-					}
+					item := newItem(entry)
 					out, err := json.Marshal(item)
 					if err != nil {
 						return errors.Wrap(err, "failed to marshal list object")
@@ -0,0 +1,49 @@
+package lsjson
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// classObject is an Object which reports a storage class
+type classObject struct {
+	fs.Object
+	remote string
+	class  string
+}
+
+func (o *classObject) Remote() string       { return o.remote }
+func (o *classObject) Size() int64          { return 1 }
+func (o *classObject) ModTime() time.Time   { return time.Unix(1, 0) }
+func (o *classObject) StorageClass() string { return o.class }
+
+func TestNewItemStorageClass(t *testing.T) {
+	o := &classObject{remote: "dir/file.txt", class: "GLACIER"}
+
+	// Not shown unless asked for
+	item := newItem(o)
+	assert.Equal(t, "", item.StorageClass)
+	out, err := json.Marshal(item)
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "StorageClass")
+
+	showClass = true
+	defer func() { showClass = false }()
+	item = newItem(o)
+	assert.Equal(t, "GLACIER", item.StorageClass)
+	assert.Equal(t, "file.txt", item.Name)
+	assert.False(t, item.IsDir)
+	out, err = json.Marshal(item)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"StorageClass":"GLACIER"`)
+
+	// Directories don't have a storage class
+	item = newItem(fs.NewDir("dir", time.Unix(1, 0)))
+	assert.True(t, item.IsDir)
+	assert.Equal(t, "", item.StorageClass)
+}
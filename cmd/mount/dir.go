@@ -4,7 +4,6 @@ package mount
 
 import (
 	"os"
-	"path"
 	"time"
 
 	"bazil.org/fuse"
@@ -18,6 +17,15 @@ import (
 // Dir represents a directory entry
 type Dir struct {
 	*vfs.Dir
+	fsys *FS
+}
+
+// Check interface satisfied
+var _ fusefs.NodeForgetter = (*Dir)(nil)
+
+// Forget is called when the kernel has forgotten the directory
+func (d *Dir) Forget() {
+	d.fsys.forgetDir(d)
 }
 
 // Check interface satsified
@@ -28,7 +36,8 @@ func (d *Dir) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 	defer fs.Trace(d, "")("attr=%+v, err=%v", a, &err)
 	a.Gid = d.VFS().Opt.GID
 	a.Uid = d.VFS().Opt.UID
-	a.Mode = os.ModeDir | d.VFS().Opt.DirPerms
+	a.Inode = d.Inode()
+	a.Mode = d.Dir.Mode()
 	modTime := d.ModTime()
 	a.Atime = modTime
 	a.Mtime = modTime
@@ -77,7 +86,7 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 	case *vfs.File:
 		return &File{x}, nil
 	case *vfs.Dir:
-		return &Dir{x}, nil
+		return d.fsys.dir(x), nil
 	}
 	panic("bad type")
 }
@@ -95,18 +104,21 @@ func (d *Dir) ReadDirAll(ctx context.Context) (dirents []fuse.Dirent, err error)
 	}
 	for _, item := range items {
 		var dirent fuse.Dirent
-		switch x := item.DirEntry().(type) {
-		case fs.Object:
+		switch item.(type) {
+		case *vfs.File:
 			dirent = fuse.Dirent{
-				// Inode FIXME ???
-				Type: fuse.DT_File,
-				Name: path.Base(x.Remote()),
+				Inode: item.Inode(),
+				Type:  fuse.DT_File,
+				Name:  item.Name(),
+			}
+			if item.Mode()&os.ModeSymlink != 0 {
+				dirent.Type = fuse.DT_Link
 			}
-		case fs.Directory:
+		case *vfs.Dir:
 			dirent = fuse.Dirent{
-				// Inode FIXME ???
-				Type: fuse.DT_Dir,
-				Name: path.Base(x.Remote()),
+				Inode: item.Inode(),
+				Type:  fuse.DT_Dir,
+				Name:  item.Name(),
 			}
 		default:
 			return nil, errors.Errorf("unknown type %T", item)
@@ -122,7 +134,13 @@ var _ fusefs.NodeCreater = (*Dir)(nil)
 // Create makes a new file
 func (d *Dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.CreateResponse) (node fusefs.Node, handle fusefs.Handle, err error) {
 	defer fs.Trace(d, "name=%q", req.Name)("node=%v, handle=%v, err=%v", &node, &handle, &err)
-	file, fh, err := d.Dir.Create(req.Name)
+	var file *vfs.File
+	var fh *vfs.WriteFileHandle
+	if req.Flags&fuse.OpenExclusive != 0 {
+		file, fh, err = d.Dir.CreateExclusive(req.Name)
+	} else {
+		file, fh, err = d.Dir.Create(req.Name)
+	}
 	if err != nil {
 		return nil, nil, translateError(err)
 	}
@@ -138,7 +156,36 @@ func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (node fusefs.No
 	if err != nil {
 		return nil, translateError(err)
 	}
-	return &Dir{dir}, nil
+	return d.fsys.dir(dir), nil
+}
+
+var _ fusefs.NodeSymlinker = (*Dir)(nil)
+
+// Symlink creates a new symbolic link in the receiver
+func (d *Dir) Symlink(ctx context.Context, req *fuse.SymlinkRequest) (node fusefs.Node, err error) {
+	defer fs.Trace(d, "name=%q, target=%q", req.NewName, req.Target)("node=%+v, err=%v", &node, &err)
+	file, err := d.Dir.Symlink(req.NewName, req.Target)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &File{file}, nil
+}
+
+var _ fusefs.NodeLinker = (*Dir)(nil)
+
+// Link creates a new directory entry in the receiver which is a hard
+// link to old.  As remotes can't have hard links this is a copy of old.
+func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fusefs.Node) (node fusefs.Node, err error) {
+	defer fs.Trace(d, "newName=%q, old=%+v", req.NewName, old)("node=%+v, err=%v", &node, &err)
+	oldFile, ok := old.(*File)
+	if !ok {
+		return nil, fuse.EPERM
+	}
+	file, err := oldFile.Dir().Link(oldFile.Name(), req.NewName, d.Dir)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &File{file}, nil
 }
 
 var _ fusefs.NodeRemover = (*Dir)(nil)
@@ -28,7 +28,8 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 	Blocks := (Size + 511) / 512
 	a.Gid = f.VFS().Opt.GID
 	a.Uid = f.VFS().Opt.UID
-	a.Mode = f.VFS().Opt.FilePerms
+	a.Inode = f.File.Inode()
+	a.Mode = f.File.Mode()
 	a.Size = Size
 	a.Atime = modTime
 	a.Mtime = modTime
@@ -41,9 +42,16 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 // Check interface satisfied
 var _ fusefs.NodeSetattrer = (*File)(nil)
 
-// Setattr handles attribute changes from FUSE. Currently supports ModTime only.
+// Setattr handles attribute changes from FUSE. Currently supports
+// Size and ModTime only.
 func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) (err error) {
 	defer fs.Trace(f, "a=%+v", req)("err=%v", &err)
+	if req.Valid.Size() {
+		err = f.File.Truncate(int64(req.Size))
+		if err != nil {
+			return translateError(err)
+		}
+	}
 	if f.VFS().Opt.NoModTime {
 		return nil
 	}
@@ -55,6 +63,16 @@ func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse
 	return translateError(err)
 }
 
+// Check interface satisfied
+var _ fusefs.NodeReadlinker = (*File)(nil)
+
+// Readlink returns the target of a symlink
+func (f *File) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (target string, err error) {
+	defer fs.Trace(f, "")("target=%q, err=%v", &target, &err)
+	target, err = f.File.Readlink()
+	return target, translateError(err)
+}
+
 // Check interface satisfied
 var _ fusefs.NodeOpener = (*File)(nil)
 
@@ -87,3 +105,47 @@ func (f *File) Fsync(ctx context.Context, req *fuse.FsyncRequest) (err error) {
 	defer fs.Trace(f, "")("err=%v", &err)
 	return nil
 }
+
+// Check interface satisfied
+var _ fusefs.NodeGetxattrer = (*File)(nil)
+
+// Getxattr gets an extended attribute by the given name from the
+// node.
+func (f *File) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) (err error) {
+	defer fs.Trace(f, "name=%q", req.Name)("err=%v", &err)
+	resp.Xattr, err = f.File.Getxattr(req.Name)
+	return translateError(err)
+}
+
+// Check interface satisfied
+var _ fusefs.NodeListxattrer = (*File)(nil)
+
+// Listxattr lists the extended attributes recorded for the node.
+func (f *File) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) (err error) {
+	defer fs.Trace(f, "")("err=%v", &err)
+	names, err := f.File.Listxattr()
+	if err != nil {
+		return translateError(err)
+	}
+	resp.Append(names...)
+	return nil
+}
+
+// Check interface satisfied
+var _ fusefs.NodeSetxattrer = (*File)(nil)
+
+// Setxattr sets an extended attribute with the given name and
+// value for the node.
+func (f *File) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) (err error) {
+	defer fs.Trace(f, "name=%q", req.Name)("err=%v", &err)
+	return translateError(f.File.Setxattr(req.Name, req.Xattr))
+}
+
+// Check interface satisfied
+var _ fusefs.NodeRemovexattrer = (*File)(nil)
+
+// Removexattr removes an extended attribute for the name.
+func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) (err error) {
+	defer fs.Trace(f, "name=%q", req.Name)("err=%v", &err)
+	return translateError(f.File.Removexattr(req.Name))
+}
@@ -5,6 +5,7 @@
 package mount
 
 import (
+	"sync"
 	"syscall"
 
 	"bazil.org/fuse"
@@ -19,7 +20,10 @@ import (
 // FS represents the top level filing system
 type FS struct {
 	*vfs.VFS
-	f fs.Fs
+	f      fs.Fs
+	server *fusefs.Server
+	mu     sync.Mutex        // protects the following
+	dirs   map[*vfs.Dir]*Dir // the Dirs the kernel knows about
 }
 
 // Check interface satistfied
@@ -28,12 +32,59 @@ var _ fusefs.FS = (*FS)(nil)
 // NewFS makes a new FS
 func NewFS(f fs.Fs) *FS {
 	fsys := &FS{
-		VFS: vfs.New(f, &vfsflags.Opt),
-		f:   f,
+		VFS:  vfs.New(f, &vfsflags.Opt),
+		f:    f,
+		dirs: make(map[*vfs.Dir]*Dir),
 	}
 	return fsys
 }
 
+// dir returns the Dir for vfsDir, making it if necessary.
+//
+// The same Dir is returned each time while the kernel remembers it so
+// that it can be told when the directory changes.
+func (f *FS) dir(vfsDir *vfs.Dir) *Dir {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d := f.dirs[vfsDir]
+	if d == nil {
+		d = &Dir{Dir: vfsDir, fsys: f}
+		f.dirs[vfsDir] = d
+	}
+	return d
+}
+
+// forgetDir removes d once the kernel has forgotten it
+func (f *FS) forgetDir(d *Dir) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.dirs[d.Dir] == d {
+		delete(f.dirs, d.Dir)
+	}
+}
+
+// invalidate tells the kernel to forget the entries in vfsDir which
+// have changed on the remote so they are looked up again.
+func (f *FS) invalidate(vfsDir *vfs.Dir, nodes vfs.Nodes) {
+	f.mu.Lock()
+	d := f.dirs[vfsDir]
+	f.mu.Unlock()
+	if d == nil || f.server == nil {
+		// the kernel doesn't know about the directory
+		return
+	}
+	for _, node := range nodes {
+		err := f.server.InvalidateEntry(d, node.Name())
+		if err != nil && err != fuse.ErrNotCached {
+			fs.Debugf(d, "Failed to invalidate %q: %v", node.Name(), err)
+		}
+	}
+	err := f.server.InvalidateNodeData(d)
+	if err != nil && err != fuse.ErrNotCached {
+		fs.Debugf(d, "Failed to invalidate directory: %v", err)
+	}
+}
+
 // Root returns the root node
 func (f *FS) Root() (node fusefs.Node, err error) {
 	defer fs.Trace("", "")("node=%+v, err=%v", &node, &err)
@@ -41,7 +92,7 @@ func (f *FS) Root() (node fusefs.Node, err error) {
 	if err != nil {
 		return nil, translateError(err)
 	}
-	return &Dir{root}, nil
+	return f.dir(root), nil
 }
 
 // Check interface satsified
@@ -61,6 +112,14 @@ func (f *FS) Statfs(ctx context.Context, req *fuse.StatfsRequest, resp *fuse.Sta
 	resp.Bsize = blockSize  // Block size
 	resp.Namelen = 255      // Maximum file name length?
 	resp.Frsize = blockSize // Fragment size, smallest addressable data size in the file system.
+	total, _, free := f.VFS.Statfs()
+	if total >= 0 {
+		resp.Blocks = uint64(total) / blockSize
+	}
+	if free >= 0 {
+		resp.Bfree = uint64(free) / blockSize
+		resp.Bavail = resp.Bfree
+	}
 	return nil
 }
 
@@ -90,6 +149,16 @@ func translateError(err error) error {
 		return fuse.Errno(syscall.EROFS)
 	case vfs.ENOSYS:
 		return fuse.ENOSYS
+	case vfs.EINVAL:
+		return fuse.Errno(syscall.EINVAL)
+	case vfs.EMFILE:
+		return fuse.Errno(syscall.EMFILE)
+	case vfs.ENOTSUP:
+		return fuse.Errno(syscall.ENOTSUP)
+	case vfs.ENOATTR:
+		return fuse.ErrNoXattr
+	case vfs.ENAMETOOLONG:
+		return fuse.Errno(syscall.ENAMETOOLONG)
 	}
 	return err
 }
@@ -80,6 +80,8 @@ func mount(f fs.Fs, mountpoint string) (*vfs.VFS, <-chan error, func() error, er
 
 	filesys := NewFS(f)
 	server := fusefs.New(c, nil)
+	filesys.server = server
+	filesys.VFS.AddChangeNotify(filesys.invalidate)
 
 	// Serve the mount point in the background returning error to errChan
 	errChan := make(chan error, 1)
@@ -136,7 +138,7 @@ waitloop:
 		case <-sigInt:
 			err = unmount()
 			break waitloop
-		// user sent SIGHUP to clear the cache
+		// user sent SIGHUP to clear the cache and reload the options
 		case <-sigHup:
 			root, err := FS.Root()
 			if err != nil {
@@ -144,6 +146,7 @@ waitloop:
 			} else {
 				root.ForgetAll()
 			}
+			vfsflags.Reload(FS)
 		}
 	}
 
@@ -20,6 +20,8 @@ func TestDirCacheFlush(t *testing.T)              { mounttest.TestDirCacheFlush(
 func TestDirCacheFlushOnDirRename(t *testing.T)   { mounttest.TestDirCacheFlushOnDirRename(t) }
 func TestFileModTime(t *testing.T)                { mounttest.TestFileModTime(t) }
 func TestFileModTimeWithOpenWriters(t *testing.T) { mounttest.TestFileModTimeWithOpenWriters(t) }
+func TestFileModTimeOverwrite(t *testing.T)       { mounttest.TestFileModTimeOverwrite(t) }
+func TestFileSymlink(t *testing.T)                { mounttest.TestFileSymlink(t) }
 func TestMount(t *testing.T)                      { mounttest.TestMount(t) }
 func TestRoot(t *testing.T)                       { mounttest.TestRoot(t) }
 func TestReadByByte(t *testing.T)                 { mounttest.TestReadByByte(t) }
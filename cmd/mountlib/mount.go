@@ -89,6 +89,34 @@ This can only write files seqentially, it can only seek when reading.
 This means that many applications won't work with their files on an
 rclone mount.
 
+Files being written are streamed to the remote as the data arrives
+rather than being stored on disk first, so writing the output of a
+pipe needs only a small amount of memory however large it is.  Remotes
+which can't upload files of unknown size have files larger than
+` + "`--streaming-upload-cutoff`" + ` spooled to a temporary file on
+local disk and uploaded from there when they are closed.
+
+Files can be opened for append (` + "`O_APPEND`" + `) as long as they
+are no larger than ` + "`--streaming-upload-cutoff`" + ` as the
+existing contents are read into memory and uploaded again with the
+new data.  Opening larger files for append fails with a permission
+error rather than truncating them.
+
+Renaming files uses the remote's server side move.  If the remote
+can't move files then they are copied to the new name and the
+original deleted, which isn't atomic and can be slow for large files.
+
+Exclusive creates (` + "`O_CREAT|O_EXCL`" + `), as used for lock files,
+check the remote as well as the directory cache and fail if the file
+exists.  This is reliable between processes using the same rclone
+mount, but another rclone could still create the same file at the
+same time, so it is only best effort across different rclones.
+
+Byte range locks taken with ` + "`fcntl`" + ` are handled by the
+kernel on Linux, FreeBSD and macOS, so they work between processes on
+the same machine using the mount, but aren't seen by other rclones or
+other machines.
+
 The bucket based remotes (eg Swift, S3, Google Compute Storage, B2,
 Hubic) won't work from the root - you will need to specify a bucket,
 or a path within the bucket.  So ` + "`swift:`" + ` won't work whereas
@@ -108,11 +136,54 @@ can't use retries in the same way without making local copies of the
 uploads.  This might happen in the future, but for the moment rclone
 ` + commandName + ` won't do that, so will be less reliable than the rclone command.
 
+Closing a file waits for its upload to the remote to finish and
+returns an error if the upload failed.  If the
+` + "`--vfs-write-back-sync`" + ` flag is set then the size and hashes
+of the uploaded file are checked against the data written too, and
+close returns an error if they don't match.  Only the size is checked
+on remotes which don't support hashes.  The data isn't kept after it
+is uploaded so the upload can't be retried.  Note that many
+applications ignore errors from close.
+
 ### Filters ###
 
 Note that all the rclone filters can be used to select a subset of the
 files to be visible in the mount.
 
+Files which are excluded by the filters can't be opened.  Creating
+files or directories, or renaming or copying files, to names the
+filters would exclude fails with a permission error rather than making
+files which would then vanish from the mount.  Only the name rules
+are used for these checks as the size and age aren't known yet.
+
+Some remotes limit the length of file names or paths, eg Box allows
+names of up to 255 bytes and S3 paths of up to 1024 bytes.  Creating,
+renaming or copying to names longer than the remote allows fails
+straight away with a "file name too long" error, rather than when the
+file is closed and uploaded.  Paths include the part of the remote
+above the mount point, and renaming a directory checks the paths of
+everything in it.  Crypt remotes lower the name limit of the remote
+they wrap to allow for the encrypted names being longer.
+
+### Read only ###
+
+If the ` + "`--read-only`" + ` flag is set then opening files for
+write, creating or removing files and directories, renaming and
+setting modification times all fail with a read-only file system
+error.  This is enforced by rclone itself rather than relying on the
+operating system, so the same flag works for the ` + "`rclone serve`" + `
+commands too.
+
+### Dry run ###
+
+If the ` + "`--vfs-dry-run`" + ` flag is set then changes to the remote
+are logged instead of being made, which is useful for testing how an
+application behaves with the mount.  Files can be written, created,
+renamed and removed as normal and the changes show up in the mount,
+but they are forgotten when the directory cache expires.  The data
+written is thrown away so new files can't be read back, and the
+contents of renamed directories aren't shown.
+
 ### Directory Cache ###
 
 Using the ` + "`--dir-cache-time`" + ` flag, you can set how long a
@@ -127,9 +198,267 @@ Assuming only one rclone instance is running, you can reset the cache
 like this:
 
     kill -SIGHUP $(pidof rclone)
+
+If ` + "`--vfs-options-file`" + ` is set, ` + "`SIGHUP`" + ` also re-reads the
+flags in that file, one or more to a line, on top of the ones given on
+the command line.  Lines starting with ` + "`#`" + ` are ignored.  Only
+` + "`--dir-cache-time`" + ` and ` + "`--vfs-bwlimit`" + ` can be changed
+this way without a remount.  Changes to any other flag, including
+` + "`--poll-interval`" + ` as polling can't be stopped once started, are
+logged and ignored.  For example with a file containing
+
+    --dir-cache-time 1m
+    --vfs-bwlimit 1M
+
+sending ` + "`SIGHUP`" + ` makes the mount cache directories for a minute
+and limits its bandwidth to 1 MByte/s.  Removing the lines and sending
+` + "`SIGHUP`" + ` again puts them back to their values on the command line.
+
+The size, modification time and permissions of each file and
+directory come from the directory listing, so listing a directory
+then looking at every entry in it, as file browsers do, only reads
+the directory from the remote once.  On Windows these are passed back
+with the listing so each entry doesn't need to be looked up at all.
+
+Directories show the modification time the remote gives them, and
+this is updated each time the directory they are in is read again.
+Remotes which don't store times for directories, eg S3 and Swift,
+show the time the mount was started instead, so the times don't change
+while it is running.
+
+The directory cache grows as more directories are read, which can use
+a lot of memory on remotes with millions of directories.  Set
+` + "`--dir-cache-max-entries`" + ` to limit the number of directory
+listings cached.  When the limit is exceeded the least recently used
+listings are forgotten, and read again from the remote when next
+needed.  Listings of directories with files open in them or with
+subdirectories which are still cached are kept, so the limit may be
+exceeded temporarily.
+
+On remotes which support it, eg Google Drive, rclone polls for
+changes every ` + "`--poll-interval`" + ` and forgets the cached
+listings of the directories which have changed.  With ` + "`rclone mount`" + `
+on Linux, FreeBSD and macOS the kernel is told to forget the entries
+in those directories too, so applications see the new files next time
+they look.  Changes to directories which aren't cached are ignored.
+
+If the ` + "`--vfs-serve-stale-on-error`" + ` flag is set then rclone
+will carry on serving the cached directory listing if the remote
+fails when the directory is re-read, for instance if it is
+temporarily unreachable.  The failure is logged and the directory is
+re-read in the background until it succeeds.  Note that only
+directory listings are cached, so reading file contents will still
+fail while the remote is unavailable.
+
+Some remotes, eg S3 and Swift, are eventually consistent, so a file
+which has just been written or deleted may not show up correctly in
+a listing straight away.  On these remotes a directory which has been
+changed through the mount isn't read again from the remote until
+` + "`--vfs-consistency-window`" + ` (default 10s) has passed since
+the last change, even if its cache has expired, so files don't seem
+to vanish just after being written.  Set it to 0 to disable this.
+
+### Read retries ###
+
+If reading a file from the remote fails, for instance because of a
+flaky network connection, then rclone will re-open the file at the
+current offset and retry the read, waiting a little longer each time.
+An error is only returned to the application once
+` + "`--vfs-read-retries`" + ` retries have failed in a row, which
+defaults to ` + "`--low-level-retries`" + ` if not set.
+A successful read resets the count for that file handle.  Errors which
+retrying can't fix, such as the file having been deleted from the
+remote, are returned straight away.
+
+Reads are served from a buffer which is filled in the background
+ahead of the application, so large sequential reads such as playing a
+video don't wait on the remote for each block.  By default this reads
+up to ` + "`--buffer-size`" + ` ahead, which can be changed for the
+mount with ` + "`--vfs-read-ahead`" + `.  A larger value smooths out
+slow or bursty remotes at the cost of memory for each open file.
+Seeking discards the buffer and starts filling it again from the new
+position.
+
+### Open files ###
+
+Each file open for reading or writing holds a connection open to the
+remote.  If the ` + "`--vfs-max-open-files`" + ` flag is set then at
+most that many are held open at once.  When the limit is reached the
+least recently used file open for reading is closed to make room,
+and it is reopened transparently at the same place the next time it
+is read.  Files open for writing can't be closed like this, so if all
+the open files are being written, opening another returns a "too many
+open files" error.
+
+Applications which crash without closing their files can leave them
+open until rclone exits, and files open for writing aren't uploaded
+until they are closed.  If ` + "`--vfs-handle-idle-timeout`" + ` is set
+then files which haven't been read or written for that long are
+closed, and uploaded if they were open for writing, eg
+` + "`--vfs-handle-idle-timeout 1h`" + `.  Any later reads or writes
+on the closed files fail.
+
+### Bandwidth limit ###
+
+The ` + "`--vfs-bwlimit`" + ` flag limits the bandwidth used by reads
+and writes through this mount, eg ` + "`--vfs-bwlimit 1M`" + ` for 1
+MByte/s shared between all the open files.  This is applied as well
+as any global ` + "`--bwlimit`" + `, so whichever is stricter wins.
+Unlike ` + "`--bwlimit`" + ` it only affects this mount, so other
+rclone commands using the same remote aren't slowed down.
+
+### Upload chunk size ###
+
+Remotes which upload large files in chunks normally use their own
+chunk size.  The ` + "`--vfs-upload-chunk-size`" + ` flag sets the
+chunk size for uploads through this mount only, eg
+` + "`--vfs-upload-chunk-size 64M`" + `.  Bigger chunks mean fewer
+round trips, which helps on high latency links, but use more memory.
+This is currently only used by S3 and is ignored by other remotes.
+
+### Mime types ###
+
+Remotes which store a mime type for each file, such as S3, Google
+Cloud Storage and Google Drive, are given one for files written to the
+mount.  It is worked out from the file's extension, or if the
+extension isn't a known one, from the first 512 bytes of the data
+written.  Use ` + "`--vfs-no-mime-sniff`" + ` to use only the extension,
+in which case files without a known one get
+` + "`application/octet-stream`" + `.
+
+### Disk space ###
+
+The size and free space of the mount, as shown by ` + "`df`" + ` or
+the drive properties on Windows, come from the quota of the remote if
+it has one.  Otherwise the mount is shown as very large, which confuses
+some applications which check the free space before writing.  For
+these set ` + "`--vfs-disk-space-total-size`" + `, eg
+` + "`--vfs-disk-space-total-size 1T`" + `, to set the size to report.
+The quota is read at most once every ` + "`--dir-cache-time`" + `.
+
+### Trash ###
+
+Files deleted in the mount are normally deleted on the remote in the
+same way as ` + "`rclone delete`" + ` would, so whether they can be
+recovered depends on the remote's own settings (eg
+` + "`--drive-use-trash`" + `).  With ` + "`--vfs-use-trash`" + ` files
+deleted in the mount are always sent to the remote's trash or recycle
+bin, where they can be restored from.  At the moment this is supported
+with Google Drive and OneDrive.  On other remotes files are deleted as
+usual.
+
+### Encrypted path ###
+
+With ` + "`--vfs-crypt-path`" + ` the contents of the files in one path
+on the remote are encrypted, while the rest of the mount is left in
+the clear, eg ` + "`--vfs-crypt-path secret --vfs-crypt-remote mycrypt`" + `.
+The passwords are read from the config of the crypt remote given with
+` + "`--vfs-crypt-remote`" + `, and the data is encrypted in the same
+way as the crypt remote does.  Only the contents of files are
+encrypted, not their names, so the files can't be read with the crypt
+remote itself.  Files renamed into or out of the path are copied so
+they are encrypted or decrypted, but directories can't be.  Checksums
+aren't checked for the encrypted files.
+
+If the passwords can't be read then the mount fails to start.
+
+### Extended attributes ###
+
+Extended attributes set on files in the mount are stored as metadata
+on the remote, so applications which keep their own metadata in them
+work.  At the moment this is supported with the local backend on
+Linux and with S3, where they are stored as user metadata.  On other
+remotes, and for directories, getting or setting extended attributes
+returns an "operation not supported" error.  S3 metadata keys are
+case insensitive and the values must be printable text.
+
+### Permissions ###
+
+All files and directories are normally shown with the same
+permissions (0666 and 0777 less the ` + "`--umask`" + `).  Use
+` + "`--vfs-perms glob=perms`" + ` to give the files and directories
+matching ` + "`glob`" + ` different permissions.  The globs are the
+same as those used in the [filters](/filtering/), and as there
+directories are matched with a ` + "`/`" + ` on the end.  The flag can
+be repeated and the first matching rule is used, eg
+
+    --vfs-perms "/bin/**=0755" --vfs-perms "*.txt=0444"
+
+makes the ` + "`bin`" + ` directory and everything in it executable
+and all other ` + "`.txt`" + ` files read only.  The ` + "`--umask`" + `
+isn't applied to these permissions.
+
+### Case insensitivity ###
+
+Most remotes, eg S3, are case sensitive, but many Windows and macOS
+applications expect files to be found whatever case is used to open
+them.  If the ` + "`--vfs-case-insensitive`" + ` flag is set then a
+name which isn't found exactly is looked up again ignoring case.  If
+several names only differ by case then an exact match is always used
+if there is one, otherwise the first in sorted order is used and a
+warning is logged.
+
+### Name transforms ###
+
+Names on the remote may contain characters which the operating system
+the mount is used from doesn't allow, eg Windows doesn't allow
+` + "`:`" + ` or ` + "`?`" + ` in file names.  Setting
+` + "`--vfs-name-transform windows`" + ` shows these characters as
+their FULLWIDTH unicode equivalents, eg ` + "`a:b`" + ` on the remote
+is shown as ` + "`a：b`" + `, and turns them back again when files are
+looked up, created or renamed.  Names on the remote which already
+contain the FULLWIDTH characters have them quoted with ` + "`‛`" + `
+so every name survives the round trip unchanged.
+
+### Symlinks ###
+
+Remotes can't normally store symlinks, so making one in the mount
+fails.  If the ` + "`--vfs-links`" + ` flag is set then symlinks are
+stored as small files containing the target with ` + "`.rclonelink`" + `
+on the end of their name, eg a symlink ` + "`link`" + ` is stored as
+` + "`link.rclonelink`" + `.  These files are shown as symlinks in the
+mount without the ` + "`.rclonelink`" + `.  Without the flag they are
+shown as ordinary files containing the target.
+
+### Hard links ###
+
+Remotes can't have two paths referring to the same object, so hard
+links (` + "`ln`" + ` without ` + "`-s`" + `) make a copy of the file
+with a server side copy if the remote can do one, and fail with
+"function not implemented" otherwise.  The copy is a separate file
+with its own inode number - changes to one of them aren't seen in the
+other.  Directories can't be hard linked.
+
+### Control file ###
+
+If the ` + "`--control-file`" + ` flag is set then rclone exposes a
+virtual file ` + "`.rclone/command`" + ` in the root of the mount.
+Writing a command line to it runs the command and reading it returns
+the status of the last command run, for example
+
+    echo "forget path/to/dir" > /path/to/local/mount/.rclone/command
+    cat /path/to/local/mount/.rclone/command
+
+The commands understood are
+
+  * ` + "`forget [path...]`" + ` - forget the directory cache for the paths given, or everything
+  * ` + "`flush`" + ` - flush all the directory caches, the same as sending SIGHUP
+  * ` + "`help`" + ` - show the available commands
+
+Forgetting the path of a directory forgets it and the directories
+below it, and forgetting the path of a file forgets the directory it
+is in.  With ` + "`rclone mount`" + ` on Linux, FreeBSD and macOS the
+kernel is told to forget the entries too, as it is for changes found
+by polling, so applications see them straight away.
+
+Note that this hides any ` + "`.rclone`" + ` directory in the root of the remote.
 `,
 		Run: func(command *cobra.Command, args []string) {
 			cmd.CheckArgs(2, 2, command, args)
+			err := vfsflags.Opt.Check()
+			if err != nil {
+				log.Fatalf("Fatal error: %v", err)
+			}
 			fdst := cmd.NewFsDst(args)
 
 			// Show stats if the user has specifically requested them
@@ -138,7 +467,7 @@ like this:
 				defer close(stopStats)
 			}
 
-			err := Mount(fdst, args[1])
+			err = Mount(fdst, args[1])
 			if err != nil {
 				log.Fatalf("Fatal error: %v", err)
 			}
@@ -2,9 +2,11 @@ package mounttest
 
 import (
 	"os"
+	"runtime"
 	"testing"
 	"time"
 
+	"github.com/ncw/rclone/vfs/vfsflags"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -55,3 +57,75 @@ func TestFileModTimeWithOpenWriters(t *testing.T) {
 
 	run.rm(t, "cp-archive-test")
 }
+
+// TestFileModTimeOverwrite tests the mod time set on a file being
+// overwritten is kept on the remote
+func TestFileModTimeOverwrite(t *testing.T) {
+	run.skipIfNoFUSE(t)
+
+	run.createFile(t, "file", "123")
+
+	mtime := time.Date(2012, 11, 18, 17, 32, 31, 0, time.UTC)
+	filepath := run.path("file")
+
+	f, err := os.OpenFile(filepath, os.O_WRONLY|os.O_TRUNC, 0600)
+	require.NoError(t, err)
+
+	_, err = f.Write([]byte("4567"))
+	require.NoError(t, err)
+
+	err = os.Chtimes(filepath, mtime, mtime)
+	require.NoError(t, err)
+
+	err = f.Close()
+	require.NoError(t, err)
+
+	o, err := run.fremote.NewObject("file")
+	require.NoError(t, err)
+
+	// avoid errors because of timezone differences
+	assert.Equal(t, o.ModTime().Unix(), mtime.Unix())
+
+	run.rm(t, "file")
+}
+
+// TestFileSymlink tests making and reading symlinks with --vfs-links
+func TestFileSymlink(t *testing.T) {
+	run.skipIfNoFUSE(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks not supported on windows")
+	}
+
+	// Remount with --vfs-links
+	run.umount()
+	vfsflags.Opt.LinkFiles = true
+	run.mount()
+	defer func() {
+		run.umount()
+		vfsflags.Opt.LinkFiles = false
+		run.mount()
+	}()
+
+	run.createFile(t, "file", "123")
+	err := os.Symlink("file", run.path("link"))
+	require.NoError(t, err)
+
+	target, err := os.Readlink(run.path("link"))
+	require.NoError(t, err)
+	assert.Equal(t, "file", target)
+	info, err := os.Lstat(run.path("link"))
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode()&os.ModeSymlink)
+
+	// Reading through the link reads the target
+	assert.Equal(t, "123", run.readFile(t, "link"))
+
+	// Check the link is stored as a file on the remote
+	o, err := run.fremote.NewObject("link.rclonelink")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("file")), o.Size())
+
+	run.rm(t, "link")
+	run.rm(t, "file")
+	run.checkDir(t, "")
+}
@@ -217,19 +217,20 @@ func (r *Run) readLocal(t *testing.T, dir dirMap, filepath string) {
 
 // reads the remote tree into dir
 func (r *Run) readRemote(t *testing.T, dir dirMap, filepath string) {
-	objs, dirs, err := fs.WalkGetAll(r.fremote, filepath, true, 1)
-	if err == fs.ErrorDirNotFound {
-		return
-	}
-	require.NoError(t, err)
-	for _, obj := range objs {
-		dir[fmt.Sprintf("%s %d", obj.Remote(), obj.Size())] = struct{}{}
-	}
-	for _, d := range dirs {
+	err := fs.WalkCallback(r.fremote, filepath, true, 1, func(obj fs.Object, d fs.Directory) error {
+		if obj != nil {
+			dir[fmt.Sprintf("%s %d", obj.Remote(), obj.Size())] = struct{}{}
+			return nil
+		}
 		name := d.Remote()
 		dir[name+"/"] = struct{}{}
 		r.readRemote(t, dir, name)
+		return nil
+	})
+	if err == fs.ErrorDirNotFound {
+		return
 	}
+	require.NoError(t, err)
 }
 
 // checkDir checks the local and remote against the string passed in
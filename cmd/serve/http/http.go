@@ -3,6 +3,7 @@ package http
 import (
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
 	"path"
@@ -41,6 +42,10 @@ IPs.  By default it only listens on localhost.
 You can use the filter flags (eg --include, --exclude) to control what
 is served.
 
+Single range requests (eg for resuming downloads) are supported and
+return partial content.  Requests for multiple ranges are answered
+with the whole file, as are all requests if --no-seek is set.
+
 The server will log errors.  Use -v to see access logs.
 
 --bwlimit will be respected for file transfers.  Use --stats to
@@ -171,9 +176,8 @@ func (s *server) serveDir(w http.ResponseWriter, r *http.Request, dirRemote stri
 
 	var out entries
 	for _, node := range dirEntries {
-		obj := node.DirEntry()
-		remote := strings.Trim(obj.Remote(), "/")
-		leaf := path.Base(remote)
+		leaf := node.Name()
+		remote := path.Join(dirRemote, leaf)
 		urlRemote := leaf
 		if node.IsDir() {
 			leaf += "/"
@@ -212,14 +216,17 @@ func (s *server) serveFile(w http.ResponseWriter, r *http.Request, remote string
 		http.Error(w, "Not a file", http.StatusNotFound)
 		return
 	}
-	obj := node.DirEntry().(fs.Object)
 	file := node.(*vfs.File)
 
 	// Set content length since we know how long the object is
 	w.Header().Set("Content-Length", strconv.FormatInt(node.Size(), 10))
 
-	// Set content type
-	mimeType := fs.MimeType(obj)
+	// Set content type - the object may not exist yet if it is
+	// still being written
+	mimeType := fs.MimeTypeFromName(remote)
+	if obj, ok := node.DirEntry().(fs.Object); ok {
+		mimeType = fs.MimeType(obj)
+	}
 	if mimeType == "application/octet-stream" && path.Ext(remote) == "" {
 		// Leave header blank so http server guesses
 	} else {
@@ -231,6 +238,13 @@ func (s *server) serveFile(w http.ResponseWriter, r *http.Request, remote string
 		return
 	}
 
+	// Multiple ranges would need the file reading from the remote
+	// once for each range, so send the whole file instead which is
+	// allowed by RFC 7233
+	if strings.Contains(r.Header.Get("Range"), ",") {
+		r.Header.Del("Range")
+	}
+
 	// open the object
 	in, err := file.OpenRead()
 	if err != nil {
@@ -249,6 +263,19 @@ func (s *server) serveFile(w http.ResponseWriter, r *http.Request, remote string
 	defer fs.Stats.DoneTransferring(remote, true)
 	// FIXME in = fs.NewAccount(in, obj).WithBuffer() // account the transfer
 
-	// Serve the file
+	// If seeking is disabled ranges can't be served so send the
+	// whole file
+	if s.vfs.Opt.NoSeek {
+		w.Header().Set("Last-Modified", node.ModTime().UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		_, err = io.Copy(w, in)
+		if err != nil {
+			fs.Errorf(remote, "Failed to write file: %v", err)
+		}
+		return
+	}
+
+	// Serve the file - this deals with Range requests by seeking
+	// the file handle and returns 206 Partial Content
 	http.ServeContent(w, r, remote, node.ModTime(), in)
 }
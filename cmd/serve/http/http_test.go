@@ -5,12 +5,14 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/ncw/rclone/fs"
 	_ "github.com/ncw/rclone/local"
+	"github.com/ncw/rclone/vfs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -78,11 +80,12 @@ func checkGolden(t *testing.T, fileName string, got []byte) {
 
 func TestGET(t *testing.T) {
 	for _, test := range []struct {
-		URL    string
-		Status int
-		Golden string
-		Method string
-		Range  string
+		URL          string
+		Status       int
+		Golden       string
+		Method       string
+		Range        string
+		ContentRange string
 	}{
 		{
 			URL:    "",
@@ -154,22 +157,31 @@ func TestGET(t *testing.T) {
 			Golden: "testdata/golden/two.txt",
 		},
 		{
-			URL:    "two.txt",
-			Status: http.StatusPartialContent,
-			Range:  "bytes=2-5",
-			Golden: "testdata/golden/two2-5.txt",
+			URL:          "two.txt",
+			Status:       http.StatusPartialContent,
+			Range:        "bytes=2-5",
+			ContentRange: "bytes 2-5/11",
+			Golden:       "testdata/golden/two2-5.txt",
 		},
 		{
-			URL:    "two.txt",
-			Status: http.StatusPartialContent,
-			Range:  "bytes=0-6",
-			Golden: "testdata/golden/two-6.txt",
+			URL:          "two.txt",
+			Status:       http.StatusPartialContent,
+			Range:        "bytes=0-6",
+			ContentRange: "bytes 0-6/11",
+			Golden:       "testdata/golden/two-6.txt",
+		},
+		{
+			URL:          "two.txt",
+			Status:       http.StatusPartialContent,
+			Range:        "bytes=3-",
+			ContentRange: "bytes 3-10/11",
+			Golden:       "testdata/golden/two3-.txt",
 		},
 		{
 			URL:    "two.txt",
-			Status: http.StatusPartialContent,
-			Range:  "bytes=3-",
-			Golden: "testdata/golden/two3-.txt",
+			Status: http.StatusOK,
+			Range:  "bytes=0-1,3-4",
+			Golden: "testdata/golden/two.txt",
 		},
 	} {
 		method := test.Method
@@ -184,9 +196,32 @@ func TestGET(t *testing.T) {
 		resp, err := http.DefaultClient.Do(req)
 		require.NoError(t, err)
 		assert.Equal(t, test.Status, resp.StatusCode, test.Golden)
+		assert.Equal(t, test.ContentRange, resp.Header.Get("Content-Range"), test.Golden)
 		body, err := ioutil.ReadAll(resp.Body)
 		require.NoError(t, err)
 
 		checkGolden(t, test.Golden, body)
 	}
 }
+
+func TestGETNoSeek(t *testing.T) {
+	f, err := fs.NewFs("testdata/files")
+	require.NoError(t, err)
+	opt := vfs.DefaultOpt
+	opt.NoSeek = true
+	s := &server{
+		f:   f,
+		vfs: vfs.New(f, &opt),
+	}
+
+	// Range requests can't be served without seeking so get the
+	// whole file
+	req := httptest.NewRequest("GET", "/two.txt", nil)
+	req.Header.Add("Range", "bytes=2-5")
+	w := httptest.NewRecorder()
+	s.handler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Header().Get("Content-Range"))
+	assert.Equal(t, "11", w.Header().Get("Content-Length"))
+	checkGolden(t, "testdata/golden/two.txt", w.Body.Bytes())
+}
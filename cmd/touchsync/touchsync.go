@@ -0,0 +1,42 @@
+package touchsync
+
+import (
+	"github.com/ncw/rclone/cmd"
+	"github.com/ncw/rclone/fs"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefintion)
+}
+
+var commandDefintion = &cobra.Command{
+	Use:   "touch-sync source:path dest:path",
+	Short: `Set the modification times in dest to match the source.`,
+	Long: `
+Sets the modification times of the files in the destination to match
+the files in the source without transferring any data.  Use this to
+repair modification times which have drifted, for instance after
+copying with a tool which doesn't preserve them.
+
+Only files which have the same size and hash (MD5 or SHA1) in the
+source and destination are altered, and a count of how many were
+corrected is logged at the end.  Files only in one of the source or
+destination are ignored.
+
+If the source and destination don't share a hash then files are
+skipped unless you supply the --size-only flag, in which case files of
+the same size will have their modification times set.
+
+Use the --dry-run flag to see which modification times would be
+corrected without setting them.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(2, 2, command, args)
+		fsrc, fdst := cmd.NewFsSrcDst(args)
+		cmd.Run(true, false, command, func() error {
+			_, err := fs.SyncModTimes(fdst, fsrc)
+			return err
+		})
+	},
+}
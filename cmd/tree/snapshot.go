@@ -0,0 +1,270 @@
+// Export and compare snapshots of a remote's directory structure
+
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// SnapshotItem is a single entry in a JSON snapshot of a tree
+type SnapshotItem struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+	Hashes  map[string]string `json:",omitempty"`
+}
+
+// walkSorted calls fn for every entry in dir and below in a depth
+// first order with the entries of each directory sorted by name.
+// This is the order compareSnapshotPaths sorts in.
+//
+// Only one directory listing per level is held in memory at once.
+func walkSorted(f fs.Fs, dir string, maxLevel int, fn func(entry fs.DirEntry) error) error {
+	entries, err := fs.ListDirSorted(f, false, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err = fn(entry)
+		if err != nil {
+			return err
+		}
+		if _, ok := entry.(fs.Directory); ok && maxLevel != 1 {
+			err = walkSorted(f, entry.Remote(), maxLevel-1, fn)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compareSnapshotPaths compares the paths a and b one path segment at
+// a time returning -1, 0 or +1.  This means a directory sorts
+// immediately before its contents.
+func compareSnapshotPaths(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] < bs[i] {
+			return -1
+		} else if as[i] > bs[i] {
+			return 1
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// newSnapshotItem makes a SnapshotItem from entry reading the hashes
+// in hashTypes if it is an object
+func newSnapshotItem(entry fs.DirEntry, hashTypes []fs.HashType) SnapshotItem {
+	item := SnapshotItem{
+		Path:    entry.Remote(),
+		Size:    entry.Size(),
+		ModTime: entry.ModTime(),
+	}
+	switch x := entry.(type) {
+	case fs.Directory:
+		item.IsDir = true
+	case fs.Object:
+		for _, hashType := range hashTypes {
+			hash, err := x.Hash(hashType)
+			if err != nil {
+				fs.Errorf(x, "Failed to read hash: %v", err)
+			} else if hash != "" {
+				if item.Hashes == nil {
+					item.Hashes = make(map[string]string)
+				}
+				item.Hashes[hashType.String()] = hash
+			}
+		}
+	}
+	return item
+}
+
+// Snapshot writes a JSON snapshot of the tree in f to out, including
+// the hashes if showHash is set.
+//
+// The output is a JSON array with one item on each line written as
+// the remote is listed so it can be used on large trees.
+func Snapshot(f fs.Fs, out io.Writer, maxLevel int, showHash bool) error {
+	var hashTypes []fs.HashType
+	if showHash {
+		hashTypes = f.Hashes().Array()
+	}
+	_, err := fmt.Fprintln(out, "[")
+	if err != nil {
+		return err
+	}
+	first := true
+	err = walkSorted(f, "", maxLevel, func(entry fs.DirEntry) error {
+		buf, err := json.Marshal(newSnapshotItem(entry, hashTypes))
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal snapshot item")
+		}
+		if !first {
+			_, err = fmt.Fprintln(out, ",")
+			if err != nil {
+				return err
+			}
+		}
+		first = false
+		_, err = out.Write(buf)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !first {
+		_, err = fmt.Fprintln(out)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(out, "]")
+	return err
+}
+
+// snapshotReader reads SnapshotItems one at a time from a JSON snapshot
+type snapshotReader struct {
+	dec *json.Decoder
+}
+
+// newSnapshotReader starts reading the snapshot in in
+func newSnapshotReader(in io.Reader) (*snapshotReader, error) {
+	dec := json.NewDecoder(in)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot")
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("failed to read snapshot: expecting a JSON array")
+	}
+	return &snapshotReader{dec: dec}, nil
+}
+
+// next returns the next item or nil if there are no more
+func (r *snapshotReader) next() (*SnapshotItem, error) {
+	if !r.dec.More() {
+		return nil, nil
+	}
+	item := new(SnapshotItem)
+	err := r.dec.Decode(item)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot item")
+	}
+	return item, nil
+}
+
+// snapshotDiffer describes how a snapshot item differs from the live
+// one, returning an empty string if they are the same
+func snapshotDiffer(old, live *SnapshotItem) string {
+	if old.IsDir != live.IsDir {
+		if old.IsDir {
+			return "was a directory, is now a file"
+		}
+		return "was a file, is now a directory"
+	}
+	if old.IsDir {
+		return ""
+	}
+	if old.Size != live.Size {
+		return fmt.Sprintf("size changed from %d to %d", old.Size, live.Size)
+	}
+	for hashType, hash := range old.Hashes {
+		if liveHash, ok := live.Hashes[hashType]; ok && liveHash != hash {
+			return fmt.Sprintf("%s changed from %s to %s", hashType, hash, liveHash)
+		}
+	}
+	dt := live.ModTime.Sub(old.ModTime)
+	if dt >= fs.Config.ModifyWindow || dt <= -fs.Config.ModifyWindow {
+		return fmt.Sprintf("modification time changed from %v to %v", old.ModTime, live.ModTime)
+	}
+	return ""
+}
+
+// SnapshotDiff compares the JSON snapshot read from in, as written by
+// Snapshot, with the tree in f.  It writes a line to out for each
+// difference found and returns the number of differences.
+//
+// Lines start with "-" for items only in the snapshot, "+" for items
+// only in f and "*" for items which have changed.
+//
+// Both the snapshot and the remote are read in order so this uses a
+// bounded amount of memory.
+func SnapshotDiff(f fs.Fs, in io.Reader, out io.Writer, maxLevel int) (differences int, err error) {
+	r, err := newSnapshotReader(in)
+	if err != nil {
+		return 0, err
+	}
+	old, err := r.next()
+	if err != nil {
+		return 0, err
+	}
+	report := func(format string, a ...interface{}) error {
+		differences++
+		_, err := fmt.Fprintf(out, format+"\n", a...)
+		return err
+	}
+	hashTypes := f.Hashes().Array()
+	err = walkSorted(f, "", maxLevel, func(entry fs.DirEntry) (err error) {
+		// Items in the snapshot before this one have been removed
+		for old != nil && compareSnapshotPaths(old.Path, entry.Remote()) < 0 {
+			err = report("- %s", old.Path)
+			if err != nil {
+				return err
+			}
+			old, err = r.next()
+			if err != nil {
+				return err
+			}
+		}
+		if old == nil || old.Path != entry.Remote() {
+			return report("+ %s", entry.Remote())
+		}
+		// Only read the hashes which are in the snapshot
+		var types []fs.HashType
+		for _, hashType := range hashTypes {
+			if _, ok := old.Hashes[hashType.String()]; ok {
+				types = append(types, hashType)
+			}
+		}
+		live := newSnapshotItem(entry, types)
+		if why := snapshotDiffer(old, &live); why != "" {
+			err = report("* %s: %s", entry.Remote(), why)
+			if err != nil {
+				return err
+			}
+		}
+		old, err = r.next()
+		return err
+	})
+	if err != nil {
+		return differences, err
+	}
+	// Anything left in the snapshot has been removed
+	for old != nil {
+		err = report("- %s", old.Path)
+		if err != nil {
+			return differences, err
+		}
+		old, err = r.next()
+		if err != nil {
+			return differences, err
+		}
+	}
+	return differences, nil
+}
@@ -0,0 +1,95 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain drives the tests
+func TestMain(m *testing.M) {
+	fstest.TestMain(m)
+}
+
+func TestCompareSnapshotPaths(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{"a", "a", 0},
+		{"a", "b", -1},
+		{"b", "a", 1},
+		{"a", "a/b", -1},
+		{"a/b", "a b", -1},
+		{"a b", "a/b", 1},
+		{"a/b/c", "a/c", -1},
+	} {
+		assert.Equal(t, test.want, compareSnapshotPaths(test.a, test.b), test.a+" vs "+test.b)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	t1 := fstest.Time("2001-02-03T04:05:06.499999999Z")
+	t2 := fstest.Time("2011-12-25T12:59:59.123456789Z")
+	file1 := r.WriteObject("a", "a contents", t1)
+	file2 := r.WriteObject("dir/b", "b contents", t1)
+	file3 := r.WriteObject("dir/sub/c", "c contents", t1)
+	file4 := r.WriteObject("dir b", "dir b contents", t1)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3, file4)
+
+	var snapshot bytes.Buffer
+	require.NoError(t, Snapshot(r.Fremote, &snapshot, -1, true))
+
+	// Check it is valid JSON in the right order
+	var items []SnapshotItem
+	require.NoError(t, json.Unmarshal(snapshot.Bytes(), &items))
+	var paths []string
+	for _, item := range items {
+		paths = append(paths, item.Path)
+	}
+	assert.Equal(t, []string{"a", "dir", "dir/b", "dir/sub", "dir/sub/c", "dir b"}, paths)
+	assert.Equal(t, int64(10), items[0].Size)
+	assert.False(t, items[0].IsDir)
+	assert.True(t, items[1].IsDir)
+	if r.Fremote.Hashes().Contains(fs.HashMD5) {
+		assert.Equal(t, "e258b6ca405d94a518624445b91ade38", items[0].Hashes["MD5"])
+	}
+
+	// No differences against itself
+	var out bytes.Buffer
+	differences, err := SnapshotDiff(r.Fremote, bytes.NewReader(snapshot.Bytes()), &out, -1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, differences)
+	assert.Equal(t, "", out.String())
+
+	// Now modify the remote
+	r.WriteObject("dir/b", "b contents changed", t1)
+	r.WriteObject("dir/sub/c", "c contents", t2)
+	r.WriteObject("dir/new", "new contents", t1)
+	o, err := r.Fremote.NewObject("a")
+	require.NoError(t, err)
+	require.NoError(t, o.Remove())
+	// ... and check the differences are found
+	out.Reset()
+	differences, err = SnapshotDiff(r.Fremote, bytes.NewReader(snapshot.Bytes()), &out, -1)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 4, differences)
+	require.Equal(t, 4, len(lines), out.String())
+	assert.Equal(t, "- a", lines[0])
+	assert.Equal(t, "* dir/b: size changed from 10 to 18", lines[1])
+	assert.Equal(t, "+ dir/new", lines[2])
+	assert.True(t, strings.HasPrefix(lines[3], "* dir/sub/c: modification time changed from"), lines[3])
+
+	// Check a bad snapshot
+	_, err = SnapshotDiff(r.Fremote, strings.NewReader(`{"Path":"a"}`), &out, -1)
+	assert.EqualError(t, err, "failed to read snapshot: expecting a JSON array")
+}
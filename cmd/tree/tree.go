@@ -22,6 +22,8 @@ var (
 	outFileName string
 	noReport    bool
 	sort        string
+	jsonOutput  bool
+	showHash    bool
 )
 
 func init() {
@@ -59,6 +61,9 @@ func init() {
 	// Graphics
 	flags.BoolVarP(&opts.NoIndent, "noindent", "i", false, "Don't print indentation lines.")
 	flags.BoolVarP(&opts.Colorize, "color", "C", false, "Turn colorization on always.")
+	// Snapshot
+	flags.BoolVarP(&jsonOutput, "json", "", false, "Output a JSON snapshot of the tree for use with treediff.")
+	flags.BoolVarP(&showHash, "hash", "", false, "Include hashes in the --json output (may take longer).")
 }
 
 var commandDefintion = &cobra.Command{
@@ -87,6 +92,12 @@ You can use any of the filtering options with the tree command (eg
 The tree command has many options for controlling the listing which
 are compatible with the tree command.  Note that not all of them have
 short options as they conflict with rclone's short options.
+
+Use the --json flag to output a snapshot of the paths, sizes and
+modification times (and hashes if --hash is set) in the tree as JSON
+instead.  This is written as the remote is listed so can be used on
+large trees.  The snapshot can be compared with the remote later using
+"rclone treediff".
 `,
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
@@ -108,6 +119,9 @@ short options as they conflict with rclone's short options.
 			opts.DeepLevel = fs.Config.MaxDepth
 		}
 		cmd.Run(false, false, command, func() error {
+			if jsonOutput {
+				return Snapshot(fsrc, outFile, opts.DeepLevel, showHash)
+			}
 			return Tree(fsrc, outFile, &opts)
 		})
 	},
@@ -0,0 +1,55 @@
+package treediff
+
+import (
+	"os"
+
+	"github.com/ncw/rclone/cmd"
+	"github.com/ncw/rclone/cmd/tree"
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefintion)
+}
+
+var commandDefintion = &cobra.Command{
+	Use:   "treediff snapshot.json remote:path",
+	Short: `Compare a remote with a JSON snapshot made by "rclone tree --json".`,
+	Long: `
+Compares the directory structure of the remote with a JSON snapshot
+previously made with "rclone tree --json".  It prints a line for each
+difference found
+
+    - path      - in the snapshot but no longer on the remote
+    + path      - on the remote but not in the snapshot
+    * path: why - changed since the snapshot
+
+Files are compared by size, modification time and any hashes stored
+in the snapshot (made with "rclone tree --json --hash").
+
+The snapshot and the remote are read in order so this can be used on
+large trees.  Use the same filters and --max-depth as when making the
+snapshot.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(2, 2, command, args)
+		fsrc := cmd.NewFsSrc(args[1:])
+		cmd.Run(false, false, command, func() (err error) {
+			in, err := os.Open(args[0])
+			if err != nil {
+				return errors.Wrap(err, "failed to open snapshot")
+			}
+			defer fs.CheckClose(in, &err)
+			differences, err := tree.SnapshotDiff(fsrc, in, os.Stdout, fs.Config.MaxDepth)
+			if err != nil {
+				return err
+			}
+			if differences > 0 {
+				return errors.Errorf("%d differences found", differences)
+			}
+			return nil
+		})
+	},
+}
@@ -1142,3 +1142,31 @@ func TestKey(t *testing.T) {
 	assert.Equal(t, [32]byte{}, c.nameKey)
 	assert.Equal(t, [16]byte{}, c.nameTweak)
 }
+
+func TestMaxLengths(t *testing.T) {
+	for _, test := range []struct {
+		mode     NameEncryptionMode
+		maxName  int
+		maxPath  int
+		wantName int
+		wantPath int
+	}{
+		{NameEncryptionOff, 0, 0, 0, 0},
+		{NameEncryptionOff, 255, 1024, 251, 1020},
+		{NameEncryptionOff, 3, 0, 1, 0},
+		{NameEncryptionStandard, 0, 1024, 0, 0},
+		{NameEncryptionStandard, 255, 1024, 143, 0},
+		{NameEncryptionStandard, 10, 0, 15, 0},
+		{NameEncryptionObfuscated, 255, 1024, 0, 0},
+	} {
+		gotName, gotPath := maxLengths(test.mode, test.maxName, test.maxPath)
+		assert.Equal(t, test.wantName, gotName, fmt.Sprintf("%+v", test))
+		assert.Equal(t, test.wantPath, gotPath, fmt.Sprintf("%+v", test))
+	}
+
+	// Check the longest name allowed fits and one more doesn't
+	c, _ := newCipher(NameEncryptionStandard, "", "", true)
+	maxName, _ := maxLengths(NameEncryptionStandard, 255, 0)
+	assert.True(t, len(c.EncryptFileName(strings.Repeat("a", maxName))) <= 255)
+	assert.True(t, len(c.EncryptFileName(strings.Repeat("a", maxName+1))) > 255)
+}
@@ -68,8 +68,8 @@ func init() {
 	})
 }
 
-// NewFs contstructs an Fs from the path, container:path
-func NewFs(name, rpath string) (fs.Fs, error) {
+// newCipherForConfig makes a cipher from the config of the remote name
+func newCipherForConfig(name string) (*cipher, error) {
 	mode, err := NewNameEncryptionMode(fs.ConfigFileGet(name, "filename_encryption", "standard"))
 	if err != nil {
 		return nil, err
@@ -97,6 +97,22 @@ func NewFs(name, rpath string) (fs.Fs, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to make cipher")
 	}
+	return cipher, nil
+}
+
+// NewCipher makes a Cipher from the passwords and settings in the
+// config of the crypt remote name
+func NewCipher(name string) (Cipher, error) {
+	return newCipherForConfig(name)
+}
+
+// NewFs contstructs an Fs from the path, container:path
+func NewFs(name, rpath string) (fs.Fs, error) {
+	cipher, err := newCipherForConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	mode := cipher.mode
 	remote := fs.ConfigFileGet(name, "remote")
 	if strings.HasPrefix(remote, name+":") {
 		return nil, errors.New("can't point crypt remote at itself - check the value of the remote setting")
@@ -129,9 +145,47 @@ func NewFs(name, rpath string) (fs.Fs, error) {
 		BucketBased:             true,
 		CanHaveEmptyDirectories: true,
 	}).Fill(f).Mask(wrappedFs)
+	f.features.MaxNameLength, f.features.MaxPathLength = maxLengths(mode, f.features.MaxNameLength, f.features.MaxPathLength)
 	return f, err
 }
 
+// maxLengths returns the limits on the lengths of names and paths
+// given the limits of the wrapped remote, as the names stored there
+// are longer.  Limits which can't be worked out are dropped.
+func maxLengths(mode NameEncryptionMode, maxName, maxPath int) (int, int) {
+	switch mode {
+	case NameEncryptionOff:
+		// Only the suffix is added to file names
+		return reduceLimit(maxName, len(encryptedSuffix)), reduceLimit(maxPath, len(encryptedSuffix))
+	case NameEncryptionStandard:
+		// Names are padded to a whole number of blocks then
+		// base32 encoded.  Each name in a path is padded
+		// separately so the path limit depends on how many
+		// there are.
+		if maxName <= 0 {
+			return 0, 0
+		}
+		blocks := maxName * 5 / 8 / nameCipherBlockSize
+		if blocks < 1 {
+			blocks = 1
+		}
+		return blocks*nameCipherBlockSize - 1, 0
+	}
+	// Obfuscated names can grow by an unknown amount
+	return 0, 0
+}
+
+// reduceLimit returns limit reduced by n, or 0 if there is no limit
+func reduceLimit(limit, n int) int {
+	if limit <= 0 {
+		return 0
+	}
+	if limit <= n {
+		return 1
+	}
+	return limit - n
+}
+
 // Fs represents a wrapped fs.Fs
 type Fs struct {
 	fs.Fs
@@ -1402,12 +1402,24 @@ func (o *Object) Update(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOptio
 
 // Remove an object
 func (o *Object) Remove() error {
+	return o.remove(*driveUseTrash)
+}
+
+// Trash sends the object to the trash whatever --drive-use-trash is
+// set to
+func (o *Object) Trash() error {
+	return o.remove(true)
+}
+
+// remove sends the object to the trash if useTrash is set, otherwise
+// deletes it permanently
+func (o *Object) remove(useTrash bool) error {
 	if o.isDocument {
 		return errors.New("can't delete a google document")
 	}
 	var err error
 	err = o.fs.pacer.Call(func() (bool, error) {
-		if *driveUseTrash {
+		if useTrash {
 			_, err = o.fs.svc.Files.Trash(o.id).Fields(googleapi.Field(partialFields)).SupportsTeamDrives(o.fs.isTeamDrive).Do()
 		} else {
 			err = o.fs.svc.Files.Delete(o.id).Fields(googleapi.Field(partialFields)).SupportsTeamDrives(o.fs.isTeamDrive).Do()
@@ -1442,4 +1454,5 @@ var (
 	_ fs.MergeDirser       = (*Fs)(nil)
 	_ fs.Object            = (*Object)(nil)
 	_ fs.MimeTyper         = &Object{}
+	_ fs.Trasher           = &Object{}
 )
@@ -43,20 +43,25 @@ func newTokenBucket(bandwidth SizeSuffix) *rate.Limiter {
 // Start the token bucket if necessary
 func startTokenBucket() {
 	currLimitMu.Lock()
-	currLimit := bwLimit.LimitAt(time.Now())
+	currLimit = bwLimit.LimitAt(time.Now())
+	bandwidth := currLimit.bandwidth
 	currLimitMu.Unlock()
 
-	if currLimit.bandwidth > 0 {
-		tokenBucket = newTokenBucket(currLimit.bandwidth)
-		Infof(nil, "Starting bandwidth limiter at %vBytes/s", &currLimit.bandwidth)
+	if bandwidth > 0 {
+		tokenBucket = newTokenBucket(bandwidth)
+		Infof(nil, "Starting bandwidth limiter at %vBytes/s", &bandwidth)
+	}
 
-		// Start the SIGUSR2 signal handler to toggle bandwidth.
-		// This function does nothing in windows systems.
+	// Start the SIGUSR2 signal handler to toggle bandwidth, and
+	// SIGHUP to reset a timetable.  This function does nothing
+	// in windows systems.
+	if bandwidth > 0 || len(bwLimit) > 1 {
 		startSignalHandler()
 	}
 }
 
-// startTokenTicker creates a ticker to update the bandwidth limiter every minute.
+// startTokenTicker starts a goroutine to update the bandwidth limiter
+// as each time slot in the timetable starts.
 func startTokenTicker() {
 	// If the timetable has a single entry or was not specified, we don't need
 	// a ticker to update the bandwidth.
@@ -64,47 +69,82 @@ func startTokenTicker() {
 		return
 	}
 
-	ticker := time.NewTicker(time.Minute)
 	go func() {
-		for range ticker.C {
-			limitNow := bwLimit.LimitAt(time.Now())
-			currLimitMu.Lock()
-
-			if currLimit.bandwidth != limitNow.bandwidth {
-				tokenBucketMu.Lock()
-
-				// If bwlimit is toggled off, the change should only
-				// become active on the next toggle, which causes
-				// an exchange of tokenBucket <-> prevTokenBucket
-				var targetBucket **rate.Limiter
-				if bwLimitToggledOff {
-					targetBucket = &prevTokenBucket
-				} else {
-					targetBucket = &tokenBucket
-				}
-
-				// Set new bandwidth. If unlimited, set tokenbucket to nil.
-				if limitNow.bandwidth > 0 {
-					*targetBucket = newTokenBucket(limitNow.bandwidth)
-					if bwLimitToggledOff {
-						Logf(nil, "Scheduled bandwidth change. "+
-							"Limit will be set to %vBytes/s when toggled on again.", &limitNow.bandwidth)
-					} else {
-						Logf(nil, "Scheduled bandwidth change. Limit set to %vBytes/s", &limitNow.bandwidth)
-					}
-				} else {
-					*targetBucket = nil
-					Logf(nil, "Scheduled bandwidth change. Bandwidth limits disabled")
-				}
-
-				currLimit = limitNow
-				tokenBucketMu.Unlock()
+		for {
+			// Check at least every minute in case the wall
+			// clock jumps, eg after a suspend or a DST change
+			wait := time.Minute
+			now := time.Now()
+			if next := bwLimit.NextChange(now); !next.IsZero() && next.Sub(now) < wait {
+				wait = next.Sub(now)
 			}
-			currLimitMu.Unlock()
+			time.Sleep(wait)
+			updateTokenBucket(time.Now())
 		}
 	}()
 }
 
+// updateTokenBucket sets the bandwidth limiter to the time slot in
+// the timetable which is in force at now if it has changed.
+func updateTokenBucket(now time.Time) {
+	limitNow := bwLimit.LimitAt(now)
+	currLimitMu.Lock()
+	defer currLimitMu.Unlock()
+
+	if currLimit.bandwidth == limitNow.bandwidth {
+		return
+	}
+	tokenBucketMu.Lock()
+	defer tokenBucketMu.Unlock()
+
+	// If bwlimit is toggled off, the change should only
+	// become active on the next toggle, which causes
+	// an exchange of tokenBucket <-> prevTokenBucket
+	var targetBucket **rate.Limiter
+	if bwLimitToggledOff {
+		targetBucket = &prevTokenBucket
+	} else {
+		targetBucket = &tokenBucket
+	}
+
+	// Set new bandwidth. If unlimited, set tokenbucket to nil.
+	if limitNow.bandwidth > 0 {
+		*targetBucket = newTokenBucket(limitNow.bandwidth)
+		if bwLimitToggledOff {
+			Logf(nil, "Scheduled bandwidth change. "+
+				"Limit will be set to %vBytes/s when toggled on again.", &limitNow.bandwidth)
+		} else {
+			Logf(nil, "Scheduled bandwidth change. Limit set to %vBytes/s", &limitNow.bandwidth)
+		}
+	} else {
+		*targetBucket = nil
+		Logf(nil, "Scheduled bandwidth change. Bandwidth limits disabled")
+	}
+
+	currLimit = limitNow
+}
+
+// resetTokenBucket sets the bandwidth limiter back to the time slot
+// in the timetable which is in force at now, undoing any toggle.
+func resetTokenBucket(now time.Time) {
+	limitNow := bwLimit.LimitAt(now)
+	currLimitMu.Lock()
+	defer currLimitMu.Unlock()
+	tokenBucketMu.Lock()
+	defer tokenBucketMu.Unlock()
+
+	bwLimitToggledOff = false
+	prevTokenBucket = nil
+	if limitNow.bandwidth > 0 {
+		tokenBucket = newTokenBucket(limitNow.bandwidth)
+		Logf(nil, "Bandwidth limit reset to %vBytes/s", &limitNow.bandwidth)
+	} else {
+		tokenBucket = nil
+		Logf(nil, "Bandwidth limit reset to unlimited")
+	}
+	currLimit = limitNow
+}
+
 // stringSet holds a set of strings
 type stringSet map[string]struct{}
 
@@ -316,11 +356,14 @@ func (s *StatsInfo) Transferring(remote string) {
 // if ok is true then it increments the transfers count
 func (s *StatsInfo) DoneTransferring(remote string, ok bool) {
 	s.lock.Lock()
-	defer s.lock.Unlock()
 	delete(s.transferring, remote)
 	if ok {
 		s.transfers++
 	}
+	s.lock.Unlock()
+	if s == Stats {
+		sendStatsSnapshot()
+	}
 }
 
 // Account limits and accounts for one transfer
@@ -345,7 +388,9 @@ type Account struct {
 	exit    chan struct{}      // channel that will be closed when transfer is finished
 	withBuf bool               // is using a buffered in
 
-	wholeFileDisabled bool // disables the whole file when doing parts
+	wholeFileDisabled bool      // disables the whole file when doing parts
+	lastUpdate        time.Time // time of the last TransferUpdate sent
+	bufferSize        int64     // size of the buffer set with WithBufferSize
 }
 
 // NewAccountSizeName makes a Account reader for an io.ReadCloser of
@@ -372,10 +417,21 @@ func NewAccount(in io.ReadCloser, obj Object) *Account {
 
 // WithBuffer - If the file is above a certain size it adds an Async reader
 func (acc *Account) WithBuffer() *Account {
+	return acc.WithBufferSize(int64(Config.BufferSize))
+}
+
+// WithBufferSize is like WithBuffer but reads up to bufferSize bytes
+// ahead rather than --buffer-size
+func (acc *Account) WithBufferSize(bufferSize int64) *Account {
 	acc.withBuf = true
+	acc.bufferSize = bufferSize
 	var buffers int
-	if acc.size >= int64(Config.BufferSize) || acc.size == -1 {
-		buffers = int(int64(Config.BufferSize) / asyncBufferSize)
+	if acc.size >= bufferSize || acc.size == -1 {
+		buffers = int(bufferSize / asyncBufferSize)
+		// Use at least one buffer if any read ahead was asked for
+		if buffers < 1 && bufferSize > 0 {
+			buffers = 1
+		}
 	} else {
 		buffers = int(acc.size / asyncBufferSize)
 	}
@@ -411,7 +467,11 @@ func (acc *Account) UpdateReader(in io.ReadCloser) {
 	acc.StopBuffering()
 	acc.in = in
 	acc.origIn = in
-	acc.WithBuffer()
+	if acc.bufferSize > 0 {
+		acc.WithBufferSize(acc.bufferSize)
+	} else {
+		acc.WithBuffer()
+	}
 	acc.mu.Unlock()
 }
 
@@ -464,6 +524,9 @@ func (acc *Account) read(in io.Reader, p []byte) (n int, err error) {
 	acc.statmu.Lock()
 	acc.lpBytes += n
 	acc.bytes += int64(n)
+	if haveProgressSinks() {
+		acc.progressUpdate(time.Now())
+	}
 	acc.statmu.Unlock()
 
 	Stats.Bytes(int64(n))
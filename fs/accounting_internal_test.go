@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// bucketLimit returns the current limit of the token bucket or 0 if
+// unlimited
+func bucketLimit() rate.Limit {
+	tokenBucketMu.Lock()
+	defer tokenBucketMu.Unlock()
+	if tokenBucket == nil {
+		return 0
+	}
+	return tokenBucket.Limit()
+}
+
+func TestTokenBucketTimetable(t *testing.T) {
+	oldBwLimit := bwLimit
+	defer func() {
+		bwLimit = oldBwLimit
+		resetTokenBucket(time.Now())
+	}()
+	bwLimit = BwTimetable{}
+	require.NoError(t, bwLimit.Set("08:00,512 19:00,10M 23:00,off"))
+
+	at := func(hour, min int) time.Time {
+		return time.Date(2017, time.April, 20, hour, min, 0, 0, time.Local)
+	}
+
+	// Start in the unlimited slot which wraps past midnight
+	resetTokenBucket(at(2, 0))
+	assert.Equal(t, rate.Limit(0), bucketLimit())
+
+	// Nothing changes until the boundary
+	updateTokenBucket(at(7, 59))
+	assert.Equal(t, rate.Limit(0), bucketLimit())
+	updateTokenBucket(at(8, 0))
+	assert.Equal(t, rate.Limit(512*1024), bucketLimit())
+	updateTokenBucket(at(19, 0))
+	assert.Equal(t, rate.Limit(10*1024*1024), bucketLimit())
+	updateTokenBucket(at(23, 0))
+	assert.Equal(t, rate.Limit(0), bucketLimit())
+	updateTokenBucket(at(0, 30))
+	assert.Equal(t, rate.Limit(0), bucketLimit())
+
+	// When toggled off changes only apply when toggled on again
+	updateTokenBucket(at(8, 0))
+	tokenBucketMu.Lock()
+	bwLimitToggledOff = true
+	tokenBucket, prevTokenBucket = prevTokenBucket, tokenBucket
+	tokenBucketMu.Unlock()
+	assert.Equal(t, rate.Limit(0), bucketLimit())
+	updateTokenBucket(at(19, 0))
+	assert.Equal(t, rate.Limit(0), bucketLimit())
+	tokenBucketMu.Lock()
+	assert.Equal(t, rate.Limit(10*1024*1024), prevTokenBucket.Limit())
+	tokenBucketMu.Unlock()
+
+	// Resetting undoes the toggle
+	resetTokenBucket(at(19, 30))
+	assert.Equal(t, rate.Limit(10*1024*1024), bucketLimit())
+	tokenBucketMu.Lock()
+	assert.False(t, bwLimitToggledOff)
+	assert.Nil(t, prevTokenBucket)
+	tokenBucketMu.Unlock()
+}
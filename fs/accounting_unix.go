@@ -9,17 +9,27 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 // startSignalHandler() sets a signal handler to catch SIGUSR2 and toggle throttling.
+//
+// If a bandwidth timetable is in use it also catches SIGHUP and
+// resets the limit to the one in the timetable.
 func startSignalHandler() {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGUSR2)
+	if len(bwLimit) > 1 {
+		signal.Notify(signals, syscall.SIGHUP)
+	}
 
 	go func() {
 		// This runs forever, but blocks until the signal is received.
-		for {
-			<-signals
+		for sig := range signals {
+			if sig == syscall.SIGHUP {
+				resetTokenBucket(time.Now())
+				continue
+			}
 			tokenBucketMu.Lock()
 			bwLimitToggledOff = !bwLimitToggledOff
 			tokenBucket, prevTokenBucket = prevTokenBucket, tokenBucket
@@ -0,0 +1,128 @@
+// Per backend limits on the number of checkers and transfers
+
+package fs
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Globals
+var (
+	checkersPerBackend  = StringArrayP("checkers-per-backend", "", nil, "Limit checkers using the named remote, eg remote=4.  May be repeated.")
+	transfersPerBackend = StringArrayP("transfers-per-backend", "", nil, "Limit transfers using the named remote, eg remote=2.  May be repeated.")
+	checkerLimits       *backendLimiter
+	transferLimits      *backendLimiter
+)
+
+// parseBackendLimits parses a list of name=N limits
+func parseBackendLimits(flag string, list []string) (map[string]int, error) {
+	limits := make(map[string]int, len(list))
+	for _, item := range list {
+		i := strings.LastIndex(item, "=")
+		if i < 0 {
+			return nil, errors.Errorf("--%s: %q should be in the form remote=N", flag, item)
+		}
+		name := strings.TrimSuffix(item[:i], ":")
+		n, err := strconv.Atoi(item[i+1:])
+		if err != nil || n <= 0 {
+			return nil, errors.Errorf("--%s: bad number in %q", flag, item)
+		}
+		limits[name] = n
+	}
+	return limits, nil
+}
+
+// backendLimiter limits the number of concurrent operations on each
+// backend.
+//
+// The limit for a backend comes from the flags, or if not set there
+// from the key in the config file section for the remote.  Backends
+// with no limit are only limited by the global --checkers or
+// --transfers.
+type backendLimiter struct {
+	key    string                   // config file key to read limits from
+	limits map[string]int           // limits from the command line
+	mu     sync.Mutex               // protects the following
+	tokens map[string]chan struct{} // tokens for each limited backend, nil if unlimited
+}
+
+// newBackendLimiter makes a backendLimiter reading limits from the
+// config file key given if not present in limits
+func newBackendLimiter(key string, limits map[string]int) *backendLimiter {
+	return &backendLimiter{
+		key:    key,
+		limits: limits,
+		tokens: make(map[string]chan struct{}),
+	}
+}
+
+// getTokens returns the token channel for the backend called name,
+// or nil if it is unlimited
+func (l *backendLimiter) getTokens(name string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tokens, found := l.tokens[name]
+	if found {
+		return tokens
+	}
+	n, ok := l.limits[name]
+	if !ok && configData != nil {
+		n = ConfigFileGetInt(name, l.key, 0)
+	}
+	if n > 0 {
+		Debugf(name, "Limiting to %d concurrent %s", n, l.key)
+		tokens = make(chan struct{}, n)
+	}
+	l.tokens[name] = tokens
+	return tokens
+}
+
+// acquire waits until an operation may start on all of the named
+// backends.  It returns a function to call when the operation has
+// finished.
+//
+// It is safe to call on a nil backendLimiter.
+func (l *backendLimiter) acquire(names ...string) (release func()) {
+	if l == nil {
+		return func() {}
+	}
+	// Take the tokens in name order so concurrent callers can't
+	// deadlock
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	var held []chan struct{}
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			continue
+		}
+		if tokens := l.getTokens(name); tokens != nil {
+			tokens <- struct{}{}
+			held = append(held, tokens)
+		}
+	}
+	return func() {
+		for _, tokens := range held {
+			<-tokens
+		}
+	}
+}
+
+// startBackendLimits sets up the per backend limits from the flags
+func startBackendLimits() error {
+	limits, err := parseBackendLimits("checkers-per-backend", *checkersPerBackend)
+	if err != nil {
+		return err
+	}
+	checkerLimits = newBackendLimiter("checkers", limits)
+	limits, err = parseBackendLimits("transfers-per-backend", *transfersPerBackend)
+	if err != nil {
+		return err
+	}
+	transferLimits = newBackendLimiter("transfers", limits)
+	return nil
+}
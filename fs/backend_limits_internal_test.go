@@ -0,0 +1,81 @@
+package fs
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBackendLimits(t *testing.T) {
+	limits, err := parseBackendLimits("checkers-per-backend", []string{"s3=4", "drive:=2", "odd=name=3"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"s3": 4, "drive": 2, "odd=name": 3}, limits)
+
+	for _, bad := range []string{"s3", "s3=", "s3=potato", "s3=0", "s3=-1"} {
+		_, err = parseBackendLimits("checkers-per-backend", []string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestBackendLimiter(t *testing.T) {
+	l := newBackendLimiter("checkers", map[string]int{"a": 2, "b": 3})
+	var (
+		mu      sync.Mutex
+		running = map[string]int{}
+		maxSeen = map[string]int{}
+		wg      sync.WaitGroup
+	)
+	// run an operation on the backends given noting the concurrency
+	op := func(names ...string) {
+		defer wg.Done()
+		release := l.acquire(names...)
+		mu.Lock()
+		for _, name := range names {
+			running[name]++
+			if running[name] > maxSeen[name] {
+				maxSeen[name] = running[name]
+			}
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		for _, name := range names {
+			running[name]--
+		}
+		mu.Unlock()
+		release()
+	}
+	for i := 0; i < 10; i++ {
+		wg.Add(4)
+		go op("a")
+		go op("b")
+		go op("c")
+		go op("b", "a")
+	}
+	wg.Wait()
+	assert.Equal(t, 2, maxSeen["a"])
+	assert.Equal(t, 3, maxSeen["b"])
+	assert.True(t, maxSeen["c"] > 3, fmt.Sprintf("c should be unlimited but max was %d", maxSeen["c"]))
+
+	// Check the same backend twice only takes one token
+	done := make(chan struct{})
+	go func() {
+		release := l.acquire("a", "a")
+		release2 := l.acquire("a")
+		release()
+		release2()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deadlock acquiring the same backend twice")
+	}
+
+	// nil limiter does nothing
+	(*backendLimiter)(nil).acquire("a")()
+}
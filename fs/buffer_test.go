@@ -281,3 +281,24 @@ func testAsyncReaderClose(t *testing.T, writeto bool) {
 }
 func TestAsyncReaderCloseRead(t *testing.T)    { testAsyncReaderClose(t, false) }
 func TestAsyncReaderCloseWriteTo(t *testing.T) { testAsyncReaderClose(t, true) }
+
+// Check a buffer smaller than asyncBufferSize still reads ahead
+func TestAccountWithBufferSizeSmall(t *testing.T) {
+	const size = 3 * asyncBufferSize
+	data := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+	acc := NewAccountSizeName(ioutil.NopCloser(bytes.NewReader(data)), size, "test")
+	acc.WithBufferSize(64 * 1024)
+	_, ok := acc.in.(*asyncReader)
+	assert.True(t, ok, "expecting an asyncReader")
+	got, err := ioutil.ReadAll(acc)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	require.NoError(t, acc.Close())
+
+	// No buffer is used if none is asked for
+	acc = NewAccountSizeName(ioutil.NopCloser(bytes.NewReader(data)), size, "test")
+	acc.WithBufferSize(0)
+	_, ok = acc.in.(*asyncReader)
+	assert.False(t, ok, "not expecting an asyncReader")
+	require.NoError(t, acc.Close())
+}
@@ -70,11 +70,14 @@ var (
 	modifyWindow          = DurationP("modify-window", "", time.Nanosecond, "Max time diff to be considered the same")
 	checkers              = IntP("checkers", "", 8, "Number of checkers to run in parallel.")
 	transfers             = IntP("transfers", "", 4, "Number of file transfers to run in parallel.")
+	transfersPerDir       = IntP("transfers-per-dir", "", 0, "Max number of file transfers into a single directory at once. 0 for no limit.")
+	dedupeUploads         = BoolP("dedupe-uploads", "", false, "Upload files with identical content once and server side copy the rest.")
 	configFile            = StringP("config", "", ConfigPath, "Config file.")
 	checkSum              = BoolP("checksum", "c", false, "Skip based on checksum & size, not mod-time & size")
 	sizeOnly              = BoolP("size-only", "", false, "Skip based on size only, not mod-time or checksum")
 	ignoreTimes           = BoolP("ignore-times", "I", false, "Don't skip files that match size and time - transfer all files")
 	ignoreExisting        = BoolP("ignore-existing", "", false, "Skip all files that exist on destination")
+	addOnly               = BoolP("add-only", "", false, "Only copy files missing from the destination - never modify or delete existing files")
 	dryRun                = BoolP("dry-run", "n", false, "Do a trial run with no permanent changes")
 	connectTimeout        = DurationP("contimeout", "", 60*time.Second, "Connect timeout")
 	timeout               = DurationP("timeout", "", 5*60*time.Second, "IO idle timeout")
@@ -82,6 +85,9 @@ var (
 	dumpBodies            = BoolP("dump-bodies", "", false, "Dump HTTP headers and bodies - may contain sensitive info")
 	dumpAuth              = BoolP("dump-auth", "", false, "Dump HTTP headers with auth info")
 	skipVerify            = BoolP("no-check-certificate", "", false, "Do not verify the server SSL certificate. Insecure.")
+	caCert                = StringP("ca-cert", "", "", "CA certificate used to verify servers")
+	clientCert            = StringP("client-cert", "", "", "Client SSL certificate (PEM) for mutual TLS auth")
+	clientKey             = StringP("client-key", "", "", "Client SSL private key (PEM) for mutual TLS auth")
 	AskPassword           = BoolP("ask-password", "", true, "Allow prompt for password for encrypted configuration.")
 	deleteBefore          = BoolP("delete-before", "", false, "When synchronizing, delete files on destination before transfering")
 	deleteDuring          = BoolP("delete-during", "", false, "When synchronizing, delete files during transfer (default)")
@@ -95,11 +101,18 @@ var (
 	ignoreChecksum        = BoolP("ignore-checksum", "", false, "Skip post copy check of checksums.")
 	noTraverse            = BoolP("no-traverse", "", false, "Don't traverse destination file system on copy.")
 	noUpdateModTime       = BoolP("no-update-modtime", "", false, "Don't update destination mod-time if files identical.")
+	preserveDirModTimes   = BoolP("preserve-dir-modtimes", "", false, "Set the mod-time of destination directories to that of the source directories.")
 	backupDir             = StringP("backup-dir", "", "", "Make backups into hierarchy based in DIR.")
 	suffix                = StringP("suffix", "", "", "Suffix for use with --backup-dir.")
+	purgeResumeFile       = StringP("purge-resume-file", "", "", "Record the progress of purge in this file so it can be resumed if interrupted.")
 	useListR              = BoolP("fast-list", "", false, "Use recursive list if available. Uses more memory but fewer transactions.")
+	fastListMaxEntries    = IntP("fast-list-max-entries", "", 0, "If --fast-list finds more entries than this list directories individually instead. 0 for no limit.")
 	tpsLimit              = Float64P("tpslimit", "", 0, "Limit HTTP transactions per second to this.")
 	tpsLimitBurst         = IntP("tpslimit-burst", "", 1, "Max burst of transactions for --tpslimit.")
+	deleteTPSLimit        = Float64P("delete-tpslimit", "", 0, "Limit deletes per second to this.")
+	unicodeNormalization  = StringP("unicode-normalization", "", "", "Normalize unicode file names to this form: nfc or nfd.")
+	checksumChoice        = StringP("checksum-choice", "", "", "Comma separated list of hashes to compare with in order of preference, eg sha1,md5")
+	retryReads            = BoolP("retry-reads", "", false, "Retry failed reads when downloading, resuming from the last good offset.")
 	bindAddr              = StringP("bind", "", "", "Local address to bind to for outgoing connections, IPv4, IPv6 or name.")
 	disableFeatures       = StringP("disable", "", "", "Disable a comma separated list of features.  Use help to see a list.")
 	userAgent             = StringP("user-agent", "", "rclone/"+Version, "Set the user-agent to a specified string. The default is rclone/ version")
@@ -110,6 +123,8 @@ var (
 	statsLogLevel         = LogLevelInfo
 	bwLimit               BwTimetable
 	bufferSize            SizeSuffix = 16 << 20
+	strictHash            HashType
+	minFreeSpace          SizeSuffix
 
 	// Key to use for password en/decryption.
 	// When nil, no encryption will be used for saving.
@@ -122,6 +137,8 @@ func init() {
 	VarP(&bwLimit, "bwlimit", "", "Bandwidth limit in kBytes/s, or use suffix b|k|M|G or a full timetable.")
 	VarP(&bufferSize, "buffer-size", "", "Buffer size when copying files.")
 	VarP(&streamingUploadCutoff, "streaming-upload-cutoff", "", "Cutoff for switching to chunked upload if file size is unknown. Upload starts after reaching cutoff or when file ends.")
+	VarP(&minFreeSpace, "min-free-space", "", "Pause new transfers while the destination has less free space than this.")
+	VarP(&strictHash, "strict-hash", "", "Fail unless source and destination share a hash at least this strong: any|md5|sha1|dropbox")
 }
 
 // crypt internals
@@ -214,9 +231,12 @@ type ConfigInfo struct {
 	SizeOnly              bool
 	IgnoreTimes           bool
 	IgnoreExisting        bool
+	AddOnly               bool
 	ModifyWindow          time.Duration
 	Checkers              int
 	Transfers             int
+	TransfersPerDir       int
+	DedupeUploads         bool
 	ConnectTimeout        time.Duration // Connect timeout
 	Timeout               time.Duration // Data channel timeout
 	DumpHeaders           bool
@@ -224,6 +244,9 @@ type ConfigInfo struct {
 	DumpAuth              bool
 	Filter                *Filter
 	InsecureSkipVerify    bool // Skip server certificate verification
+	CaCert                string
+	ClientCert            string
+	ClientKey             string
 	DeleteMode            DeleteMode
 	TrackRenames          bool // Track file renames.
 	LowLevelRetries       int
@@ -234,13 +257,22 @@ type ConfigInfo struct {
 	IgnoreChecksum        bool
 	NoTraverse            bool
 	NoUpdateModTime       bool
+	PreserveDirModTimes   bool // Copy directory mod-times to the destination
 	DataRateUnit          string
 	BackupDir             string
 	Suffix                string
+	PurgeResumeFile       string
 	UseListR              bool
+	FastListMaxEntries    int
 	BufferSize            SizeSuffix
 	TPSLimit              float64
 	TPSLimitBurst         int
+	DeleteTPSLimit        float64
+	UnicodeNormalization  string
+	StrictHash            HashType
+	ChecksumChoice        []HashType
+	RetryReads            bool
+	MinFreeSpace          SizeSuffix
 	BindAddr              net.IP
 	DisableFeatures       []string
 	Immutable             bool
@@ -360,6 +392,8 @@ func LoadConfig() {
 	Config.ModifyWindow = *modifyWindow
 	Config.Checkers = *checkers
 	Config.Transfers = *transfers
+	Config.TransfersPerDir = *transfersPerDir
+	Config.DedupeUploads = *dedupeUploads
 	Config.DryRun = *dryRun
 	Config.Timeout = *timeout
 	Config.ConnectTimeout = *connectTimeout
@@ -367,10 +401,14 @@ func LoadConfig() {
 	Config.SizeOnly = *sizeOnly
 	Config.IgnoreTimes = *ignoreTimes
 	Config.IgnoreExisting = *ignoreExisting
+	Config.AddOnly = *addOnly
 	Config.DumpHeaders = *dumpHeaders
 	Config.DumpBodies = *dumpBodies
 	Config.DumpAuth = *dumpAuth
 	Config.InsecureSkipVerify = *skipVerify
+	Config.CaCert = *caCert
+	Config.ClientCert = *clientCert
+	Config.ClientKey = *clientKey
 	Config.LowLevelRetries = *lowLevelRetries
 	Config.UpdateOlder = *updateOlder
 	Config.NoGzip = *noGzip
@@ -379,14 +417,21 @@ func LoadConfig() {
 	Config.IgnoreChecksum = *ignoreChecksum
 	Config.NoTraverse = *noTraverse
 	Config.NoUpdateModTime = *noUpdateModTime
+	Config.PreserveDirModTimes = *preserveDirModTimes
 	Config.BackupDir = *backupDir
 	Config.Suffix = *suffix
+	Config.PurgeResumeFile = *purgeResumeFile
 	Config.UseListR = *useListR
+	Config.FastListMaxEntries = *fastListMaxEntries
 	Config.TPSLimit = *tpsLimit
 	Config.TPSLimitBurst = *tpsLimitBurst
+	Config.DeleteTPSLimit = *deleteTPSLimit
+	Config.RetryReads = *retryReads
+	Config.MinFreeSpace = minFreeSpace
 	Config.Immutable = *immutable
 	Config.AutoConfirm = *autoConfirm
 	Config.BufferSize = bufferSize
+	Config.StrictHash = strictHash
 	Config.StreamingUploadCutoff = streamingUploadCutoff
 
 	Config.TrackRenames = *trackRenames
@@ -413,6 +458,25 @@ func LoadConfig() {
 		log.Fatalf(`Can only use --suffix with --backup-dir.`)
 	}
 
+	Config.ChecksumChoice = nil
+	if *checksumChoice != "" {
+		for _, name := range strings.Split(*checksumChoice, ",") {
+			var hash HashType
+			err := hash.Set(strings.TrimSpace(name))
+			if err != nil {
+				log.Fatalf("--checksum-choice: %v", err)
+			}
+			Config.ChecksumChoice = append(Config.ChecksumChoice, hash)
+		}
+	}
+
+	Config.UnicodeNormalization = strings.ToLower(*unicodeNormalization)
+	switch Config.UnicodeNormalization {
+	case "", "nfc", "nfd":
+	default:
+		log.Fatalf(`--unicode-normalization: unknown form %q - use nfc or nfd`, *unicodeNormalization)
+	}
+
 	if *bindAddr != "" {
 		addrs, err := net.LookupIP(*bindAddr)
 		if err != nil {
@@ -461,6 +525,18 @@ func LoadConfig() {
 
 	// Start the transactions per second limiter
 	startHTTPTokenBucket()
+
+	// Start the deletes per second limiter
+	startDeleteTokenBucket()
+
+	// Set up the per backend limits on checkers and transfers
+	err = startBackendLimits()
+	if err != nil {
+		log.Fatalf("Failed to load per backend limits: %v", err)
+	}
+
+	// Start sending transfer events if required
+	startEventSocket()
 }
 
 var errorConfigFileNotFound = errors.New("config file not found")
@@ -1074,7 +1150,7 @@ func NewRemoteName() (name string) {
 		case name == "":
 			fmt.Printf("Can't use empty name.\n")
 		case isDriveLetter(name):
-			fmt.Printf("Can't use %q as it can be confused a drive letter.\n", name)
+			fmt.Printf("Can't use %q as it can be confused with a drive letter.\n", name)
 		case parts == nil:
 			fmt.Printf("Can't use %q as it has invalid characters in it.\n", name)
 		default:
@@ -1140,16 +1216,69 @@ func copyRemote(name string) string {
 	return newName
 }
 
+// updateRemoteReferences rewrites the remote key of any crypt remotes
+// which wrap the remote name, eg "remote = name:path", to newName.
+func updateRemoteReferences(name, newName string) {
+	prefix := name + ":"
+	for _, section := range configData.GetSectionList() {
+		if section == name || section == newName {
+			continue
+		}
+		if configData.MustValue(section, "type", "") != "crypt" {
+			continue
+		}
+		value := configData.MustValue(section, "remote", "")
+		if strings.HasPrefix(value, prefix) {
+			newValue := newName + ":" + value[len(prefix):]
+			Logf(nil, "Updating remote in remote %q from %q to %q", section, value, newValue)
+			configData.SetValue(section, "remote", newValue)
+		}
+	}
+}
+
 // RenameRemote renames a config section
 func RenameRemote(name string) {
 	fmt.Printf("Enter new name for %q remote.\n", name)
 	newName := copyRemote(name)
 	if name != newName {
+		updateRemoteReferences(name, newName)
 		configData.DeleteSection(name)
 		SaveConfig()
 	}
 }
 
+// RenameRemoteTo renames the remote name to newName, updating any
+// references to it in the other remotes, and saves the config.
+func RenameRemoteTo(name, newName string) error {
+	sections := configData.GetSectionList()
+	found := false
+	for _, section := range sections {
+		if section == newName {
+			return errors.Errorf("remote %q already exists", newName)
+		}
+		if section == name {
+			found = true
+		}
+	}
+	if !found {
+		return errors.Errorf("remote %q not found", name)
+	}
+	if isDriveLetter(newName) {
+		return errors.Errorf("can't use %q as it can be confused with a drive letter", newName)
+	}
+	if newName == "" || matcher.FindStringSubmatch(newName+":") == nil {
+		return errors.Errorf("can't use %q as it has invalid characters in it", newName)
+	}
+	for _, key := range configData.GetKeyList(name) {
+		value := configData.MustValue(name, key, "")
+		configData.SetValue(newName, key, value)
+	}
+	updateRemoteReferences(name, newName)
+	configData.DeleteSection(name)
+	SaveConfig()
+	return nil
+}
+
 // CopyRemote copies a config section
 func CopyRemote(name string) {
 	fmt.Printf("Enter name for copy of %q remote.\n", name)
@@ -101,6 +101,74 @@ func TestCRUD(t *testing.T) {
 	assert.Equal(t, []string{}, configData.GetSectionList())
 }
 
+func TestRenameRemoteTo(t *testing.T) {
+	configKey = nil // reset password
+	// create temp config file
+	tempFile, err := ioutil.TempFile("", "rename.conf")
+	assert.NoError(t, err)
+	path := tempFile.Name()
+	defer func() {
+		err := os.Remove(path)
+		assert.NoError(t, err)
+	}()
+	assert.NoError(t, tempFile.Close())
+
+	// temporarily adapt configuration
+	oldConfigFile := configFile
+	oldConfig := Config
+	oldConfigData := configData
+	configFile = &path
+	Config = &ConfigInfo{}
+	configData = nil
+	defer func() {
+		configFile = oldConfigFile
+		Config = oldConfig
+		configData = oldConfigData
+	}()
+
+	LoadConfig()
+	configData.SetValue("base", "type", "local")
+	configData.SetValue("secret", "type", "crypt")
+	configData.SetValue("secret", "remote", "base:path/to/secret")
+	configData.SetValue("other", "type", "crypt")
+	configData.SetValue("other", "remote", "basement:path")
+	SaveConfig()
+	configData = nil
+	LoadConfig()
+
+	// Error cases
+	assert.EqualError(t, RenameRemoteTo("potato", "new"), `remote "potato" not found`)
+	assert.EqualError(t, RenameRemoteTo("base", "secret"), `remote "secret" already exists`)
+	assert.EqualError(t, RenameRemoteTo("base", "new/name"), `can't use "new/name" as it has invalid characters in it`)
+
+	require.NoError(t, RenameRemoteTo("base", "newbase"))
+
+	// Reload the config to check it was saved
+	configData = nil
+	LoadConfig()
+	assert.Equal(t, []string{"secret", "other", "newbase"}, configData.GetSectionList())
+	assert.Equal(t, "local", ConfigFileGet("newbase", "type"))
+	assert.Equal(t, "newbase:path/to/secret", ConfigFileGet("secret", "remote"))
+	assert.Equal(t, "basement:path", ConfigFileGet("other", "remote"))
+
+	// Check only the remote key of crypt remotes is changed
+	configData.SetValue("https", "type", "http")
+	configData.SetValue("https", "url", "https://example.com/")
+	configData.SetValue("web", "type", "http")
+	configData.SetValue("web", "url", "https://example.com/files/")
+	configData.SetValue("webcrypt", "type", "crypt")
+	configData.SetValue("webcrypt", "remote", "https:secret")
+	configData.SetValue("webcrypt", "password", "https:not-a-remote")
+	configData.SetValue("webalias", "type", "alias")
+	configData.SetValue("webalias", "remote", "https:files")
+	require.NoError(t, RenameRemoteTo("https", "site"))
+	assert.Equal(t, "https://example.com/", ConfigFileGet("site", "url"))
+	assert.Equal(t, "https://example.com/files/", ConfigFileGet("web", "url"))
+	assert.Equal(t, "site:secret", ConfigFileGet("webcrypt", "remote"))
+	assert.Equal(t, "https:not-a-remote", ConfigFileGet("webcrypt", "password"))
+	assert.Equal(t, "https:files", ConfigFileGet("webalias", "remote"))
+}
+
 // Test some error cases
 func TestReveal(t *testing.T) {
 	for _, test := range []struct {
@@ -0,0 +1,232 @@
+// Copy to multiple destinations reading the source only once
+
+package fs
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// errorAllDestinationsFailed is returned when there are no
+// destinations left to write to
+var errorAllDestinationsFailed = errors.New("all destinations failed")
+
+// multiWriter writes to all of its writers, dropping any which
+// return an error so the rest can carry on.
+type multiWriter struct {
+	writers []*io.PipeWriter
+	failed  []bool
+}
+
+// Write p to all the writers which haven't failed
+func (mw *multiWriter) Write(p []byte) (n int, err error) {
+	ok := false
+	for i, w := range mw.writers {
+		if mw.failed[i] {
+			continue
+		}
+		_, err = w.Write(p)
+		if err != nil {
+			mw.failed[i] = true
+			continue
+		}
+		ok = true
+	}
+	if !ok {
+		return 0, errorAllDestinationsFailed
+	}
+	return len(p), nil
+}
+
+// multiDst is a destination that CopyMulti is copying to
+type multiDst struct {
+	f   Fs
+	dst Object // existing object or nil
+	err error  // result of the copy
+}
+
+// CopyMulti copies src to each of fdsts, opening and reading src
+// only once.  It skips any destinations where the object is already
+// up to date.
+//
+// A failure to copy to one destination doesn't affect the others.  It
+// returns an error for each destination which failed, or nil if the
+// copy succeeded everywhere.
+func CopyMulti(fdsts []Fs, src Object) (errs []error) {
+	remote := src.Remote()
+	var dsts []*multiDst
+	for _, fdst := range fdsts {
+		dst, err := fdst.NewObject(remote)
+		switch err {
+		case nil:
+			if Equal(src, dst) {
+				Debugf(src, "Unchanged skipping on %v", fdst)
+				continue
+			}
+		case ErrorObjectNotFound, ErrorDirNotFound:
+			dst = nil
+		default:
+			Stats.Error()
+			Errorf(src, "Failed to read object from %v: %v", fdst, err)
+			errs = append(errs, err)
+			continue
+		}
+		if Config.DryRun {
+			Logf(src, "Not copying to %v as --dry-run", fdst)
+			continue
+		}
+		dsts = append(dsts, &multiDst{f: fdst, dst: dst})
+	}
+	if len(dsts) == 0 {
+		return errs
+	}
+
+	Stats.Transferring(remote)
+	var err error
+	defer func() {
+		doneTransferring(remote, src.Size(), err)
+	}()
+	in0, err := openRetrying(src)
+	if err != nil {
+		Stats.Error()
+		err = errors.Wrap(err, "failed to open source object")
+		Errorf(src, "%v", err)
+		for range dsts {
+			errs = append(errs, err)
+		}
+		return errs
+	}
+	in := NewAccount(in0, src).WithBuffer() // account and buffer the transfer
+
+	// Start an upload to each destination reading from a pipe
+	var wg sync.WaitGroup
+	mw := &multiWriter{
+		writers: make([]*io.PipeWriter, len(dsts)),
+		failed:  make([]bool, len(dsts)),
+	}
+	for i, d := range dsts {
+		pr, pw := io.Pipe()
+		mw.writers[i] = pw
+		wg.Add(1)
+		go func(d *multiDst, pr *io.PipeReader) {
+			defer wg.Done()
+			if d.dst != nil {
+				d.err = d.dst.Update(pr, src)
+			} else {
+				d.dst, d.err = d.f.Put(pr, src)
+			}
+			// Make sure writes to this destination fail from now on
+			closeErr := d.err
+			if closeErr == nil {
+				closeErr = errors.New("destination stopped reading")
+			}
+			_ = pr.CloseWithError(closeErr)
+		}(d, pr)
+	}
+	_, err = io.Copy(mw, in)
+	if err == errorAllDestinationsFailed {
+		err = nil
+	}
+	for _, pw := range mw.writers {
+		_ = pw.CloseWithError(err)
+	}
+	wg.Wait()
+	closeErr := in.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	// Check the results for each destination
+	failed := 0
+	for _, d := range dsts {
+		if d.err == nil {
+			d.err = err
+		}
+		if d.err == nil {
+			d.err = checkMultiCopy(src, d.dst)
+		}
+		if d.err != nil {
+			failed++
+			Stats.Error()
+			Errorf(src, "Failed to copy to %v: %v", d.f, d.err)
+			errs = append(errs, d.err)
+			continue
+		}
+		Infof(src, "Copied to %v", d.f)
+	}
+	if failed > 0 && err == nil {
+		err = errors.Errorf("failed to copy to %d destinations", failed)
+	}
+	return errs
+}
+
+// checkMultiCopy checks dst is the same as src after a transfer,
+// removing dst if not
+func checkMultiCopy(src, dst Object) error {
+	if !Config.IgnoreSize && src.Size() != dst.Size() {
+		removeFailedCopy(dst)
+		return errors.Errorf("corrupted on transfer: sizes differ %d vs %d", src.Size(), dst.Size())
+	}
+	if Config.IgnoreChecksum {
+		return nil
+	}
+	equal, hash, err := CheckHashes(src, dst)
+	if err != nil {
+		return errors.Wrap(err, "failed to read hash")
+	}
+	if !equal {
+		removeFailedCopy(dst)
+		return errors.Errorf("corrupted on transfer: %v hash differ", hash)
+	}
+	return nil
+}
+
+// CopyDirMulti copies the files in fsrc to each of fdsts, reading
+// each file from fsrc only once however many destinations need it.
+//
+// Empty directories aren't copied.
+func CopyDirMulti(fdsts []Fs, fsrc Fs) error {
+	for _, fdst := range fdsts {
+		if Overlapping(fdst, fsrc) {
+			err := ErrorCantCopyOverlapping
+			Errorf(fdst, "%v", err)
+			return err
+		}
+		if !Config.DryRun {
+			err := Mkdir(fdst, "")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var (
+		wg         sync.WaitGroup
+		errorCount int32
+	)
+	toBeCopied := make(ObjectsChan, Config.Transfers)
+	wg.Add(Config.Transfers)
+	for i := 0; i < Config.Transfers; i++ {
+		go func() {
+			defer wg.Done()
+			for src := range toBeCopied {
+				errs := CopyMulti(fdsts, src)
+				atomic.AddInt32(&errorCount, int32(len(errs)))
+			}
+		}()
+	}
+	err := ListFn(fsrc, func(o Object) {
+		toBeCopied <- o
+	})
+	close(toBeCopied)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	if errorCount > 0 {
+		return errors.Errorf("%d copies failed", errorCount)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Upload identical files once and server side copy the duplicates
+
+package fs
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// uploadDeduper tracks the uploads in a sync by content so files
+// with the same content are only uploaded once.  The duplicates are
+// server side copied from the first upload.
+type uploadDeduper struct {
+	hashType HashType
+	mu       sync.Mutex                // protects the following
+	uploads  map[string]*dedupedUpload // uploads by size and hash
+}
+
+// dedupedUpload is an upload which duplicates may be copied from
+type dedupedUpload struct {
+	done chan struct{} // closed when the upload has finished
+	dst  Object        // the uploaded object or nil if it failed
+}
+
+// newUploadDeduper makes an uploadDeduper for copying or moving from
+// fsrc to fdst or returns nil if --dedupe-uploads isn't set or can't
+// be used.
+func newUploadDeduper(fdst, fsrc Fs, DoMove bool) *uploadDeduper {
+	if !Config.DedupeUploads {
+		return nil
+	}
+	if fdst.Features().Copy == nil {
+		Errorf(fdst, "Ignoring --dedupe-uploads as the destination does not support server side copy")
+		return nil
+	}
+	if DoMove && SameConfig(fdst, fsrc) {
+		// Moves will be server side anyway
+		return nil
+	}
+	hashType := fsrc.Hashes().GetOne()
+	if hashType == HashNone {
+		Errorf(fsrc, "Ignoring --dedupe-uploads as the source does not support hashes")
+		return nil
+	}
+	return &uploadDeduper{
+		hashType: hashType,
+		uploads:  make(map[string]*dedupedUpload),
+	}
+}
+
+// key returns the size and hash of src or "" if it can't be
+// deduplicated
+func (d *uploadDeduper) key(src Object) string {
+	if src.Size() <= 0 {
+		return ""
+	}
+	hash, err := src.Hash(d.hashType)
+	if err != nil {
+		Debugf(src, "Hash failed so not deduplicating upload: %v", err)
+		return ""
+	}
+	if hash == "" {
+		return ""
+	}
+	return fmt.Sprintf("%d,%s", src.Size(), hash)
+}
+
+// claim returns the upload for src.  If first is set the caller
+// should upload src then call finish on the upload, otherwise it
+// should wait for the upload and copy it.
+//
+// It returns a nil upload if src can't be deduplicated.  It is safe
+// to call on a nil uploadDeduper.
+func (d *uploadDeduper) claim(src Object) (upload *dedupedUpload, first bool) {
+	if d == nil {
+		return nil, false
+	}
+	key := d.key(src)
+	if key == "" {
+		return nil, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if upload = d.uploads[key]; upload != nil {
+		return upload, false
+	}
+	upload = &dedupedUpload{
+		done: make(chan struct{}),
+	}
+	d.uploads[key] = upload
+	return upload, true
+}
+
+// finish marks the upload as done.  dst should be the uploaded object
+// or nil if the upload failed.
+func (u *dedupedUpload) finish(dst Object) {
+	u.dst = dst
+	close(u.done)
+}
+
+// wait waits for the upload to finish returning the uploaded object,
+// or nil if the upload failed or ctx was cancelled.
+func (u *dedupedUpload) wait(ctx context.Context) Object {
+	select {
+	case <-u.done:
+		return u.dst
+	case <-ctx.Done():
+		return nil
+	}
+}
@@ -67,6 +67,12 @@ func (d *Dir) ModTime() time.Time {
 	return time.Now()
 }
 
+// HasModTime returns true if the remote gave a modification date,
+// false if ModTime is only a guess
+func (d *Dir) HasModTime() bool {
+	return !d.modTime.IsZero()
+}
+
 // Size returns the size of the file
 func (d *Dir) Size() int64 {
 	return d.size
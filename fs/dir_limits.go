@@ -0,0 +1,80 @@
+// Limit the number of concurrent transfers into a single directory
+
+package fs
+
+import (
+	"sync"
+)
+
+// dirLimiter limits the number of transfers in progress in each
+// directory.
+//
+// Transfers which can't start because their directory is at the
+// limit are queued rather than blocking, so the transfer go routine
+// can carry on with files in other directories.  The queued
+// transfers are handed to whoever finishes a transfer in that
+// directory.
+type dirLimiter struct {
+	max     int
+	mu      sync.Mutex              // protects the following
+	running map[string]int          // transfers in progress in each directory
+	pending map[string][]ObjectPair // transfers waiting for each directory
+}
+
+// newDirLimiter makes a dirLimiter allowing max transfers per
+// directory or returns nil if max <= 0
+func newDirLimiter(max int) *dirLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &dirLimiter{
+		max:     max,
+		running: make(map[string]int),
+		pending: make(map[string][]ObjectPair),
+	}
+}
+
+// start returns true if pair may be transferred into dir now.  If
+// not it queues it to be returned by a later call to finish.
+//
+// It is safe to call on a nil dirLimiter.
+func (l *dirLimiter) start(dir string, pair ObjectPair) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running[dir] >= l.max {
+		l.pending[dir] = append(l.pending[dir], pair)
+		return false
+	}
+	l.running[dir]++
+	return true
+}
+
+// finish should be called when a transfer into dir has finished.  If
+// there is a transfer queued for dir it returns it with ok set and the
+// caller should transfer it then call finish again.
+//
+// It is safe to call on a nil dirLimiter.
+func (l *dirLimiter) finish(dir string) (pair ObjectPair, ok bool) {
+	if l == nil {
+		return pair, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if queue := l.pending[dir]; len(queue) > 0 {
+		pair = queue[0]
+		if len(queue) == 1 {
+			delete(l.pending, dir)
+		} else {
+			l.pending[dir] = queue[1:]
+		}
+		return pair, true
+	}
+	l.running[dir]--
+	if l.running[dir] <= 0 {
+		delete(l.running, dir)
+	}
+	return pair, false
+}
@@ -0,0 +1,179 @@
+// Per file transfer completion events sent to a unix socket or pipe
+
+package fs
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Globals
+var (
+	eventSocketPath = StringP("event-socket", "", "", "Send a JSON event to this unix socket or named pipe as each transfer completes.")
+	eventSink       *eventSender
+)
+
+const (
+	// eventBufferSize is the number of events buffered before they
+	// are dropped if the consumer isn't keeping up
+	eventBufferSize = 1024
+	// eventFlushTimeout is the maximum time to wait for buffered
+	// events to be written on exit
+	eventFlushTimeout = 5 * time.Second
+)
+
+// TransferEvent is sent as a line of JSON when a transfer completes
+type TransferEvent struct {
+	Path   string `json:"path"`
+	Bytes  int64  `json:"bytes"`
+	Status string `json:"status"` // "ok" or "error"
+	Error  string `json:"error,omitempty"`
+}
+
+// eventSender writes TransferEvents to a unix socket or named pipe.
+//
+// Events are buffered and dropped if the buffer is full so a slow
+// consumer never holds up the transfers.
+type eventSender struct {
+	path    string
+	events  chan []byte
+	dropped int64 // number of events dropped - use atomic
+	out     io.WriteCloser
+	done    chan struct{}
+	once    sync.Once
+}
+
+// newEventSender starts an eventSender writing to path
+func newEventSender(path string) *eventSender {
+	s := &eventSender{
+		path:   path,
+		events: make(chan []byte, eventBufferSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// connect opens the named pipe or connects to the unix socket at
+// s.path
+func (s *eventSender) connect() (io.WriteCloser, error) {
+	fi, err := os.Stat(s.path)
+	if err == nil && fi.Mode()&os.ModeNamedPipe != 0 {
+		return os.OpenFile(s.path, os.O_WRONLY, 0)
+	}
+	return net.Dial("unix", s.path)
+}
+
+// write writes buf to the consumer, connecting if necessary.  On
+// error the connection is dropped and will be retried with the next
+// event.
+func (s *eventSender) write(buf []byte) {
+	if s.out == nil {
+		out, err := s.connect()
+		if err != nil {
+			Debugf(nil, "Failed to connect to --event-socket %q: %v", s.path, err)
+			atomic.AddInt64(&s.dropped, 1)
+			return
+		}
+		s.out = out
+	}
+	_, err := s.out.Write(buf)
+	if err != nil {
+		Debugf(nil, "Failed to write to --event-socket %q: %v", s.path, err)
+		atomic.AddInt64(&s.dropped, 1)
+		_ = s.out.Close()
+		s.out = nil
+	}
+}
+
+// run writes the events until the channel is closed
+func (s *eventSender) run() {
+	defer close(s.done)
+	for buf := range s.events {
+		s.write(buf)
+	}
+	if s.out != nil {
+		_ = s.out.Close()
+	}
+}
+
+// send queues event to be written, dropping it if the buffer is full
+func (s *eventSender) send(event *TransferEvent) {
+	buf, err := json.Marshal(event)
+	if err != nil {
+		Errorf(nil, "Failed to marshal transfer event: %v", err)
+		return
+	}
+	buf = append(buf, '\n')
+	select {
+	case s.events <- buf:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+// Close stops the eventSender waiting up to timeout for the buffered
+// events to be written
+func (s *eventSender) Close(timeout time.Duration) {
+	s.once.Do(func() {
+		close(s.events)
+	})
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+		Errorf(nil, "Timed out writing events to --event-socket %q", s.path)
+	}
+	if dropped := atomic.LoadInt64(&s.dropped); dropped > 0 {
+		Errorf(nil, "Dropped %d events for --event-socket %q", dropped, s.path)
+	}
+}
+
+// startEventSocket starts sending events if --event-socket is set
+func startEventSocket() {
+	if *eventSocketPath != "" {
+		eventSink = newEventSender(*eventSocketPath)
+	}
+}
+
+// CloseEventSocket writes any outstanding transfer events and closes
+// the --event-socket if it is in use
+func CloseEventSocket() {
+	if eventSink != nil {
+		eventSink.Close(eventFlushTimeout)
+	}
+}
+
+// doneTransferring marks remote as finished in the stats and sends a
+// transfer event if required.  size is the size of the object
+// transferred and err the result of the transfer.
+func doneTransferring(remote string, size int64, err error) {
+	update := TransferUpdate{
+		Name: remote,
+		Size: size,
+		Done: true,
+		Err:  err,
+	}
+	if err == nil {
+		update.Bytes = size
+	}
+	sendTransferUpdate(update)
+	Stats.DoneTransferring(remote, err == nil)
+	if eventSink == nil {
+		return
+	}
+	event := &TransferEvent{
+		Path:   remote,
+		Bytes:  size,
+		Status: "ok",
+	}
+	if err != nil {
+		event.Status = "error"
+		event.Error = err.Error()
+	}
+	eventSink.send(event)
+}
@@ -0,0 +1,101 @@
+package fs
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets not supported")
+	}
+	dir, err := ioutil.TempDir("", "rclone-events")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	socketPath := filepath.Join(dir, "events.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	defer func() {
+		_ = l.Close()
+	}()
+
+	// Read the events in the background
+	received := make(chan []TransferEvent, 1)
+	go func() {
+		var events []TransferEvent
+		defer func() { received <- events }()
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			var event TransferEvent
+			if json.Unmarshal(scanner.Bytes(), &event) == nil {
+				events = append(events, event)
+			}
+		}
+	}()
+
+	oldEventSink := eventSink
+	eventSink = newEventSender(socketPath)
+	defer func() { eventSink = oldEventSink }()
+
+	for _, remote := range []string{"one", "two", "three"} {
+		Stats.Transferring(remote)
+	}
+	doneTransferring("one", 1, nil)
+	doneTransferring("two", 22, errors.New("boom"))
+	doneTransferring("three", 333, nil)
+	CloseEventSocket()
+
+	var events []TransferEvent
+	select {
+	case events = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for events")
+	}
+	assert.Equal(t, []TransferEvent{
+		{Path: "one", Bytes: 1, Status: "ok"},
+		{Path: "two", Bytes: 22, Status: "error", Error: "boom"},
+		{Path: "three", Bytes: 333, Status: "ok"},
+	}, events)
+	assert.Equal(t, int64(0), eventSink.dropped)
+}
+
+func TestEventSenderDoesNotBlock(t *testing.T) {
+	// An eventSender whose consumer never reads anything
+	s := &eventSender{
+		path:   "not used",
+		events: make(chan []byte, 2),
+		done:   make(chan struct{}),
+	}
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			s.send(&TransferEvent{Path: "file", Bytes: int64(i), Status: "ok"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("send blocked on a slow consumer")
+	}
+	assert.Equal(t, 2, len(s.events))
+	assert.Equal(t, int64(8), s.dropped)
+}
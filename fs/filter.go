@@ -28,6 +28,9 @@ var (
 	filesFrom      = StringArrayP("files-from", "", nil, "Read list of source-file names from file")
 	minAge         = StringP("min-age", "", "", "Don't transfer any file younger than this in s or suffix ms|s|m|h|d|w|M|y")
 	maxAge         = StringP("max-age", "", "", "Don't transfer any file older than this in s or suffix ms|s|m|h|d|w|M|y")
+	modifiedSince  = StringP("modified-since", "", "", "Only transfer files modified at or after this time, eg 2006-01-02 or 2006-01-02T15:04:05Z")
+	modifiedUntil  = StringP("modified-until", "", "", "Only transfer files modified before this time, eg 2006-01-02 or 2006-01-02T15:04:05Z")
+	storageClass   = StringArrayP("storage-class", "", nil, "Only transfer files in this storage class, eg STANDARD")
 	minSize        = SizeSuffix(-1)
 	maxSize        = SizeSuffix(-1)
 	dumpFilters    = BoolP("dump-filters", "", false, "Dump the filters to the output")
@@ -103,6 +106,7 @@ type Filter struct {
 	MaxSize        int64
 	ModTimeFrom    time.Time
 	ModTimeTo      time.Time
+	StorageClasses []string // if set only include objects in these storage classes
 	fileRules      rules
 	dirRules       rules
 	files          FilesMap // files if filesFrom
@@ -147,6 +151,35 @@ func ParseDuration(age string) (time.Duration, error) {
 	return time.Duration(period), nil
 }
 
+// timeFormats are the formats accepted by ParseTime.  Those without a
+// time zone are interpreted in the local time zone.
+var timeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04Z07:00",
+	"2006-01-02 15:04",
+	"2006-01-02Z07:00",
+	"2006-01-02",
+}
+
+// ParseTime parses an absolute time such as 2006-01-02,
+// 2006-01-02T15:04:05 or 2006-01-02T15:04:05+07:00.  If no time zone
+// is given the local time zone is used.
+func ParseTime(in string) (time.Time, error) {
+	in = strings.TrimSpace(in)
+	for _, format := range timeFormats {
+		t, err := time.ParseInLocation(format, in, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("couldn't parse %q as a time - try 2006-01-02 or 2006-01-02T15:04:05Z07:00", in)
+}
+
 // NewFilter parses the command line options and creates a Filter object
 func NewFilter() (f *Filter, err error) {
 	f = &Filter{
@@ -246,6 +279,42 @@ func NewFilter() (f *Filter, err error) {
 		}
 		Debugf(nil, "--max-age %v to %v", duration, f.ModTimeFrom)
 	}
+	if *modifiedSince != "" {
+		since, err := ParseTime(*modifiedSince)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad --modified-since")
+		}
+		if f.ModTimeFrom.IsZero() || since.After(f.ModTimeFrom) {
+			f.ModTimeFrom = since
+		}
+		Debugf(nil, "--modified-since %v", since)
+	}
+	if *modifiedUntil != "" {
+		until, err := ParseTime(*modifiedUntil)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad --modified-until")
+		}
+		// --modified-until is exclusive so ranges can be butted
+		// together without overlapping
+		until = until.Add(-time.Nanosecond)
+		if f.ModTimeTo.IsZero() || until.Before(f.ModTimeTo) {
+			f.ModTimeTo = until
+		}
+		Debugf(nil, "--modified-until %v", until)
+	}
+	if (*modifiedSince != "" || *modifiedUntil != "") && !f.ModTimeTo.IsZero() && f.ModTimeTo.Before(f.ModTimeFrom) {
+		return nil, errors.New("no modification times are in the range given by --modified-since/--modified-until and --min-age/--max-age")
+	}
+	if storageClass != nil {
+		for _, class := range *storageClass {
+			for _, class := range strings.Split(class, ",") {
+				class = strings.TrimSpace(class)
+				if class != "" {
+					f.StorageClasses = append(f.StorageClasses, class)
+				}
+			}
+		}
+	}
 	if *dumpFilters {
 		fmt.Println("--- start filters ---")
 		fmt.Println(f.DumpFilters())
@@ -369,6 +438,7 @@ func (f *Filter) InActive() bool {
 	return (f.files == nil &&
 		f.ModTimeFrom.IsZero() &&
 		f.ModTimeTo.IsZero() &&
+		len(f.StorageClasses) == 0 &&
 		f.MinSize < 0 &&
 		f.MaxSize < 0 &&
 		f.fileRules.len() == 0 &&
@@ -426,6 +496,18 @@ func (f *Filter) Include(remote string, size int64, modTime time.Time) bool {
 	return f.includeRemote(remote)
 }
 
+// IncludeName returns whether an object called remote would be
+// included by the name based rules.  It is for objects which don't
+// exist yet, so the size, age and storage class filters aren't used.
+func (f *Filter) IncludeName(remote string) bool {
+	// filesFrom takes precedence
+	if f.files != nil {
+		_, include := f.files[remote]
+		return include
+	}
+	return f.includeRemote(remote)
+}
+
 // IncludeObject returns whether this object should be included into
 // the sync or not. This is a convenience function to avoid calling
 // o.ModTime(), which is an expensive operation.
@@ -438,9 +520,32 @@ func (f *Filter) IncludeObject(o Object) bool {
 		modTime = time.Unix(0, 0)
 	}
 
+	if !f.includeStorageClass(o) {
+		return false
+	}
+
 	return f.Include(o.Remote(), o.Size(), modTime)
 }
 
+// includeStorageClass returns whether the storage class of o passes
+// --storage-class.  Objects which don't report a storage class are
+// always included.
+func (f *Filter) includeStorageClass(o Object) bool {
+	if len(f.StorageClasses) == 0 {
+		return true
+	}
+	class := StorageClass(o)
+	if class == "" {
+		return true
+	}
+	for _, want := range f.StorageClasses {
+		if strings.EqualFold(class, want) {
+			return true
+		}
+	}
+	return false
+}
+
 // forEachLine calls fn on every line in the file pointed to by path
 //
 // It ignores empty lines and lines starting with '#' or ';'
@@ -474,6 +579,9 @@ func (f *Filter) DumpFilters() string {
 	if !f.ModTimeTo.IsZero() {
 		rules = append(rules, fmt.Sprintf("Last-modified date must be equal or less than: %s", f.ModTimeTo.String()))
 	}
+	if len(f.StorageClasses) > 0 {
+		rules = append(rules, fmt.Sprintf("Storage class must be one of: %s", strings.Join(f.StorageClasses, ", ")))
+	}
 	rules = append(rules, "--- File filter rules ---")
 	for _, rule := range f.fileRules.rules {
 		rules = append(rules, rule.String())
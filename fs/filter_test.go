@@ -246,6 +246,22 @@ func TestNewFilterMaxSize(t *testing.T) {
 	assert.False(t, f.InActive())
 }
 
+func TestNewFilterIncludeName(t *testing.T) {
+	f, err := NewFilter()
+	require.NoError(t, err)
+	f.MinSize = 100
+	require.NoError(t, f.AddRule("+ *.mp4"))
+	require.NoError(t, f.AddRule("- *"))
+	assert.True(t, f.IncludeName("film.mp4"))
+	assert.True(t, f.IncludeName("dir/film.mp4"))
+	assert.False(t, f.IncludeName("film.jpg"))
+
+	err = f.AddFile("file1.jpg")
+	require.NoError(t, err)
+	assert.True(t, f.IncludeName("file1.jpg"))
+	assert.False(t, f.IncludeName("film.mp4"))
+}
+
 func TestNewFilterMinAndMaxAge(t *testing.T) {
 	f, err := NewFilter()
 	require.NoError(t, err)
@@ -289,6 +305,117 @@ func TestNewFilterMaxAge(t *testing.T) {
 	assert.False(t, f.InActive())
 }
 
+func TestParseTime(t *testing.T) {
+	utc := time.UTC
+	plus2 := time.FixedZone("", 2*60*60)
+	for _, test := range []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local), false},
+		{"2024-01-02Z", time.Date(2024, 1, 2, 0, 0, 0, 0, utc), false},
+		{"2024-01-02+02:00", time.Date(2024, 1, 2, 0, 0, 0, 0, plus2), false},
+		{"2024-01-02 03:04", time.Date(2024, 1, 2, 3, 4, 0, 0, time.Local), false},
+		{"2024-01-02T03:04", time.Date(2024, 1, 2, 3, 4, 0, 0, time.Local), false},
+		{"2024-01-02T03:04Z", time.Date(2024, 1, 2, 3, 4, 0, 0, utc), false},
+		{"2024-01-02 03:04:05", time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), false},
+		{"2024-01-02T03:04:05", time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), false},
+		{"2024-01-02T03:04:05Z", time.Date(2024, 1, 2, 3, 4, 5, 0, utc), false},
+		{"2024-01-02T03:04:05.5+02:00", time.Date(2024, 1, 2, 3, 4, 5, 500000000, plus2), false},
+		{"2024-01-02 03:04:05+02:00", time.Date(2024, 1, 2, 3, 4, 5, 0, plus2), false},
+		{" 2024-01-02 ", time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local), false},
+		{"", time.Time{}, true},
+		{"1d", time.Time{}, true},
+		{"2024-13-01", time.Time{}, true},
+		{"02/01/2024", time.Time{}, true},
+	} {
+		got, err := ParseTime(test.in)
+		if test.err {
+			require.Error(t, err, test.in)
+			continue
+		}
+		require.NoError(t, err, test.in)
+		assert.True(t, test.want.Equal(got), "%q: want %v got %v", test.in, test.want, got)
+	}
+}
+
+func TestNewFilterModifiedSinceUntil(t *testing.T) {
+	since, until := "2024-01-01T00:00:00Z", "2024-02-01T00:00:00Z"
+	modifiedSince, modifiedUntil = &since, &until
+	defer func() {
+		empty := ""
+		modifiedSince, modifiedUntil = &empty, &empty
+	}()
+	f, err := NewFilter()
+	require.NoError(t, err)
+	date := func(s string) int64 {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t.Unix()
+	}
+	testInclude(t, f, []includeTest{
+		{"2023.jpg", 100, date("2023-06-01T00:00:00Z"), false},
+		{"just-before.jpg", 100, date("2023-12-31T23:59:59Z"), false},
+		{"start.jpg", 100, date("2024-01-01T00:00:00Z"), true},
+		{"middle.jpg", 100, date("2024-01-15T12:00:00Z"), true},
+		{"middle-zone.jpg", 100, date("2024-02-01T01:59:59+02:00"), true},
+		{"end.jpg", 100, date("2024-02-01T00:00:00Z"), false},
+		{"end-zone.jpg", 100, date("2024-02-01T02:00:00+02:00"), false},
+		{"2025.jpg", 100, date("2025-01-01T00:00:00Z"), false},
+	})
+	assert.False(t, f.InActive())
+
+	// Check errors
+	bad := "potato"
+	modifiedSince = &bad
+	_, err = NewFilter()
+	assert.Error(t, err)
+	since, until = "2024-02-01", "2024-01-01"
+	modifiedSince = &since
+	_, err = NewFilter()
+	assert.Error(t, err)
+}
+
+// classObject is an Object with a storage class
+type classObject struct {
+	mockObject
+	class string
+}
+
+// StorageClass returns the storage class of the object
+func (o classObject) StorageClass() string {
+	return o.class
+}
+
+func TestNewFilterStorageClass(t *testing.T) {
+	classes := []string{"STANDARD", "standard_ia, REDUCED_REDUNDANCY"}
+	storageClass = &classes
+	defer func() { storageClass = nil }()
+	f, err := NewFilter()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"STANDARD", "standard_ia", "REDUCED_REDUNDANCY"}, f.StorageClasses)
+	assert.False(t, f.InActive())
+	assert.Contains(t, f.DumpFilters(), "Storage class must be one of: STANDARD, standard_ia, REDUCED_REDUNDANCY")
+
+	for _, test := range []struct {
+		o    Object
+		want bool
+	}{
+		{classObject{"standard", "STANDARD"}, true},
+		{classObject{"lower", "standard"}, true},
+		{classObject{"ia", "STANDARD_IA"}, true},
+		{classObject{"rr", "REDUCED_REDUNDANCY"}, true},
+		{classObject{"glacier", "GLACIER"}, false},
+		{classObject{"unknown", ""}, true},
+		{mockObject("no class"), true},
+	} {
+		assert.Equal(t, test.want, f.IncludeObject(test.o), test.o.Remote())
+	}
+}
+
 func TestNewFilterMatches(t *testing.T) {
 	f, err := NewFilter()
 	require.NoError(t, err)
@@ -182,7 +182,19 @@ func (x *BwTimetable) Set(s string) error {
 		if err := ts.bandwidth.Set(tv[1]); err != nil {
 			return err
 		}
-		*x = append(*x, ts)
+
+		// Keep the timetable sorted by time so LimitAt and
+		// NextChange can rely on the order.
+		i := 0
+		for i < len(*x) && (*x)[i].hhmm < ts.hhmm {
+			i++
+		}
+		if i < len(*x) && (*x)[i].hhmm == ts.hhmm {
+			return errors.Errorf("duplicate time in timetable: %q", hhmm)
+		}
+		*x = append(*x, BwTimeSlot{})
+		copy((*x)[i+1:], (*x)[i:])
+		(*x)[i] = ts
 	}
 	return nil
 }
@@ -226,6 +238,24 @@ func (x BwTimetable) LimitAt(tt time.Time) BwTimeSlot {
 	return ret
 }
 
+// NextChange returns the first time after tt when a time slot in the
+// timetable starts, wrapping round to the next day after the last
+// one.  It returns the zero time if the limit never changes.
+func (x BwTimetable) NextChange(tt time.Time) time.Time {
+	if len(x) <= 1 {
+		return time.Time{}
+	}
+	for day := 0; day <= 1; day++ {
+		for _, ts := range x {
+			start := time.Date(tt.Year(), tt.Month(), tt.Day()+day, ts.hhmm/100, ts.hhmm%100, 0, 0, tt.Location())
+			if start.After(tt) {
+				return start
+			}
+		}
+	}
+	return time.Time{}
+}
+
 // Type of the value
 func (x BwTimetable) Type() string {
 	return "BwTimetable"
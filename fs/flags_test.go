@@ -106,6 +106,16 @@ func TestBwTimetableSet(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"23:00,off 08:00,512 19:00,10M",
+			BwTimetable{
+				BwTimeSlot{hhmm: 800, bandwidth: 512 * 1024},
+				BwTimeSlot{hhmm: 1900, bandwidth: 10 * 1024 * 1024},
+				BwTimeSlot{hhmm: 2300, bandwidth: -1},
+			},
+			false,
+		},
+		{"08:00,512 08:00,1M", BwTimetable{BwTimeSlot{hhmm: 800, bandwidth: 512 * 1024}}, true},
 		{"bad,bad", BwTimetable{}, true},
 		{"bad bad", BwTimetable{}, true},
 		{"bad", BwTimetable{}, true},
@@ -185,3 +195,42 @@ func TestBwTimetableLimitAt(t *testing.T) {
 		assert.Equal(t, test.want, slot)
 	}
 }
+
+func TestBwTimetableNextChange(t *testing.T) {
+	tt := BwTimetable{
+		BwTimeSlot{hhmm: 800, bandwidth: 512 * 1024},
+		BwTimeSlot{hhmm: 1900, bandwidth: 10 * 1024 * 1024},
+		BwTimeSlot{hhmm: 2300, bandwidth: -1},
+	}
+	for _, test := range []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{
+			time.Date(2017, time.April, 20, 7, 59, 59, 0, time.UTC),
+			time.Date(2017, time.April, 20, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2017, time.April, 20, 8, 0, 0, 0, time.UTC),
+			time.Date(2017, time.April, 20, 19, 0, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2017, time.April, 20, 22, 30, 0, 0, time.UTC),
+			time.Date(2017, time.April, 20, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2017, time.April, 20, 23, 0, 0, 0, time.UTC),
+			time.Date(2017, time.April, 21, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2017, time.April, 30, 23, 59, 0, 0, time.UTC),
+			time.Date(2017, time.May, 1, 8, 0, 0, 0, time.UTC),
+		},
+	} {
+		assert.Equal(t, test.want, tt.NextChange(test.now), test.now.String())
+	}
+
+	// A single slot never changes
+	assert.True(t, BwTimetable{}.NextChange(time.Now()).IsZero())
+	assert.True(t, tt[:1].NextChange(time.Now()).IsZero())
+}
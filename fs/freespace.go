@@ -0,0 +1,90 @@
+// Pause transfers while the destination is short of space
+
+package fs
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// freeSpaceCheckInterval is how often the free space is read
+var freeSpaceCheckInterval = 10 * time.Second
+
+// freeSpaceGuard holds up new transfers while the free space on an
+// Fs is below a threshold.
+type freeSpaceGuard struct {
+	f       Info
+	about   func() (*Usage, error)
+	min     int64
+	mu      sync.Mutex // protects the following
+	checked time.Time  // when the free space was last read
+	enough  bool       // whether there was enough space when last read - starts true
+}
+
+// newFreeSpaceGuard makes a freeSpaceGuard for f if --min-free-space
+// is set, returning nil if it isn't or f can't report its free space.
+func newFreeSpaceGuard(f Fs) *freeSpaceGuard {
+	if Config.MinFreeSpace <= 0 {
+		return nil
+	}
+	about := f.Features().About
+	if about == nil {
+		Logf(f, "Ignoring --min-free-space as the free space can't be read")
+		return nil
+	}
+	return &freeSpaceGuard{
+		f:      f,
+		about:  about,
+		min:    int64(Config.MinFreeSpace),
+		enough: true,
+	}
+}
+
+// check returns whether there is enough free space, reading it at
+// most once every freeSpaceCheckInterval
+func (g *freeSpaceGuard) check() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.checked.IsZero() && time.Since(g.checked) < freeSpaceCheckInterval {
+		return g.enough
+	}
+	g.checked = time.Now()
+	usage, err := g.about()
+	if err != nil {
+		Errorf(g.f, "Failed to read free space: %v", err)
+		g.enough = true
+		return g.enough
+	}
+	if usage.Free == nil {
+		g.enough = true
+		return g.enough
+	}
+	enough := *usage.Free >= g.min
+	if enough && !g.enough {
+		Logf(g.f, "Free space %v is above --min-free-space %v - resuming transfers", SizeSuffix(*usage.Free), SizeSuffix(g.min))
+	} else if !enough && g.enough {
+		Logf(g.f, "Free space %v is below --min-free-space %v - pausing new transfers", SizeSuffix(*usage.Free), SizeSuffix(g.min))
+	}
+	g.enough = enough
+	return g.enough
+}
+
+// wait until there is enough free space to start a new transfer.  It
+// returns false if ctx was cancelled while waiting.
+//
+// It is safe to call on a nil freeSpaceGuard.
+func (g *freeSpaceGuard) wait(ctx context.Context) bool {
+	if g == nil {
+		return true
+	}
+	for !g.check() {
+		select {
+		case <-time.After(freeSpaceCheckInterval):
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
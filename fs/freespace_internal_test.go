@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestFreeSpaceGuard(t *testing.T) {
+	oldInterval := freeSpaceCheckInterval
+	freeSpaceCheckInterval = 10 * time.Millisecond
+	defer func() { freeSpaceCheckInterval = oldInterval }()
+
+	var (
+		mu    sync.Mutex
+		free  = int64(50)
+		calls = 0
+	)
+	setFree := func(n int64) {
+		mu.Lock()
+		free = n
+		mu.Unlock()
+	}
+	g := &freeSpaceGuard{
+		about: func() (*Usage, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			n := free
+			return &Usage{Free: &n}, nil
+		},
+		min:    100,
+		enough: true,
+	}
+
+	// nil guard never waits
+	assert.True(t, (*freeSpaceGuard)(nil).wait(context.Background()))
+
+	// New transfers pause while the free space is low
+	done := make(chan bool)
+	go func() {
+		done <- g.wait(context.Background())
+	}()
+	select {
+	case <-done:
+		t.Fatal("transfer started with low free space")
+	case <-time.After(100 * time.Millisecond):
+	}
+	mu.Lock()
+	assert.True(t, calls > 1, "free space not re-read")
+	mu.Unlock()
+
+	// and resume once space is freed
+	setFree(200)
+	select {
+	case ok := <-done:
+		assert.True(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("transfer didn't resume when free space increased")
+	}
+
+	// Check cancelling the context stops the wait
+	setFree(50)
+	time.Sleep(2 * freeSpaceCheckInterval)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- g.wait(ctx)
+	}()
+	cancel()
+	select {
+	case ok := <-done:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("wait not cancelled")
+	}
+}
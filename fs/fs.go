@@ -49,8 +49,10 @@ var (
 	ErrorNotDeleting                 = errors.New("not deleting files as there were IO errors")
 	ErrorNotDeletingDirs             = errors.New("not deleting directories as there were IO errors")
 	ErrorCantMoveOverlapping         = errors.New("can't move files on overlapping remotes")
+	ErrorCantCopyOverlapping         = errors.New("can't copy files on overlapping remotes")
 	ErrorDirectoryNotEmpty           = errors.New("directory not empty")
 	ErrorImmutableModified           = errors.New("immutable file modified")
+	ErrorMetadataNotSupported        = errors.New("metadata not supported")
 )
 
 // RegInfo provides information about a filesystem
@@ -234,6 +236,41 @@ type MimeTyper interface {
 	MimeType() string
 }
 
+// RangeOpener is an optional interface for Object
+type RangeOpener interface {
+	// RangeOpen opens the Object for reading length bytes from
+	// offset, or to the end of the Object if length is < 0,
+	// without reading the data before offset.  length must not
+	// be 0.
+	RangeOpen(offset, length int64) (io.ReadCloser, error)
+}
+
+// Metadataer is an optional interface for Object
+type Metadataer interface {
+	// Metadata returns the user metadata of the Object as key
+	// value pairs, not including any metadata rclone uses itself
+	Metadata() (map[string]string, error)
+
+	// SetMetadata replaces the user metadata of the Object with
+	// metadata
+	SetMetadata(metadata map[string]string) error
+}
+
+// StorageClasser is an optional interface for Object
+type StorageClasser interface {
+	// StorageClass returns the storage class or tier of the
+	// Object, eg "STANDARD" or "GLACIER", or "" if not known
+	StorageClass() string
+}
+
+// Trasher is an optional interface for Object
+type Trasher interface {
+	// Trash moves the Object to the remote's trash or recycle
+	// bin, where it can be restored from, instead of deleting it
+	// permanently
+	Trash() error
+}
+
 // ListRCallback defines a callback function for ListR to use
 //
 // It is called for each tranche of entries read from the listing and
@@ -252,6 +289,9 @@ type Features struct {
 	WriteMimeType           bool // can set the mime type of objects
 	CanHaveEmptyDirectories bool // can have empty directories
 	BucketBased             bool // is bucket based (like s3, swift etc)
+	EventuallyConsistent    bool // may not list changes straight after they are made
+	MaxNameLength           int  // max length in bytes of a file or directory name, or 0 for no limit
+	MaxPathLength           int  // max length in bytes of the path of an object not counting the bucket, or 0 for no limit
 
 	// Purge all files in the root and the root directory
 	//
@@ -349,6 +389,29 @@ type Features struct {
 	// Don't implement this unless you have a more efficient way
 	// of listing recursively that doing a directory traversal.
 	ListR ListRFn
+
+	// ListMultipartUploads lists the multipart uploads in the Fs
+	// which have been started but not completed or aborted.
+	ListMultipartUploads func() ([]MultipartUpload, error)
+
+	// AbortMultipartUpload aborts the incomplete multipart upload
+	// passed in, freeing the storage used by any parts uploaded.
+	AbortMultipartUpload func(upload MultipartUpload) error
+
+	// About gets quota information from the Fs
+	About func() (*Usage, error)
+
+	// ListDirMarkers lists the names of the directory marker
+	// objects in the Fs, relative to the root
+	ListDirMarkers func() ([]string, error)
+
+	// RemoveDirMarker removes the directory marker object with the
+	// name returned by ListDirMarkers
+	RemoveDirMarker func(marker string) error
+
+	// DirSetModTime sets the modification time of the directory
+	// dir, which should be "" for the root
+	DirSetModTime func(dir string, modTime time.Time) error
 }
 
 // Disable nil's out the named feature.  If it isn't found then it
@@ -431,6 +494,22 @@ func (ft *Features) Fill(f Fs) *Features {
 	if do, ok := f.(ListRer); ok {
 		ft.ListR = do.ListR
 	}
+	if do, ok := f.(MultipartLister); ok {
+		ft.ListMultipartUploads = do.ListMultipartUploads
+	}
+	if do, ok := f.(MultipartAborter); ok {
+		ft.AbortMultipartUpload = do.AbortMultipartUpload
+	}
+	if do, ok := f.(Abouter); ok {
+		ft.About = do.About
+	}
+	if do, ok := f.(DirMarkerer); ok {
+		ft.ListDirMarkers = do.ListDirMarkers
+		ft.RemoveDirMarker = do.RemoveDirMarker
+	}
+	if do, ok := f.(DirSetModTimer); ok {
+		ft.DirSetModTime = do.DirSetModTime
+	}
 	return ft.DisableList(Config.DisableFeatures)
 }
 
@@ -439,7 +518,11 @@ func (ft *Features) Fill(f Fs) *Features {
 // Only optional features which are implemented in both the original
 // Fs AND the one passed in will be advertised.  Any features which
 // aren't in both will be set to false/nil, except for UnWrap which
-// will be left untouched.
+// will be left untouched and EventuallyConsistent which is set if it
+// is set in either as it is a limitation rather than a feature.  The
+// lowest of MaxNameLength and MaxPathLength are used for the same
+// reason, so wrappers which change the names they pass on must adjust
+// these after calling Mask.
 func (ft *Features) Mask(f Fs) *Features {
 	mask := f.Features()
 	ft.CaseInsensitive = ft.CaseInsensitive && mask.CaseInsensitive
@@ -448,6 +531,9 @@ func (ft *Features) Mask(f Fs) *Features {
 	ft.WriteMimeType = ft.WriteMimeType && mask.WriteMimeType
 	ft.CanHaveEmptyDirectories = ft.CanHaveEmptyDirectories && mask.CanHaveEmptyDirectories
 	ft.BucketBased = ft.BucketBased && mask.BucketBased
+	ft.EventuallyConsistent = ft.EventuallyConsistent || mask.EventuallyConsistent
+	ft.MaxNameLength = minLimit(ft.MaxNameLength, mask.MaxNameLength)
+	ft.MaxPathLength = minLimit(ft.MaxPathLength, mask.MaxPathLength)
 	if mask.Purge == nil {
 		ft.Purge = nil
 	}
@@ -484,9 +570,36 @@ func (ft *Features) Mask(f Fs) *Features {
 	if mask.ListR == nil {
 		ft.ListR = nil
 	}
+	if mask.ListMultipartUploads == nil {
+		ft.ListMultipartUploads = nil
+	}
+	if mask.AbortMultipartUpload == nil {
+		ft.AbortMultipartUpload = nil
+	}
+	if mask.About == nil {
+		ft.About = nil
+	}
+	if mask.ListDirMarkers == nil {
+		ft.ListDirMarkers = nil
+	}
+	if mask.RemoveDirMarker == nil {
+		ft.RemoveDirMarker = nil
+	}
+	if mask.DirSetModTime == nil {
+		ft.DirSetModTime = nil
+	}
 	return ft.DisableList(Config.DisableFeatures)
 }
 
+// minLimit returns the lowest of the limits a and b where 0 means no
+// limit
+func minLimit(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
 // Wrap makes a Copy of the features passed in, overriding the UnWrap
 // method only if available in f.
 func (ft *Features) Wrap(f Fs) *Features {
@@ -631,6 +744,62 @@ type ListRer interface {
 	ListR(dir string, callback ListRCallback) error
 }
 
+// MultipartUpload describes a multipart upload which has been
+// started but not completed or aborted.
+type MultipartUpload struct {
+	Remote    string    // path of the object being uploaded
+	ID        string    // backend specific ID of the upload
+	Initiated time.Time // when the upload was started
+}
+
+// MultipartLister is an optional interface for Fs
+type MultipartLister interface {
+	// ListMultipartUploads lists the multipart uploads in the Fs
+	// which have been started but not completed or aborted.
+	ListMultipartUploads() ([]MultipartUpload, error)
+}
+
+// MultipartAborter is an optional interface for Fs
+type MultipartAborter interface {
+	// AbortMultipartUpload aborts the incomplete multipart upload
+	// passed in, freeing the storage used by any parts uploaded.
+	AbortMultipartUpload(upload MultipartUpload) error
+}
+
+// Usage is returned by the About call
+//
+// If a value is nil then it isn't supported by that backend
+type Usage struct {
+	Total *int64 // quota of bytes that can be used
+	Used  *int64 // bytes in use
+	Free  *int64 // bytes which can be uploaded before reaching the quota
+}
+
+// Abouter is an optional interface for Fs
+type Abouter interface {
+	// About gets quota information from the Fs
+	About() (*Usage, error)
+}
+
+// DirMarkerer is an optional interface for Fs which store directories
+// as marker objects
+type DirMarkerer interface {
+	// ListDirMarkers lists the names of the directory marker
+	// objects in the Fs, relative to the root
+	ListDirMarkers() ([]string, error)
+
+	// RemoveDirMarker removes the directory marker object with the
+	// name returned by ListDirMarkers
+	RemoveDirMarker(marker string) error
+}
+
+// DirSetModTimer is an optional interface for Fs
+type DirSetModTimer interface {
+	// DirSetModTime sets the modification time of the directory
+	// dir, which should be "" for the root
+	DirSetModTime(dir string, modTime time.Time) error
+}
+
 // ObjectsChan is a channel of Objects
 type ObjectsChan chan Object
 
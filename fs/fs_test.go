@@ -33,6 +33,35 @@ func TestFeaturesList(t *testing.T) {
 	assert.True(t, strings.Contains(names, ",Copy,"))
 }
 
+func TestFeaturesMaskLimits(t *testing.T) {
+	for _, test := range []struct {
+		a, b, want int
+	}{
+		{0, 0, 0},
+		{255, 0, 255},
+		{0, 255, 255},
+		{255, 1024, 255},
+		{1024, 255, 255},
+	} {
+		ft := &Features{MaxNameLength: test.a, MaxPathLength: test.a}
+		mask := &Features{MaxNameLength: test.b, MaxPathLength: test.b}
+		ft.Mask(&featuresFs{features: mask})
+		assert.Equal(t, test.want, ft.MaxNameLength, test)
+		assert.Equal(t, test.want, ft.MaxPathLength, test)
+	}
+}
+
+// featuresFs is an Fs with the features given
+type featuresFs struct {
+	Fs
+	features *Features
+}
+
+// Features returns the features of the Fs
+func (f *featuresFs) Features() *Features {
+	return f.features
+}
+
 func TestFeaturesDisableList(t *testing.T) {
 	ft := new(Features)
 	ft.Copy = func(src Object, remote string) (Object, error) {
@@ -11,6 +11,7 @@ import (
 
 	"github.com/ncw/rclone/dropbox/dbhash"
 	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
 )
 
 // HashType indicates a standard hashing algorithm
@@ -87,6 +88,32 @@ func (h HashType) String() string {
 	}
 }
 
+// Set a HashType from a flag string.  This is case insensitive and
+// accepts "any" as the weakest hash type.
+func (h *HashType) Set(s string) error {
+	switch strings.ToLower(s) {
+	case "none", "":
+		*h = HashNone
+	case "md5", "any":
+		*h = HashMD5
+	case "sha1", "sha-1":
+		*h = HashSHA1
+	case "dropbox", "dropboxhash":
+		*h = HashDropbox
+	default:
+		return errors.Errorf("unknown hash type %q", s)
+	}
+	return nil
+}
+
+// Type of the value
+func (h *HashType) Type() string {
+	return "string"
+}
+
+// Check it satisfies the interface
+var _ pflag.Value = (*HashType)(nil)
+
 // hashFromTypes will return hashers for all the requested types.
 // The types must be a subset of SupportedHashes,
 // and this function must support all types.
@@ -224,6 +251,17 @@ func (h HashSet) GetOne() HashType {
 	return HashType(HashNone)
 }
 
+// GetStrongest returns the strongest hash type in the set, or
+// HashNone if it is empty.  The hash types are numbered in order of
+// increasing strength.
+func (h HashSet) GetStrongest() HashType {
+	ht := h.Array()
+	if len(ht) == 0 {
+		return HashNone
+	}
+	return ht[len(ht)-1]
+}
+
 // Array returns an array of all hash types in the set
 func (h HashSet) Array() (ht []HashType) {
 	v := int(h)
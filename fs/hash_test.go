@@ -161,3 +161,37 @@ func TestHashStringer(t *testing.T) {
 	h = fs.HashNone
 	assert.Equal(t, h.String(), "None")
 }
+
+func TestHashSetGetStrongest(t *testing.T) {
+	assert.Equal(t, fs.HashNone, fs.NewHashSet().GetStrongest())
+	assert.Equal(t, fs.HashMD5, fs.NewHashSet(fs.HashMD5).GetStrongest())
+	assert.Equal(t, fs.HashSHA1, fs.NewHashSet(fs.HashMD5, fs.HashSHA1).GetStrongest())
+	assert.Equal(t, fs.HashDropbox, fs.SupportedHashes.GetStrongest())
+}
+
+func TestHashTypeSet(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want fs.HashType
+		err  bool
+	}{
+		{"", fs.HashNone, false},
+		{"none", fs.HashNone, false},
+		{"any", fs.HashMD5, false},
+		{"MD5", fs.HashMD5, false},
+		{"sha1", fs.HashSHA1, false},
+		{"SHA-1", fs.HashSHA1, false},
+		{"dropbox", fs.HashDropbox, false},
+		{"DropboxHash", fs.HashDropbox, false},
+		{"potato", fs.HashNone, true},
+	} {
+		h := fs.HashNone
+		err := h.Set(test.in)
+		if test.err {
+			assert.Error(t, err, test.in)
+		} else {
+			assert.NoError(t, err, test.in)
+		}
+		assert.Equal(t, test.want, h, test.in)
+	}
+}
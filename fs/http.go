@@ -5,6 +5,8 @@ package fs
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -12,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	"golang.org/x/net/context" // switch to "context" when we stop supporting go1.6
 	"golang.org/x/time/rate"
 )
@@ -108,32 +111,89 @@ func setDefaults(a, b interface{}) {
 	}
 }
 
+// tlsConfig returns the TLS config made from the certificate
+// options in ci
+func (ci *ConfigInfo) tlsConfig() (*tls.Config, error) {
+	c := &tls.Config{InsecureSkipVerify: ci.InsecureSkipVerify}
+	if ci.CaCert != "" {
+		pem, err := ioutil.ReadFile(ci.CaCert)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read CA certificate")
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in CA certificate %q", ci.CaCert)
+		}
+	}
+	if ci.ClientCert != "" || ci.ClientKey != "" {
+		if ci.ClientCert == "" || ci.ClientKey == "" {
+			return nil, errors.New("client certificate and client key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(ci.ClientCert, ci.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load client certificate")
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
+// newTransport makes a new Transport from the settings in ci
+func (ci *ConfigInfo) newTransport() (*Transport, error) {
+	tlsConfig, err := ci.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	// Start with a sensible set of defaults then override.
+	// This also means we get new stuff when it gets added to go
+	t := new(http.Transport)
+	setDefaults(t, http.DefaultTransport.(*http.Transport))
+	t.Proxy = http.ProxyFromEnvironment
+	t.MaxIdleConnsPerHost = 4 * (ci.Checkers + ci.Transfers + 1)
+	t.TLSHandshakeTimeout = ci.ConnectTimeout
+	t.ResponseHeaderTimeout = ci.Timeout
+	t.TLSClientConfig = tlsConfig
+	t.DisableCompression = *noGzip
+	// Set in http_old.go initTransport
+	//   t.Dial
+	// Set in http_new.go initTransport
+	//   t.DialContext
+	//   t.IdelConnTimeout
+	//   t.ExpectContinueTimeout
+	ci.initTransport(t)
+	// Wrap that http.Transport in our own transport
+	return NewTransport(t, ci.DumpHeaders, ci.DumpBodies, ci.DumpAuth), nil
+}
+
 // Transport returns an http.RoundTripper with the correct timeouts
 func (ci *ConfigInfo) Transport() http.RoundTripper {
 	noTransport.Do(func() {
-		// Start with a sensible set of defaults then override.
-		// This also means we get new stuff when it gets added to go
-		t := new(http.Transport)
-		setDefaults(t, http.DefaultTransport.(*http.Transport))
-		t.Proxy = http.ProxyFromEnvironment
-		t.MaxIdleConnsPerHost = 4 * (ci.Checkers + ci.Transfers + 1)
-		t.TLSHandshakeTimeout = ci.ConnectTimeout
-		t.ResponseHeaderTimeout = ci.Timeout
-		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: ci.InsecureSkipVerify}
-		t.DisableCompression = *noGzip
-		// Set in http_old.go initTransport
-		//   t.Dial
-		// Set in http_new.go initTransport
-		//   t.DialContext
-		//   t.IdelConnTimeout
-		//   t.ExpectContinueTimeout
-		ci.initTransport(t)
-		// Wrap that http.Transport in our own transport
-		transport = NewTransport(t, ci.DumpHeaders, ci.DumpBodies, ci.DumpAuth)
+		t, err := ci.newTransport()
+		if err != nil {
+			Errorf(nil, "Failed to make HTTP transport: %v", err)
+			transport = errorTransport{err}
+			return
+		}
+		transport = t
 	})
 	return transport
 }
 
+// errorTransport is an http.RoundTripper which returns err for every
+// request.  It is used if the certificate options are bad so the
+// error is returned to the request rather than stopping rclone.
+type errorTransport struct {
+	err error
+}
+
+// RoundTrip returns the error
+func (t errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return nil, errors.Wrap(t.err, "failed to make HTTP transport")
+}
+
 // Client returns an http.Client with the correct timeouts
 func (ci *ConfigInfo) Client() *http.Client {
 	return &http.Client{
@@ -141,6 +201,44 @@ func (ci *ConfigInfo) Client() *http.Client {
 	}
 }
 
+// ClientForRemote returns an http.Client for the remote called name.
+//
+// The remote may override --ca-cert, --client-cert, --client-key and
+// --no-check-certificate with ca_cert, client_cert, client_key and
+// no_check_certificate in its config.  If it does then it gets a
+// transport of its own, otherwise the shared one is used.
+func (ci *ConfigInfo) ClientForRemote(name string) (*http.Client, error) {
+	rci := *ci
+	overridden := false
+	for _, opt := range []struct {
+		key   string
+		value *string
+	}{
+		{"ca_cert", &rci.CaCert},
+		{"client_cert", &rci.ClientCert},
+		{"client_key", &rci.ClientKey},
+	} {
+		if value := ConfigFileGet(name, opt.key); value != "" {
+			*opt.value = value
+			overridden = true
+		}
+	}
+	if ConfigFileGet(name, "no_check_certificate") != "" {
+		rci.InsecureSkipVerify = ConfigFileGetBool(name, "no_check_certificate")
+		overridden = true
+	}
+	if !overridden {
+		return ci.Client(), nil
+	}
+	t, err := rci.newTransport()
+	if err != nil {
+		return nil, errors.Wrapf(err, "remote %q", name)
+	}
+	return &http.Client{
+		Transport: t,
+	}, nil
+}
+
 // Transport is a our http Transport which wraps an http.Transport
 // * Sets the User Agent
 // * Does logging
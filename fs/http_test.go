@@ -3,11 +3,26 @@
 package fs
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // returns the "%p" reprentation of the thing passed in
@@ -62,3 +77,130 @@ func TestCleanAuth(t *testing.T) {
 		assert.Equal(t, test.want, got, test.in)
 	}
 }
+
+// testCert is a certificate and key made for testing
+type testCert struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+	der  []byte
+}
+
+// newTestCert makes a certificate for name signed by parent, or self
+// signed if parent is nil
+func newTestCert(t *testing.T, name string, serial int64, parent *testCert, isCA bool) *testCert {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if isCA {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// write writes the certificate and key as PEM files in dir returning
+// their paths
+func (c *testCert) write(t *testing.T, dir, name string) (certPath, keyPath string) {
+	certPath = filepath.Join(dir, name+".crt")
+	keyPath = filepath.Join(dir, name+".key")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der})
+	require.NoError(t, ioutil.WriteFile(certPath, certPEM, 0600))
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(c.key)})
+	require.NoError(t, ioutil.WriteFile(keyPath, keyPEM, 0600))
+	return certPath, keyPath
+}
+
+func TestTransportTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-tls")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	ca := newTestCert(t, "Test CA", 1, nil, true)
+	server := newTestCert(t, "127.0.0.1", 2, ca, false)
+	client := newTestCert(t, "rclone client", 3, ca, false)
+	caPath, _ := ca.write(t, dir, "ca")
+	clientCertPath, clientKeyPath := client.write(t, dir, "client")
+
+	// A server which needs a client certificate signed by the CA
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{server.der}, PrivateKey: server.key}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	get := func(ci *ConfigInfo) (string, error) {
+		tr, err := ci.newTransport()
+		if err != nil {
+			return "", err
+		}
+		resp, err := (&http.Client{Transport: tr}).Get(ts.URL)
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := ioutil.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	// With the CA and client certificate the request succeeds
+	ci := &ConfigInfo{
+		CaCert:     caPath,
+		ClientCert: clientCertPath,
+		ClientKey:  clientKeyPath,
+	}
+	body, err := get(ci)
+	require.NoError(t, err)
+	assert.Equal(t, "rclone client", body)
+
+	// Without the CA the server isn't trusted
+	_, err = get(&ConfigInfo{ClientCert: clientCertPath, ClientKey: clientKeyPath})
+	assert.Error(t, err)
+
+	// Without the client certificate the server refuses us
+	_, err = get(&ConfigInfo{CaCert: caPath})
+	assert.Error(t, err)
+
+	// Not checking the certificate still needs the client certificate
+	body, err = get(&ConfigInfo{InsecureSkipVerify: true, ClientCert: clientCertPath, ClientKey: clientKeyPath})
+	require.NoError(t, err)
+	assert.Equal(t, "rclone client", body)
+
+	// Bad settings are reported
+	_, err = get(&ConfigInfo{ClientCert: clientCertPath})
+	assert.Error(t, err)
+	_, err = get(&ConfigInfo{CaCert: clientKeyPath})
+	assert.Error(t, err)
+	_, err = get(&ConfigInfo{CaCert: filepath.Join(dir, "notfound")})
+	assert.Error(t, err)
+
+	// A bad transport returns the error from each request
+	_, err = (&http.Client{Transport: errorTransport{errors.New("bad certificate")}}).Get(ts.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad certificate")
+}
@@ -3,12 +3,14 @@
 package fs
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"reflect"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
@@ -82,8 +84,58 @@ var (
 	logFile        = StringP("log-file", "", "", "Log everything to this file")
 	useSyslog      = BoolP("syslog", "", false, "Use Syslog for logging")
 	syslogFacility = StringP("syslog-facility", "", "DAEMON", "Facility for syslog, eg KERN,USER,...")
+	logFormat      = StringP("log-format", "", "", "Format for log lines: json or leave empty for text")
 )
 
+// jsonLog is set if the logs should be output as JSON
+var jsonLog bool
+
+// logEntry is a single log line as output by --log-format json
+type logEntry struct {
+	Level      string `json:"level"`
+	Time       string `json:"time"`
+	Message    string `json:"msg"`
+	Object     string `json:"object,omitempty"`
+	ObjectType string `json:"objectType,omitempty"`
+	Operation  string `json:"operation,omitempty"`
+}
+
+// callerName returns the package qualified name of the function skip
+// levels above the caller of callerName, eg "fs.Copy"
+func callerName(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	name := runtime.FuncForPC(pc).Name()
+	if slash := strings.LastIndex(name, "/"); slash >= 0 {
+		name = name[slash+1:]
+	}
+	return name
+}
+
+// logJSON makes a JSON log line from the arguments passed in
+//
+// It must be called from logPrintf so the operation is the function
+// which called the logging function.
+func logJSON(level LogLevel, o interface{}, text string) string {
+	entry := logEntry{
+		Level:     strings.ToLower(level.String()),
+		Time:      time.Now().Format(time.RFC3339Nano),
+		Message:   text,
+		Operation: callerName(3),
+	}
+	if o != nil {
+		entry.Object = fmt.Sprintf("%v", o)
+		entry.ObjectType = fmt.Sprintf("%T", o)
+	}
+	out, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Sprintf(`{"level":"error","msg":%q}`, fmt.Sprintf("Failed to marshal log entry: %v", err))
+	}
+	return string(out)
+}
+
 // logPrint sends the text to the logger of level
 var logPrint = func(level LogLevel, text string) {
 	text = fmt.Sprintf("%-6s: %s", level, text)
@@ -93,7 +145,9 @@ var logPrint = func(level LogLevel, text string) {
 // logPrintf produces a log string from the arguments passed in
 func logPrintf(level LogLevel, o interface{}, text string, args ...interface{}) {
 	out := fmt.Sprintf(text, args...)
-	if o != nil {
+	if jsonLog {
+		out = logJSON(level, o, out)
+	} else if o != nil {
 		out = fmt.Sprintf("%v: %s", o, out)
 	}
 	logPrint(level, out)
@@ -209,4 +263,26 @@ func InitLogging() {
 		}
 		startSysLog()
 	}
+
+	// Log format
+	switch strings.ToLower(*logFormat) {
+	case "":
+	case "json":
+		startJSONLog()
+	default:
+		log.Fatalf("Unknown --log-format %q - use json or leave empty", *logFormat)
+	}
+}
+
+// startJSONLog makes the logs output one JSON object per line
+func startJSONLog() {
+	jsonLog = true
+	if *useSyslog {
+		// syslog adds its own level and time
+		return
+	}
+	log.SetFlags(0)
+	logPrint = func(level LogLevel, text string) {
+		log.Print(text)
+	}
 }
@@ -0,0 +1,51 @@
+// Internal tests for logging
+
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogJSON(t *testing.T) {
+	var buf bytes.Buffer
+	oldLogLevel, oldLogPrint, oldFlags := Config.LogLevel, logPrint, log.Flags()
+	defer func() {
+		Config.LogLevel, logPrint, jsonLog = oldLogLevel, oldLogPrint, false
+		log.SetFlags(oldFlags)
+		log.SetOutput(os.Stderr)
+	}()
+	log.SetOutput(&buf)
+	Config.LogLevel = LogLevelDebug
+	startJSONLog()
+
+	o := mockObject("dir/file.txt")
+	Errorf(o, "error %d", 1)
+	Logf(nil, "notice %d", 2)
+	Infof(o, "info %d", 3)
+	Debugf(o, "debug %d", 4)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 4, len(lines))
+	for i, want := range []logEntry{
+		{Level: "error", Message: "error 1", Object: "dir/file.txt", ObjectType: "fs.mockObject", Operation: "fs.TestLogJSON"},
+		{Level: "notice", Message: "notice 2", Operation: "fs.TestLogJSON"},
+		{Level: "info", Message: "info 3", Object: "dir/file.txt", ObjectType: "fs.mockObject", Operation: "fs.TestLogJSON"},
+		{Level: "debug", Message: "debug 4", Object: "dir/file.txt", ObjectType: "fs.mockObject", Operation: "fs.TestLogJSON"},
+	} {
+		var got logEntry
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &got), lines[i])
+		_, err := time.Parse(time.RFC3339Nano, got.Time)
+		assert.NoError(t, err, lines[i])
+		got.Time = ""
+		assert.Equal(t, want, got, lines[i])
+	}
+}
@@ -71,18 +71,24 @@ func (m *march) makeListDir(f Fs, includeAll bool) listDirFn {
 		}
 	}
 	var (
-		mu      sync.Mutex
-		started bool
-		dirs    DirTree
-		dirsErr error
+		mu       sync.Mutex
+		started  bool
+		dirs     DirTree
+		dirsErr  error
+		fallback bool // set if there were too many entries for ListR
 	)
 	return func(dir string) (entries DirEntries, err error) {
 		mu.Lock()
-		defer mu.Unlock()
 		if !started {
-			dirs, dirsErr = NewDirTree(f, m.dir, includeAll, Config.MaxDepth)
+			dirs, dirsErr = newDirTree(f, m.dir, includeAll, Config.MaxDepth)
+			fallback = dirsErr == errFastListLimit
 			started = true
 		}
+		if fallback {
+			mu.Unlock()
+			return ListDirSorted(f, includeAll, dir)
+		}
+		defer mu.Unlock()
 		if dirsErr != nil {
 			return nil, dirsErr
 		}
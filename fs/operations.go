@@ -19,6 +19,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
 )
 
 // CalculateModifyWindow works out modify window for Fses passed in -
@@ -51,6 +52,41 @@ func HashEquals(src, dst string) bool {
 	return src == dst
 }
 
+// commonHash returns the hash type to compare objects with from the
+// hash types in common.
+//
+// If --checksum-choice is set this is the first of those in common,
+// or HashNone if there aren't any.  Otherwise if --strict-hash is set
+// it is the strongest, otherwise any of them.
+func commonHash(common HashSet) HashType {
+	if len(Config.ChecksumChoice) > 0 {
+		for _, hash := range Config.ChecksumChoice {
+			if hash == HashNone || common.Contains(hash) {
+				return hash
+			}
+		}
+		return HashNone
+	}
+	if Config.StrictHash != HashNone {
+		return common.GetStrongest()
+	}
+	return common.GetOne()
+}
+
+// CheckStrictHash returns an error if --strict-hash is set and the
+// hash type used to compare fdst and fsrc isn't at least as strong as
+// it.
+func CheckStrictHash(fdst, fsrc Info) error {
+	if Config.StrictHash == HashNone {
+		return nil
+	}
+	hash := commonHash(fsrc.Hashes().Overlap(fdst.Hashes()))
+	if hash < Config.StrictHash {
+		return errors.Errorf("--strict-hash: need a common hash at least as strong as %v but the hash which would be used between %v and %v is %v", Config.StrictHash, fsrc, fdst, hash)
+	}
+	return nil
+}
+
 // CheckHashes checks the two files to see if they have common
 // known hash types and compares them
 //
@@ -67,10 +103,10 @@ func HashEquals(src, dst string) bool {
 func CheckHashes(src ObjectInfo, dst Object) (equal bool, hash HashType, err error) {
 	common := src.Fs().Hashes().Overlap(dst.Fs().Hashes())
 	// Debugf(nil, "Shared hashes: %v", common)
-	if common.Count() == 0 {
+	hash = commonHash(common)
+	if hash == HashNone {
 		return true, HashNone, nil
 	}
-	hash = common.GetOne()
 	srcHash, err := src.Hash(hash)
 	if err != nil {
 		Stats.Error()
@@ -141,6 +177,11 @@ func equal(src ObjectInfo, dst Object, sizeOnly, checkSum bool) bool {
 			return false
 		}
 		if hash == HashNone {
+			if Config.StrictHash != HashNone {
+				Stats.Error()
+				Errorf(src, "Can't compare with --strict-hash as the hash is missing")
+				return false
+			}
 			Debugf(src, "Size of src and dst objects identical")
 		} else {
 			Debugf(src, "Size and %v of src and dst objects identical", hash)
@@ -232,6 +273,54 @@ func MimeType(o ObjectInfo) (mimeType string) {
 	return MimeTypeFromName(o.Remote())
 }
 
+// RangeOpen opens o for reading length bytes from offset, or to the
+// end of the object if length is < 0.
+//
+// It uses the RangeOpener interface if the object has one, otherwise
+// it opens the whole object and discards the data before offset.
+func RangeOpen(o Object, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+	if do, ok := o.(RangeOpener); ok {
+		return do.RangeOpen(offset, length)
+	}
+	in, err := o.Open()
+	if err != nil {
+		return nil, err
+	}
+	return NewRangeReadCloser(in, offset, length)
+}
+
+// Metadata returns the user metadata of o, or
+// ErrorMetadataNotSupported if it doesn't implement the Metadataer
+// interface
+func Metadata(o Object) (map[string]string, error) {
+	if do, ok := o.(Metadataer); ok {
+		return do.Metadata()
+	}
+	return nil, ErrorMetadataNotSupported
+}
+
+// SetMetadata replaces the user metadata of o, returning
+// ErrorMetadataNotSupported if it doesn't implement the Metadataer
+// interface
+func SetMetadata(o Object, metadata map[string]string) error {
+	if do, ok := o.(Metadataer); ok {
+		return do.SetMetadata(metadata)
+	}
+	return ErrorMetadataNotSupported
+}
+
+// StorageClass returns the storage class of the object if it
+// implements the StorageClasser interface or "" otherwise
+func StorageClass(o ObjectInfo) string {
+	if do, ok := o.(StorageClasser); ok {
+		return do.StorageClass()
+	}
+	return ""
+}
+
 // Used to remove a failed copy
 //
 // Returns whether the file was succesfully removed or not
@@ -271,6 +360,34 @@ func (o *overrideRemoteObject) MimeType() string {
 // Check interface is satisfied
 var _ MimeTyper = (*overrideRemoteObject)(nil)
 
+// Wrapper to override the mime type of an object
+type overrideMimeTypeObject struct {
+	Object
+	mimeType string
+}
+
+// MimeType returns the overriden mime type
+func (o *overrideMimeTypeObject) MimeType() string {
+	return o.mimeType
+}
+
+// Wrapper to override the mime type of an ObjectInfo
+type overrideMimeTypeInfo struct {
+	ObjectInfo
+	mimeType string
+}
+
+// MimeType returns the overriden mime type
+func (o *overrideMimeTypeInfo) MimeType() string {
+	return o.mimeType
+}
+
+// Check interfaces are satisfied
+var (
+	_ MimeTyper = (*overrideMimeTypeObject)(nil)
+	_ MimeTyper = (*overrideMimeTypeInfo)(nil)
+)
+
 // Copy src object to dst or f if nil.  If dst is nil then it uses
 // remote as the name of the new object.
 func Copy(f Fs, dst Object, remote string, src Object) (err error) {
@@ -281,6 +398,8 @@ func Copy(f Fs, dst Object, remote string, src Object) (err error) {
 	maxTries := Config.LowLevelRetries
 	tries := 0
 	doUpdate := dst != nil
+	oldDst := dst
+	serverSideCopy := false
 	// work out which hash to use - limit to 1 hash in common
 	var common HashSet
 	hashType := HashNone
@@ -302,6 +421,7 @@ func Copy(f Fs, dst Object, remote string, src Object) (err error) {
 			newDst, err = doCopy(src, remote)
 			if err == nil {
 				dst = newDst
+				serverSideCopy = true
 			}
 		} else {
 			err = ErrorCantCopy
@@ -309,7 +429,7 @@ func Copy(f Fs, dst Object, remote string, src Object) (err error) {
 		// If can't server side copy, do it manually
 		if err == ErrorCantCopy {
 			var in0 io.ReadCloser
-			in0, err = src.Open(hashOption)
+			in0, err = openRetrying(src, hashOption)
 			if err != nil {
 				err = errors.Wrap(err, "failed to open source object")
 			} else {
@@ -384,6 +504,18 @@ func Copy(f Fs, dst Object, remote string, src Object) (err error) {
 		}
 	}
 
+	// A server side copy makes a new object rather than updating
+	// the existing one, so remove that if the remote can have
+	// duplicate names otherwise both would be kept
+	if serverSideCopy && oldDst != nil && f.Features().DuplicateFiles {
+		err = oldDst.Remove()
+		if err != nil {
+			Stats.Error()
+			Errorf(oldDst, "Failed to remove replaced object: %v", err)
+			return err
+		}
+	}
+
 	Infof(src, actionTaken)
 	return err
 }
@@ -439,6 +571,18 @@ func CanServerSideMove(fdst Fs) bool {
 	return canMove || canCopy
 }
 
+// deleteBucket limits the number of deletes per second
+var deleteBucket *rate.Limiter
+
+// Start the token bucket for --delete-tpslimit if necessary
+func startDeleteTokenBucket() {
+	deleteBucket = nil
+	if Config.DeleteTPSLimit > 0 {
+		deleteBucket = rate.NewLimiter(rate.Limit(Config.DeleteTPSLimit), 1)
+		Infof(nil, "Starting delete limiter: max %g deletes/s", Config.DeleteTPSLimit)
+	}
+}
+
 // deleteFileWithBackupDir deletes a single file respecting --dry-run
 // and accumulating stats and errors.
 //
@@ -450,6 +594,13 @@ func deleteFileWithBackupDir(dst Object, backupDir Fs) (err error) {
 	if backupDir != nil {
 		action, actioned, actioning = "move into backup dir", "Moved into backup dir", "moving into backup dir"
 	}
+	if !Config.DryRun && deleteBucket != nil {
+		// Pace the deletes if required
+		tbErr := deleteBucket.Wait(context.Background())
+		if tbErr != nil {
+			Errorf(dst, "Delete token bucket error: %v", tbErr)
+		}
+	}
 	if Config.DryRun {
 		Logf(dst, "Not %s as --dry-run", actioning)
 	} else if backupDir != nil {
@@ -1152,11 +1303,22 @@ func Purge(f Fs) error {
 	}
 	if doFallbackPurge {
 		// DeleteFiles and Rmdir observe --dry-run
-		err = DeleteFiles(listToChan(f))
+		var checkpoint *purgeCheckpoint
+		checkpoint, err = openPurgeCheckpoint(f)
 		if err != nil {
 			return err
 		}
+		err = purgeFiles(f, checkpoint)
+		if err != nil {
+			_ = checkpoint.close(err)
+			return err
+		}
 		err = Rmdirs(f, "")
+		// Keep the checkpoint until the directories have gone too
+		closeErr := checkpoint.close(err)
+		if err == nil {
+			err = closeErr
+		}
 	}
 	if err != nil {
 		Stats.Error()
@@ -1495,6 +1657,118 @@ func CleanUp(f Fs) error {
 	return doCleanUp()
 }
 
+// FixDirMarkers reconciles the directory marker objects in f with the
+// objects actually stored there.
+//
+// Directories which contain objects don't need a marker to exist so
+// none are created for them.  If a directory has more than one marker,
+// eg "dir" and "dir/", the extra ones are removed.  The markers of
+// directories with no objects in are what keeps empty directories in
+// existence so they are only removed if removeEmpty is set.
+func FixDirMarkers(f Fs, removeEmpty bool) error {
+	features := f.Features()
+	if features.ListDirMarkers == nil || features.RemoveDirMarker == nil {
+		return errors.Errorf("%v doesn't support directory markers", f)
+	}
+	markers, err := features.ListDirMarkers()
+	if err != nil {
+		return errors.Wrap(err, "failed to list directory markers")
+	}
+	if len(markers) == 0 {
+		Infof(f, "No directory markers found")
+		return nil
+	}
+	// Find the directories which have objects in
+	hasObjects := make(map[string]bool)
+	err = Walk(f, "", true, -1, func(dirPath string, entries DirEntries, err error) error {
+		if err != nil {
+			return err
+		}
+		entries.ForObject(func(o Object) {
+			for dir := path.Dir(o.Remote()); dir != "." && !hasObjects[dir]; dir = path.Dir(dir) {
+				hasObjects[dir] = true
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list objects")
+	}
+	var errorCount int
+	marked := make(map[string]bool)
+	for _, marker := range markers {
+		dir := strings.TrimSuffix(marker, "/")
+		var reason string
+		switch {
+		case marked[dir]:
+			reason = "duplicate"
+		case hasObjects[dir]:
+			Debugf(dir, "Directory marker is for a directory with objects in")
+		case removeEmpty:
+			reason = "empty"
+		default:
+			Debugf(dir, "Keeping directory marker for empty directory")
+		}
+		marked[dir] = true
+		if reason == "" {
+			continue
+		}
+		if Config.DryRun {
+			Logf(marker, "Not removing %s directory marker as --dry-run", reason)
+			continue
+		}
+		err = features.RemoveDirMarker(marker)
+		if err != nil {
+			Stats.Error()
+			Errorf(marker, "Failed to remove %s directory marker: %v", reason, err)
+			errorCount++
+			continue
+		}
+		Infof(marker, "Removed %s directory marker", reason)
+	}
+	if errorCount > 0 {
+		return errors.Errorf("failed to remove %d directory markers", errorCount)
+	}
+	return nil
+}
+
+// CleanUpUploads aborts the incomplete multipart uploads in f which
+// were started more than maxAge ago.
+func CleanUpUploads(f Fs, maxAge time.Duration) error {
+	features := f.Features()
+	if features.ListMultipartUploads == nil || features.AbortMultipartUpload == nil {
+		return errors.Errorf("%v doesn't support cleaning up multipart uploads", f)
+	}
+	uploads, err := features.ListMultipartUploads()
+	if err != nil {
+		return errors.Wrap(err, "failed to list multipart uploads")
+	}
+	cutoff := time.Now().Add(-maxAge)
+	var errorCount int
+	for _, upload := range uploads {
+		if upload.Initiated.After(cutoff) {
+			Debugf(upload.Remote, "Not aborting multipart upload %q started at %v as too recent", upload.ID, upload.Initiated)
+			continue
+		}
+		if Config.DryRun {
+			Logf(upload.Remote, "Not aborting multipart upload %q started at %v as --dry-run", upload.ID, upload.Initiated)
+			continue
+		}
+		err = features.AbortMultipartUpload(upload)
+		if err != nil {
+			Stats.Error()
+			Errorf(upload.Remote, "Failed to abort multipart upload %q: %v", upload.ID, err)
+			errorCount++
+			continue
+		}
+		Infof(upload.Remote, "Aborted multipart upload %q started at %v", upload.ID, upload.Initiated)
+	}
+	if errorCount > 0 {
+		return errors.Errorf("failed to abort %d multipart uploads", errorCount)
+	}
+	return nil
+}
+
 // wrap a Reader and a Closer together into a ReadCloser
 type readCloser struct {
 	io.Reader
@@ -1533,7 +1807,7 @@ func Cat(f Fs, w io.Writer, offset, count int64) error {
 		if thisOffset > 0 {
 			options = append(options, &SeekOption{Offset: thisOffset})
 		}
-		in, err := o.Open(options...)
+		in, err := openRetrying(o, options...)
 		if err != nil {
 			Stats.Error()
 			Errorf(o, "Failed to open: %v", err)
@@ -1566,16 +1840,37 @@ func Cat(f Fs, w io.Writer, offset, count int64) error {
 }
 
 // Rcat reads data from the Reader until EOF and uploads it to a file on remote
-func Rcat(fdst Fs, dstFileName string, in0 io.ReadCloser, modTime time.Time) (dst Object, err error) {
+//
+// Any options are passed to the upload.  If there is a MimeTypeOption
+// the upload is given that mime type instead of one from the name.
+func Rcat(fdst Fs, dstFileName string, in0 io.ReadCloser, modTime time.Time, options ...OpenOption) (dst Object, err error) {
 	Stats.Transferring(dstFileName)
 	defer func() {
-		Stats.DoneTransferring(dstFileName, err == nil)
+		size := int64(-1)
+		if dst != nil {
+			size = dst.Size()
+		}
+		doneTransferring(dstFileName, size, err)
 		if otherErr := in0.Close(); otherErr != nil {
 			Debugf(fdst, "Rcat: failed to close source: %v", err)
 		}
 	}()
 
 	hashOption := &HashesOption{Hashes: fdst.Hashes()}
+	options = append([]OpenOption{hashOption}, options...)
+	// Use the mime type from a MimeTypeOption if there is one
+	mimeType := ""
+	for _, option := range options {
+		if x, ok := option.(*MimeTypeOption); ok {
+			mimeType = x.MimeType
+		}
+	}
+	withMimeType := func(objInfo ObjectInfo) ObjectInfo {
+		if mimeType == "" {
+			return objInfo
+		}
+		return &overrideMimeTypeInfo{ObjectInfo: objInfo, mimeType: mimeType}
+	}
 	hash, err := NewMultiHasherTypes(fdst.Hashes())
 	if err != nil {
 		return nil, err
@@ -1600,12 +1895,12 @@ func Rcat(fdst Fs, dstFileName string, in0 io.ReadCloser, modTime time.Time) (ds
 		Debugf(fdst, "File to upload is small (%d bytes), uploading instead of streaming", n)
 		in := ioutil.NopCloser(bytes.NewReader(buf[:n]))
 		in = NewAccountSizeName(in, int64(n), dstFileName).WithBuffer()
-		objInfo := NewStaticObjectInfo(dstFileName, modTime, int64(n), false, nil, nil)
+		objInfo := withMimeType(NewStaticObjectInfo(dstFileName, modTime, int64(n), false, nil, nil))
 		if Config.DryRun {
 			Logf("stdin", "Not uploading as --dry-run")
 			return nil, nil
 		}
-		dst, err := fdst.Put(in, objInfo, hashOption)
+		dst, err := fdst.Put(in, objInfo, options...)
 		if err != nil {
 			return dst, err
 		}
@@ -1639,15 +1934,19 @@ func Rcat(fdst Fs, dstFileName string, in0 io.ReadCloser, modTime time.Time) (ds
 		return nil, err
 	}
 
-	objInfo := NewStaticObjectInfo(dstFileName, modTime, -1, false, nil, nil)
-	if dst, err = fStreamTo.Features().PutStream(in, objInfo, hashOption); err != nil {
+	objInfo := withMimeType(NewStaticObjectInfo(dstFileName, modTime, -1, false, nil, nil))
+	if dst, err = fStreamTo.Features().PutStream(in, objInfo, options...); err != nil {
 		return dst, err
 	}
 	if err = compare(dst); err != nil {
 		return dst, err
 	}
 	if !canStream {
-		return dst, Copy(fdst, nil, dstFileName, dst)
+		src := dst
+		if mimeType != "" {
+			src = &overrideMimeTypeObject{Object: dst, mimeType: mimeType}
+		}
+		return dst, Copy(fdst, nil, dstFileName, src)
 	}
 	return dst, nil
 }
@@ -1743,10 +2042,15 @@ func moveOrCopyFile(fdst Fs, fsrc Fs, dstFileName string, srcFileName string, cp
 		return err
 	}
 
+	if dstObj != nil && Config.AddOnly {
+		Debugf(srcObj, "Destination exists, skipping as --add-only is set")
+		return nil
+	}
+
 	if NeedTransfer(dstObj, srcObj) {
 		Stats.Transferring(srcFileName)
 		err = Op(fdst, dstObj, dstFileName, srcObj)
-		Stats.DoneTransferring(srcFileName, err == nil)
+		doneTransferring(srcFileName, srcObj.Size(), err)
 	} else {
 		Stats.Checking(srcFileName)
 		if !cp {
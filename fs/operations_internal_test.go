@@ -3,7 +3,11 @@
 package fs
 
 import (
+	"fmt"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -90,3 +94,59 @@ func TestFilterAndSortUnknown(t *testing.T) {
 	assert.Error(t, err, "error")
 	assert.Nil(t, newEntries)
 }
+
+// removeTimeObject records when it was removed
+type removeTimeObject struct {
+	mockObject
+	mu    *sync.Mutex
+	times *[]time.Time
+}
+
+func (o removeTimeObject) Remove() error {
+	o.mu.Lock()
+	*o.times = append(*o.times, time.Now())
+	o.mu.Unlock()
+	return nil
+}
+
+func TestDeleteTPSLimit(t *testing.T) {
+	oldLimit, oldTransfers := Config.DeleteTPSLimit, Config.Transfers
+	defer func() {
+		Config.DeleteTPSLimit, Config.Transfers = oldLimit, oldTransfers
+		startDeleteTokenBucket()
+	}()
+	const (
+		limit = 20
+		n     = 10
+	)
+	Config.DeleteTPSLimit = limit
+	Config.Transfers = 4
+	startDeleteTokenBucket()
+	require.NotNil(t, deleteBucket)
+
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	toBeDeleted := make(ObjectsChan, n)
+	for i := 0; i < n; i++ {
+		toBeDeleted <- removeTimeObject{mockObject: mockObject(fmt.Sprintf("file%d", i)), mu: &mu, times: &times}
+	}
+	close(toBeDeleted)
+	require.NoError(t, DeleteFiles(toBeDeleted))
+
+	require.Equal(t, n, len(times))
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	// Allow a little slack for timer granularity
+	slack := 10 * time.Millisecond
+	for i := 1; i < n; i++ {
+		gap := times[i].Sub(times[0])
+		want := time.Duration(i) * time.Second / limit
+		assert.True(t, gap >= want-slack, "delete %d after %v want at least %v", i, gap, want)
+	}
+
+	// Check turning the limit off
+	Config.DeleteTPSLimit = 0
+	startDeleteTokenBucket()
+	assert.Nil(t, deleteBucket)
+}
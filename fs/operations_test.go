@@ -25,8 +25,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -289,6 +292,29 @@ func TestCheckSizeOnly(t *testing.T) {
 	TestCheck(t)
 }
 
+func TestSyncModTimes(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if fs.Config.ModifyWindow == fs.ModTimeNotSupported {
+		t.Skip("Can't test SyncModTimes - modification times not supported")
+	}
+	file1 := r.WriteFile("drifted", "same contents", t1)
+	file2 := r.WriteFile("sub dir/same", "same time", t2)
+	r.WriteFile("changed", "new contents", t1)
+	file1r := r.WriteObject("drifted", "same contents", t3)
+	r.WriteObject("sub dir/same", "same time", t2)
+	file3r := r.WriteObject("changed", "old contents", t3)
+	fstest.CheckItems(t, r.Fremote, file1r, file2, file3r)
+
+	fs.Stats.ResetCounters()
+	corrected, err := fs.SyncModTimes(r.Fremote, r.Flocal)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), corrected)
+	assert.Equal(t, int64(0), fs.Stats.GetTransfers())
+
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3r)
+}
+
 func skipIfCantDedupe(t *testing.T, f fs.Fs) {
 	if f.Features().PutUnchecked == nil {
 		t.Skip("Can't test deduplicate - no PutUnchecked")
@@ -419,6 +445,39 @@ func TestDeduplicateRename(t *testing.T) {
 	}))
 }
 
+// noRangeObject is an Object without the RangeOpener interface
+type noRangeObject struct {
+	fs.Object
+}
+
+func TestRangeOpen(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("file1", "0123456789", t1)
+
+	o, err := r.Fremote.NewObject(file1.Path)
+	require.NoError(t, err)
+	for _, obj := range []fs.Object{o, &noRangeObject{o}} {
+		for _, test := range []struct {
+			offset int64
+			length int64
+			want   string
+		}{
+			{0, -1, "0123456789"},
+			{2, 3, "234"},
+			{7, -1, "789"},
+			{5, 0, ""},
+		} {
+			in, err := fs.RangeOpen(obj, test.offset, test.length)
+			require.NoError(t, err)
+			got, err := ioutil.ReadAll(in)
+			require.NoError(t, err)
+			require.NoError(t, in.Close())
+			assert.Equal(t, test.want, string(got), "%T offset=%d, length=%d", obj, test.offset, test.length)
+		}
+	}
+}
+
 // This should really be a unit test, but the test framework there
 // doesn't have enough tools to make it easy
 func TestMergeDirs(t *testing.T) {
@@ -893,3 +952,377 @@ func TestCheckEqualReaders(t *testing.T) {
 	assert.Equal(t, myErr, err)
 	assert.Equal(t, differ, true)
 }
+
+// hashTestObject is an Object with a set of hashes
+type hashTestObject struct {
+	fs.Object
+	f      fs.Info
+	hashes map[fs.HashType]string
+}
+
+// Fs returns the Fs this object is part of
+func (o *hashTestObject) Fs() fs.Info { return o.f }
+
+// Hash returns the selected checksum of the object
+func (o *hashTestObject) Hash(hash fs.HashType) (string, error) {
+	return o.hashes[hash], nil
+}
+
+func TestCheckHashesChecksumChoice(t *testing.T) {
+	defer func() { fs.Config.ChecksumChoice = nil }()
+	hashes := map[fs.HashType]string{
+		fs.HashMD5:     "md5",
+		fs.HashSHA1:    "sha1",
+		fs.HashDropbox: "dropbox",
+	}
+	src := &hashTestObject{
+		f:      &testFsInfo{hashes: fs.NewHashSet(fs.HashMD5, fs.HashSHA1)},
+		hashes: hashes,
+	}
+	dst := &hashTestObject{
+		f:      &testFsInfo{hashes: fs.NewHashSet(fs.HashMD5, fs.HashDropbox)},
+		hashes: hashes,
+	}
+	for _, test := range []struct {
+		choice []fs.HashType
+		want   fs.HashType
+	}{
+		{nil, fs.HashMD5},
+		{[]fs.HashType{fs.HashSHA1, fs.HashMD5}, fs.HashMD5},
+		{[]fs.HashType{fs.HashDropbox, fs.HashSHA1}, fs.HashNone},
+		{[]fs.HashType{fs.HashNone, fs.HashMD5}, fs.HashNone},
+	} {
+		fs.Config.ChecksumChoice = test.choice
+		equal, hash, err := fs.CheckHashes(src, dst)
+		require.NoError(t, err)
+		assert.True(t, equal)
+		assert.Equal(t, test.want, hash, fmt.Sprintf("%v", test.choice))
+	}
+}
+
+// multipartFs is an Fs with some pending multipart uploads
+type multipartFs struct {
+	fs.Fs
+	features *fs.Features
+	uploads  []fs.MultipartUpload
+	aborted  []string
+}
+
+func newMultipartFs(f fs.Fs, uploads []fs.MultipartUpload) *multipartFs {
+	m := &multipartFs{Fs: f, uploads: uploads}
+	m.features = (&fs.Features{}).Fill(m)
+	return m
+}
+
+// Features returns the optional features of this Fs
+func (m *multipartFs) Features() *fs.Features { return m.features }
+
+// ListMultipartUploads returns the pending uploads
+func (m *multipartFs) ListMultipartUploads() ([]fs.MultipartUpload, error) {
+	return m.uploads, nil
+}
+
+// AbortMultipartUpload records the upload as aborted
+func (m *multipartFs) AbortMultipartUpload(upload fs.MultipartUpload) error {
+	m.aborted = append(m.aborted, upload.ID)
+	return nil
+}
+
+func TestCleanUpUploads(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	err := fs.CleanUpUploads(r.Fremote, time.Hour)
+	assert.Error(t, err)
+
+	now := time.Now()
+	f := newMultipartFs(r.Fremote, []fs.MultipartUpload{
+		{Remote: "old", ID: "1", Initiated: now.Add(-3 * time.Hour)},
+		{Remote: "new", ID: "2", Initiated: now.Add(-time.Minute)},
+		{Remote: "dir/old", ID: "3", Initiated: now.Add(-2 * time.Hour)},
+	})
+
+	fs.Config.DryRun = true
+	err = fs.CleanUpUploads(f, time.Hour)
+	fs.Config.DryRun = false
+	require.NoError(t, err)
+	assert.Equal(t, []string(nil), f.aborted)
+
+	err = fs.CleanUpUploads(f, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, f.aborted)
+}
+
+// openCountObject counts the number of times it is opened
+type openCountObject struct {
+	fs.Object
+	opens int
+}
+
+// Open the object counting the opens
+func (o *openCountObject) Open(options ...fs.OpenOption) (io.ReadCloser, error) {
+	o.opens++
+	return o.Object.Open(options...)
+}
+
+// failPutFs is an Fs whose uploads fail after reading some data
+type failPutFs struct {
+	fs.Fs
+}
+
+// Put reads a little of in then fails
+func (f *failPutFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	_, _ = io.ReadFull(in, make([]byte, 2))
+	return nil, errors.New("upload failed")
+}
+
+func TestCopyMulti(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("file1", "hello world", t1)
+	fstest.CheckItems(t, r.Flocal, file1)
+
+	fdst1, err := fs.NewFs(r.FremoteName + "/dst1")
+	require.NoError(t, err)
+	fdst2, err := fs.NewFs(r.FremoteName + "/dst2")
+	require.NoError(t, err)
+	fdst3, err := fs.NewFs(r.FremoteName + "/dst3")
+	require.NoError(t, err)
+	for _, f := range []fs.Fs{fdst1, fdst2} {
+		require.NoError(t, fs.Mkdir(f, ""))
+	}
+
+	o, err := r.Flocal.NewObject("file1")
+	require.NoError(t, err)
+	src := &openCountObject{Object: o}
+
+	errs := fs.CopyMulti([]fs.Fs{fdst1, &failPutFs{Fs: fdst3}, fdst2}, src)
+	require.Equal(t, 1, len(errs))
+	assert.EqualError(t, errs[0], "upload failed")
+	assert.Equal(t, 1, src.opens)
+	fstest.CheckItems(t, fdst1, file1)
+	fstest.CheckItems(t, fdst2, file1)
+
+	// Check it doesn't copy again if up to date
+	errs = fs.CopyMulti([]fs.Fs{fdst1, fdst2}, src)
+	assert.Equal(t, 0, len(errs))
+	assert.Equal(t, 1, src.opens)
+}
+
+func TestCopyDirMulti(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("file1", "hello world", t1)
+	file2 := r.WriteFile("sub dir/file2", "hello again", t2)
+	fstest.CheckItems(t, r.Flocal, file1, file2)
+
+	fdst1, err := fs.NewFs(r.FremoteName + "/dst1")
+	require.NoError(t, err)
+	fdst2, err := fs.NewFs(r.FremoteName + "/dst2")
+	require.NoError(t, err)
+
+	require.NoError(t, fs.CopyDirMulti([]fs.Fs{fdst1, fdst2}, r.Flocal))
+	fstest.CheckItems(t, fdst1, file1, file2)
+	fstest.CheckItems(t, fdst2, file1, file2)
+	fstest.CheckItems(t, r.Flocal, file1, file2)
+
+	// Check overlapping destinations are rejected
+	err = fs.CopyDirMulti([]fs.Fs{fdst1, r.Flocal}, r.Flocal)
+	assert.Equal(t, fs.ErrorCantCopyOverlapping, err)
+}
+
+// markerFs is an Fs with some directory markers
+type markerFs struct {
+	fs.Fs
+	features *fs.Features
+	markers  []string
+	removed  []string
+}
+
+func newMarkerFs(f fs.Fs, markers []string) *markerFs {
+	m := &markerFs{Fs: f, markers: markers}
+	m.features = (&fs.Features{}).Fill(m)
+	return m
+}
+
+// Features returns the optional features of this Fs
+func (m *markerFs) Features() *fs.Features { return m.features }
+
+// ListDirMarkers returns the markers
+func (m *markerFs) ListDirMarkers() ([]string, error) {
+	return m.markers, nil
+}
+
+// RemoveDirMarker records the marker as removed
+func (m *markerFs) RemoveDirMarker(marker string) error {
+	m.removed = append(m.removed, marker)
+	return nil
+}
+
+func TestFixDirMarkers(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("a/file1", "file1", t1)
+	file2 := r.WriteObject("a/b/c/file2", "file2", t1)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	err := fs.FixDirMarkers(r.Fremote, false)
+	assert.Error(t, err)
+
+	// Missing markers for directories with objects in aren't created
+	f := newMarkerFs(r.Fremote, []string{"a/b/"})
+	require.NoError(t, fs.FixDirMarkers(f, false))
+	assert.Equal(t, []string(nil), f.removed)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	// Duplicate markers are removed but the markers of empty
+	// directories are kept
+	markers := []string{"a", "a/b/", "a/", "empty", "empty/", "a/empty/"}
+	f = newMarkerFs(r.Fremote, markers)
+	fs.Config.DryRun = true
+	require.NoError(t, fs.FixDirMarkers(f, false))
+	fs.Config.DryRun = false
+	assert.Equal(t, []string(nil), f.removed)
+	require.NoError(t, fs.FixDirMarkers(f, false))
+	assert.Equal(t, []string{"a/", "empty/"}, f.removed)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	// The markers of empty directories are removed if asked
+	f = newMarkerFs(r.Fremote, markers)
+	require.NoError(t, fs.FixDirMarkers(f, true))
+	assert.Equal(t, []string{"a/", "empty", "empty/", "a/empty/"}, f.removed)
+}
+
+// staleListFs is an Fs which can't purge directly and whose listing
+// of the root doesn't change, like an eventually consistent remote.
+// Once failAfter objects have been removed the rest fail to remove,
+// and directories fail to remove if failRmdir is set.
+type staleListFs struct {
+	fs.Fs
+	listing   fs.DirEntries
+	mu        sync.Mutex
+	removes   []string
+	failAfter int
+	failRmdir bool
+}
+
+// newStaleListFs makes a staleListFs with the current listing of f
+func newStaleListFs(t *testing.T, f fs.Fs) *staleListFs {
+	sf := &staleListFs{Fs: f}
+	entries, err := f.List("")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entry = &staleListObject{Object: o, f: sf}
+		}
+		sf.listing = append(sf.listing, entry)
+	}
+	return sf
+}
+
+// Features returns the features of the wrapped Fs without Purge
+func (f *staleListFs) Features() *fs.Features {
+	features := *f.Fs.Features()
+	features.Purge = nil
+	features.ListR = nil
+	return &features
+}
+
+// List returns the stale listing for the root
+func (f *staleListFs) List(dir string) (fs.DirEntries, error) {
+	if dir != "" {
+		return f.Fs.List(dir)
+	}
+	return f.listing, nil
+}
+
+// Rmdir removes the directory unless failRmdir is set
+func (f *staleListFs) Rmdir(dir string) error {
+	if f.failRmdir {
+		return errors.New("rmdir failed")
+	}
+	return f.Fs.Rmdir(dir)
+}
+
+// staleListObject is an Object in a staleListFs
+type staleListObject struct {
+	fs.Object
+	f *staleListFs
+}
+
+// Remove records the remove and fails it if required
+func (o *staleListObject) Remove() error {
+	o.f.mu.Lock()
+	o.f.removes = append(o.f.removes, o.Remote())
+	fail := o.f.failAfter >= 0 && len(o.f.removes) > o.f.failAfter
+	o.f.mu.Unlock()
+	if fail {
+		return errors.New("interrupted")
+	}
+	return o.Object.Remove()
+}
+
+func TestPurgeResume(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	for i := 0; i < 10; i++ {
+		r.WriteObject(fmt.Sprintf("file%d", i), "purge me", t1)
+	}
+	require.NoError(t, r.Fremote.Mkdir("empty"))
+
+	dir, err := ioutil.TempDir("", "rclone-purge")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	resumeFile := filepath.Join(dir, "purge-resume")
+	fs.Config.PurgeResumeFile = resumeFile
+	defer func() { fs.Config.PurgeResumeFile = "" }()
+
+	// Interrupt the purge after 4 deletions
+	f := newStaleListFs(t, r.Fremote)
+	f.failAfter = 4
+	err = fs.Purge(f)
+	require.Error(t, err)
+	require.Equal(t, 10, len(f.removes))
+	deleted := f.removes[:4]
+	contents, err := ioutil.ReadFile(resumeFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	require.Equal(t, 5, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], "rclone purge checkpoint: "))
+	assert.Subset(t, lines[1:], deleted)
+	assert.Subset(t, deleted, lines[1:])
+
+	// The re-run should only try the objects which weren't deleted
+	// even though the listing still has the deleted ones
+	f.removes, f.failAfter, f.failRmdir = nil, -1, true
+	err = fs.Purge(f)
+	require.Error(t, err)
+	assert.Equal(t, 6, len(f.removes))
+	for _, remote := range deleted {
+		assert.NotContains(t, f.removes, remote)
+	}
+
+	// The resume file is kept as the directories weren't removed
+	_, err = os.Stat(resumeFile)
+	assert.NoError(t, err)
+
+	// So the next run only has the directories left to remove
+	f.removes, f.failRmdir = nil, false
+	err = fs.Purge(f)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(f.removes))
+
+	// The resume file is removed when the purge succeeds
+	_, err = os.Stat(resumeFile)
+	assert.True(t, os.IsNotExist(err), "resume file not removed")
+
+	// A resume file for a different remote is rejected
+	require.NoError(t, ioutil.WriteFile(resumeFile, []byte("rclone purge checkpoint: potato\n"), 0600))
+	file1 := r.WriteFile("keep me", "not purged", t1)
+	err = fs.Purge(newStaleListFs(t, r.Flocal))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not for")
+	fstest.CheckItems(t, r.Flocal, file1)
+}
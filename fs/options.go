@@ -115,6 +115,50 @@ func (o *HashesOption) Mandatory() bool {
 	return false
 }
 
+// ChunkOption defines an option used to tell remotes which upload in
+// chunks what size of chunk to use.  Remotes which don't upload in
+// chunks ignore it.
+type ChunkOption struct {
+	ChunkSize int64
+}
+
+// Header formats the option as an http header
+func (o *ChunkOption) Header() (key string, value string) {
+	return "", ""
+}
+
+// String formats the option into human readable form
+func (o *ChunkOption) String() string {
+	return fmt.Sprintf("ChunkOption(%d)", o.ChunkSize)
+}
+
+// Mandatory returns whether the option must be parsed or can be ignored
+func (o *ChunkOption) Mandatory() bool {
+	return false
+}
+
+// MimeTypeOption defines an option used to tell Rcat the mime type
+// of the data being uploaded, so remotes which store mime types use
+// it instead of guessing one from the name.
+type MimeTypeOption struct {
+	MimeType string
+}
+
+// Header formats the option as an http header
+func (o *MimeTypeOption) Header() (key string, value string) {
+	return "", ""
+}
+
+// String formats the option into human readable form
+func (o *MimeTypeOption) String() string {
+	return fmt.Sprintf("MimeTypeOption(%q)", o.MimeType)
+}
+
+// Mandatory returns whether the option must be parsed or can be ignored
+func (o *MimeTypeOption) Mandatory() bool {
+	return false
+}
+
 // OpenOptionAddHeaders adds each header found in options to the
 // headers map provided the key was non empty.
 func OpenOptionAddHeaders(options []OpenOption, headers map[string]string) {
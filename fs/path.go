@@ -3,6 +3,8 @@ package fs
 import (
 	"path"
 	"strings"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // RemoteSplit splits a remote into a parent and a leaf
@@ -28,3 +30,16 @@ func RemoteSplit(remote string) (parent string, leaf string) {
 	parent, leaf = path.Split(remotePath)
 	return remoteName + parent, leaf
 }
+
+// NormalizeUnicode returns name converted to the unicode normal form
+// set with --unicode-normalization.  If that isn't set then name is
+// returned unchanged.
+func NormalizeUnicode(name string) string {
+	switch Config.UnicodeNormalization {
+	case "nfc":
+		return norm.NFC.String(name)
+	case "nfd":
+		return norm.NFD.String(name)
+	}
+	return name
+}
@@ -33,3 +33,25 @@ func TestRemoteSplit(t *testing.T) {
 		assert.Equal(t, test.remote, gotParent+gotLeaf, fmt.Sprintf("%s: %q + %q != %q", test.remote, gotParent, gotLeaf, test.remote))
 	}
 }
+
+func TestNormalizeUnicode(t *testing.T) {
+	defer func() { Config.UnicodeNormalization = "" }()
+	nfc := "Test\u00ea\u00e9"
+	nfd := "Teste\u0302e\u0301"
+
+	for _, test := range []struct {
+		form string
+		in   string
+		want string
+	}{
+		{"", nfc, nfc},
+		{"", nfd, nfd},
+		{"nfc", nfc, nfc},
+		{"nfc", nfd, nfc},
+		{"nfd", nfc, nfd},
+		{"nfd", nfd, nfd},
+	} {
+		Config.UnicodeNormalization = test.form
+		assert.Equal(t, test.want, NormalizeUnicode(test.in), fmt.Sprintf("%q: %q", test.form, test.in))
+	}
+}
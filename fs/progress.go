@@ -0,0 +1,163 @@
+// Progress sinks for library users who want progress programmatically
+
+package fs
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is the minimum time between TransferUpdates for a
+// transfer in progress
+var progressInterval = 500 * time.Millisecond
+
+// TransferUpdate describes the progress of a single transfer
+type TransferUpdate struct {
+	Name  string  // name of the file being transferred
+	Bytes int64   // bytes transferred so far
+	Size  int64   // size of the file or -1 if unknown
+	Speed float64 // average speed in bytes per second
+	Done  bool    // set if this is the final update for the transfer
+	Err   error   // if Done this is the result of the transfer
+}
+
+// StatsSnapshot is a copy of the global stats at an instant
+type StatsSnapshot struct {
+	Bytes        int64
+	Errors       int64
+	Checks       int64
+	Transfers    int64
+	Elapsed      time.Duration
+	Checking     []string // sorted names of the checks in progress
+	Transferring []string // sorted names of the transfers in progress
+}
+
+// ProgressSink receives progress as rclone works.  Register one with
+// AddProgressSink.
+//
+// The methods are called synchronously from the transfers so they
+// should return quickly, and they may be called concurrently.
+type ProgressSink interface {
+	// Transfer is called periodically as a transfer progresses
+	// and once more with Done set when it has finished
+	Transfer(update TransferUpdate)
+
+	// Stats is called with a snapshot of the stats each time a
+	// transfer finishes
+	Stats(snapshot StatsSnapshot)
+}
+
+// progressSinkEntry is a registered ProgressSink
+type progressSinkEntry struct {
+	sink ProgressSink
+}
+
+var (
+	progressSinksMu  sync.RWMutex
+	progressSinks    []*progressSinkEntry
+	progressSinksSet int32 // non zero if there are sinks - use atomic
+)
+
+// AddProgressSink registers sink to receive progress.  Call the
+// function returned to remove it again.
+func AddProgressSink(sink ProgressSink) (remove func()) {
+	entry := &progressSinkEntry{sink: sink}
+	progressSinksMu.Lock()
+	progressSinks = append(progressSinks, entry)
+	atomic.StoreInt32(&progressSinksSet, 1)
+	progressSinksMu.Unlock()
+	return func() {
+		progressSinksMu.Lock()
+		defer progressSinksMu.Unlock()
+		for i, e := range progressSinks {
+			if e == entry {
+				progressSinks = append(progressSinks[:i:i], progressSinks[i+1:]...)
+				break
+			}
+		}
+		if len(progressSinks) == 0 {
+			atomic.StoreInt32(&progressSinksSet, 0)
+		}
+	}
+}
+
+// haveProgressSinks returns true if any sinks are registered
+func haveProgressSinks() bool {
+	return atomic.LoadInt32(&progressSinksSet) != 0
+}
+
+// sendProgress calls fn on each registered sink
+func sendProgress(fn func(ProgressSink)) {
+	progressSinksMu.RLock()
+	defer progressSinksMu.RUnlock()
+	for _, entry := range progressSinks {
+		fn(entry.sink)
+	}
+}
+
+// sendTransferUpdate sends update to the sinks if there are any
+func sendTransferUpdate(update TransferUpdate) {
+	if !haveProgressSinks() {
+		return
+	}
+	sendProgress(func(sink ProgressSink) {
+		sink.Transfer(update)
+	})
+}
+
+// sendStatsSnapshot sends a snapshot of the stats to the sinks if
+// there are any
+func sendStatsSnapshot() {
+	if !haveProgressSinks() {
+		return
+	}
+	snapshot := Stats.Snapshot()
+	sendProgress(func(sink ProgressSink) {
+		sink.Stats(snapshot)
+	})
+}
+
+// names returns the names in the stringSet, sorted
+func (ss stringSet) names() []string {
+	names := make([]string, 0, len(ss))
+	for name := range ss {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot returns a copy of the current stats
+func (s *StatsInfo) Snapshot() StatsSnapshot {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return StatsSnapshot{
+		Bytes:        s.bytes,
+		Errors:       s.errors,
+		Checks:       s.checks,
+		Transfers:    s.transfers,
+		Elapsed:      time.Now().Sub(s.start),
+		Checking:     s.checking.names(),
+		Transferring: s.transferring.names(),
+	}
+}
+
+// progressUpdate sends a TransferUpdate for acc if it is time to -
+// call with acc.statmu held
+func (acc *Account) progressUpdate(now time.Time) {
+	if now.Sub(acc.lastUpdate) < progressInterval {
+		return
+	}
+	acc.lastUpdate = now
+	update := TransferUpdate{
+		Name:  acc.name,
+		Bytes: acc.bytes,
+		Size:  acc.size,
+	}
+	if dt := now.Sub(acc.start).Seconds(); dt > 0 {
+		update.Speed = float64(acc.bytes) / dt
+	}
+	sendTransferUpdate(update)
+}
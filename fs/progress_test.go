@@ -0,0 +1,90 @@
+package fs_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink is a ProgressSink which records everything it receives
+type recordingSink struct {
+	mu        sync.Mutex
+	updates   []fs.TransferUpdate
+	snapshots []fs.StatsSnapshot
+}
+
+func (s *recordingSink) Transfer(update fs.TransferUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updates = append(s.updates, update)
+}
+
+func (s *recordingSink) Stats(snapshot fs.StatsSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, snapshot)
+}
+
+func TestProgressSink(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("file1", "hello", t1)
+	file2 := r.WriteFile("sub dir/file2", "hello world", t2)
+	file3 := r.WriteFile("sub dir/file3", "hello again world", t3)
+	r.Mkdir(r.Fremote)
+
+	sink := &recordingSink{}
+	remove := fs.AddProgressSink(sink)
+
+	fs.Stats.ResetCounters()
+	err := fs.CopyDir(r.Fremote, r.Flocal)
+	require.NoError(t, err)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3)
+
+	// Check it stops receiving once removed
+	remove()
+	r.WriteFile("file4", "not seen", t1)
+	require.NoError(t, fs.CopyDir(r.Fremote, r.Flocal))
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	// Each file should have progress updates then a final one
+	sizes := map[string]int64{
+		file1.Path: file1.Size,
+		file2.Path: file2.Size,
+		file3.Path: file3.Size,
+	}
+	progress := map[string]int{}
+	done := map[string]int{}
+	for _, update := range sink.updates {
+		size, ok := sizes[update.Name]
+		require.True(t, ok, "unexpected update for %q", update.Name)
+		assert.Equal(t, size, update.Size, update.Name)
+		if update.Done {
+			assert.NoError(t, update.Err, update.Name)
+			assert.Equal(t, size, update.Bytes, update.Name)
+			done[update.Name]++
+		} else {
+			assert.Equal(t, 0, done[update.Name], "progress after done for %q", update.Name)
+			assert.True(t, update.Bytes <= size, update.Name)
+			progress[update.Name]++
+		}
+	}
+	for name := range sizes {
+		assert.Equal(t, 1, done[name], name)
+		assert.True(t, progress[name] > 0, name)
+	}
+
+	// There should be a snapshot after each transfer
+	require.Equal(t, 3, len(sink.snapshots))
+	last := sink.snapshots[len(sink.snapshots)-1]
+	assert.Equal(t, int64(3), last.Transfers)
+	assert.Equal(t, file1.Size+file2.Size+file3.Size, last.Bytes)
+	assert.Equal(t, int64(0), last.Errors)
+	assert.Equal(t, []string{}, last.Transferring)
+}
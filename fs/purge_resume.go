@@ -0,0 +1,160 @@
+// Checkpoint purges so they can be resumed
+
+package fs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// purgeCheckpointHeader starts the first line of a
+// --purge-resume-file and is followed by the remote being purged
+const purgeCheckpointHeader = "rclone purge checkpoint: "
+
+// purgeCheckpoint records the objects deleted by a purge in a file so
+// that if the purge is interrupted a re-run can skip them.  This
+// matters on remotes whose listings can still show objects for a
+// while after they have been deleted.
+type purgeCheckpoint struct {
+	path    string
+	mu      sync.Mutex          // protects the following
+	deleted map[string]struct{} // objects deleted by previous runs
+	out     *os.File            // file the deletions are appended to
+}
+
+// openPurgeCheckpoint reads the --purge-resume-file for a purge of f,
+// creating it if it doesn't exist.  It returns nil if there is no
+// --purge-resume-file or --dry-run is set.
+func openPurgeCheckpoint(f Fs) (*purgeCheckpoint, error) {
+	path := Config.PurgeResumeFile
+	if path == "" || Config.DryRun {
+		return nil, nil
+	}
+	c := &purgeCheckpoint{
+		path:    path,
+		deleted: make(map[string]struct{}),
+	}
+	header := purgeCheckpointHeader + f.String()
+	in, err := os.Open(path)
+	if err == nil {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(nil, 1024*1024)
+		first := true
+		for scanner.Scan() {
+			line := scanner.Text()
+			if first {
+				first = false
+				if line != header {
+					_ = in.Close()
+					return nil, errors.Errorf("--purge-resume-file %q is not for %v", path, f)
+				}
+				continue
+			}
+			if line != "" {
+				c.deleted[line] = struct{}{}
+			}
+		}
+		err = scanner.Err()
+		closeErr := in.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read --purge-resume-file")
+		}
+		if len(c.deleted) > 0 {
+			Logf(f, "Resuming purge - skipping %d objects already deleted", len(c.deleted))
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to open --purge-resume-file")
+	}
+	c.out, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open --purge-resume-file")
+	}
+	fi, err := c.out.Stat()
+	if err == nil && fi.Size() == 0 {
+		_, err = fmt.Fprintln(c.out, header)
+	}
+	if err != nil {
+		_ = c.out.Close()
+		return nil, errors.Wrap(err, "failed to write --purge-resume-file")
+	}
+	return c, nil
+}
+
+// isDeleted returns true if remote was deleted by a previous run
+func (c *purgeCheckpoint) isDeleted(remote string) bool {
+	_, ok := c.deleted[remote]
+	return ok
+}
+
+// markDeleted records that remote has been deleted
+func (c *purgeCheckpoint) markDeleted(remote string) {
+	if strings.ContainsAny(remote, "\r\n") {
+		// Can't be recorded in a line based file
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := fmt.Fprintln(c.out, remote)
+	if err != nil {
+		Errorf(remote, "Failed to write --purge-resume-file: %v", err)
+	}
+}
+
+// close closes the checkpoint file, removing it if the purge
+// succeeded as it is no longer needed.  It does nothing if c is nil.
+func (c *purgeCheckpoint) close(purgeErr error) error {
+	if c == nil {
+		return nil
+	}
+	err := c.out.Close()
+	if purgeErr == nil {
+		err = os.Remove(c.path)
+	}
+	return err
+}
+
+// checkpointedObject is an Object which records itself in the
+// purgeCheckpoint when it is removed
+type checkpointedObject struct {
+	Object
+	checkpoint *purgeCheckpoint
+}
+
+// Remove removes the object recording it in the checkpoint
+func (o *checkpointedObject) Remove() error {
+	err := o.Object.Remove()
+	if err == nil {
+		o.checkpoint.markDeleted(o.Remote())
+	}
+	return err
+}
+
+// purgeFiles deletes all the objects in f.
+//
+// If checkpoint is set then the deletions are recorded there and
+// objects deleted by a previous run are skipped.
+func purgeFiles(f Fs, checkpoint *purgeCheckpoint) error {
+	if checkpoint == nil {
+		return DeleteFiles(listToChan(f))
+	}
+	toBeDeleted := make(ObjectsChan, Config.Transfers)
+	go func() {
+		defer close(toBeDeleted)
+		for o := range listToChan(f) {
+			if checkpoint.isDeleted(o.Remote()) {
+				Debugf(o, "Skipping as already deleted according to --purge-resume-file")
+				continue
+			}
+			toBeDeleted <- &checkpointedObject{Object: o, checkpoint: checkpoint}
+		}
+	}()
+	return DeleteFiles(toBeDeleted)
+}
@@ -2,6 +2,7 @@ package fs
 
 import (
 	"io"
+	"io/ioutil"
 
 	"github.com/pkg/errors"
 )
@@ -63,3 +64,32 @@ func (r *RepeatableReader) Read(b []byte) (n int, err error) {
 func NewRepeatableReader(r io.Reader) *RepeatableReader {
 	return &RepeatableReader{in: r}
 }
+
+// rangeReadCloser reads from a Reader and closes a Closer
+type rangeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// NewRangeReadCloser discards offset bytes from in then returns a
+// ReadCloser which reads at most length bytes of the rest of it, or
+// all of it if length is < 0.  Closing it closes in.
+//
+// This is used to read a range of an object from a stream of the
+// whole object.  If there is an error in is closed.
+func NewRangeReadCloser(in io.ReadCloser, offset, length int64) (io.ReadCloser, error) {
+	if offset > 0 {
+		_, err := io.CopyN(ioutil.Discard, in, offset)
+		if err != nil && err != io.EOF {
+			_ = in.Close()
+			return nil, err
+		}
+	}
+	if length < 0 {
+		return in, nil
+	}
+	return &rangeReadCloser{
+		Reader: io.LimitReader(in, length),
+		Closer: in,
+	}, nil
+}
@@ -3,6 +3,7 @@ package fs
 import (
 	"bytes"
 	"io"
+	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -98,3 +99,38 @@ func TestRepeatableReader(t *testing.T) {
 	assert.Equal(t, b[2:7], dst)
 
 }
+
+// closeRecorder records whether it has been closed
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestNewRangeReadCloser(t *testing.T) {
+	for _, test := range []struct {
+		offset int64
+		length int64
+		want   string
+	}{
+		{0, -1, "0123456789"},
+		{0, 3, "012"},
+		{4, -1, "456789"},
+		{4, 3, "456"},
+		{8, 5, "89"},
+		{20, -1, ""},
+	} {
+		in := &closeRecorder{Reader: bytes.NewBufferString("0123456789")}
+		rc, err := NewRangeReadCloser(in, test.offset, test.length)
+		require.NoError(t, err)
+		got, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, test.want, string(got), "offset=%d, length=%d", test.offset, test.length)
+		require.NoError(t, rc.Close())
+		assert.True(t, in.closed)
+	}
+}
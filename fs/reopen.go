@@ -0,0 +1,146 @@
+package fs
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// retryReadsSleep is the initial time to wait before re-opening an
+// object after a failed read.  It doubles on each consecutive retry.
+var retryReadsSleep = 100 * time.Millisecond
+
+// errorFileClosed is returned by ReOpen once it has been closed
+var errorFileClosed = errors.New("file already closed")
+
+// ReOpen is an io.ReadCloser which re-opens the object it is reading
+// if a read fails, carrying on from the last good offset.
+type ReOpen struct {
+	mu       sync.Mutex
+	src      Object       // object to open
+	options  []OpenOption // options to pass to Open (without any seek/range)
+	start    int64        // offset the read started at
+	end      int64        // end of the range to read or -1 for the end of the object
+	maxTries int          // maximum number of retries
+	rc       io.ReadCloser
+	read     int64 // number of bytes read so far
+	tries    int   // number of retries without progress
+	err      error // if set then Read returns this
+}
+
+// NewReOpen makes a handle which will re-open src on a failed read,
+// up to maxTries times in a row, seeking to the last good offset.
+//
+// Any SeekOption or RangeOption in options will be respected.
+func NewReOpen(src Object, maxTries int, options ...OpenOption) (rc *ReOpen, err error) {
+	h := &ReOpen{
+		src:      src,
+		end:      -1,
+		maxTries: maxTries,
+	}
+	for _, option := range options {
+		switch x := option.(type) {
+		case *SeekOption:
+			h.start = x.Offset
+		case *RangeOption:
+			h.start, h.end = x.Start, x.End
+		default:
+			h.options = append(h.options, option)
+		}
+	}
+	h.rc, err = h.open()
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// open the object from the current offset
+func (h *ReOpen) open() (io.ReadCloser, error) {
+	options := h.options
+	offset := h.start + h.read
+	if h.end >= 0 {
+		options = append(options[:len(options):len(options)], &RangeOption{Start: offset, End: h.end})
+	} else if offset > 0 {
+		options = append(options[:len(options):len(options)], &SeekOption{Offset: offset})
+	}
+	return h.src.Open(options...)
+}
+
+// reopen closes the current reader, waits and opens the object again
+// at the last good offset - call with the lock held
+func (h *ReOpen) reopen(readErr error) error {
+	_ = h.rc.Close()
+	h.rc = nil
+	for h.tries < h.maxTries {
+		sleep := retryReadsSleep << uint(h.tries)
+		h.tries++
+		Debugf(h.src, "Read failed at offset %d - retry %d/%d in %v: %v", h.start+h.read, h.tries, h.maxTries, sleep, readErr)
+		time.Sleep(sleep)
+		rc, err := h.open()
+		if err == nil {
+			h.rc = rc
+			return nil
+		}
+		readErr = err
+	}
+	return errors.Wrapf(readErr, "failed to read after %d retries", h.maxTries)
+}
+
+// Read bytes retrying as necessary
+func (h *ReOpen) Read(p []byte) (n int, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err != nil {
+		return 0, h.err
+	}
+	for {
+		n, err = h.rc.Read(p)
+		h.read += int64(n)
+		if err == nil || err == io.EOF {
+			if n > 0 {
+				h.tries = 0
+			}
+			return n, err
+		}
+		if n > 0 {
+			// Return what we have and retry on the next Read
+			h.tries = 0
+			err = h.reopen(err)
+			if err != nil {
+				h.err = err
+			}
+			return n, nil
+		}
+		err = h.reopen(err)
+		if err != nil {
+			h.err = err
+			return 0, err
+		}
+	}
+}
+
+// Close the stream
+func (h *ReOpen) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err == errorFileClosed {
+		return errorFileClosed
+	}
+	h.err = errorFileClosed
+	if h.rc == nil {
+		return nil
+	}
+	return h.rc.Close()
+}
+
+// openRetrying opens src with options, using ReOpen to retry failed
+// reads if --retry-reads is set
+func openRetrying(src Object, options ...OpenOption) (io.ReadCloser, error) {
+	if !Config.RetryReads {
+		return src.Open(options...)
+	}
+	return NewReOpen(src, Config.LowLevelRetries, options...)
+}
@@ -0,0 +1,135 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errorTestRead = errors.New("test read error")
+
+// reOpenTestObject is an Object whose first opens fail part way
+// through reading
+type reOpenTestObject struct {
+	mockObject
+	contents []byte
+	breaks   []int64   // fail the Nth open after reading this many bytes
+	opens    *[]string // the offsets opened at
+}
+
+// Open opens the object respecting SeekOption and RangeOption
+func (o *reOpenTestObject) Open(options ...OpenOption) (io.ReadCloser, error) {
+	start, end := int64(0), int64(len(o.contents))-1
+	for _, option := range options {
+		switch x := option.(type) {
+		case *SeekOption:
+			start = x.Offset
+		case *RangeOption:
+			start, end = x.Start, x.End
+		}
+	}
+	*o.opens = append(*o.opens, OpenOptionHeaders(options)["Range"])
+	var rc io.Reader = bytes.NewBuffer(o.contents[start : end+1])
+	if n := len(*o.opens) - 1; n < len(o.breaks) {
+		rc = io.MultiReader(io.LimitReader(rc, o.breaks[n]), &errorReader{errorTestRead})
+	}
+	return ioutil.NopCloser(rc), nil
+}
+
+// errorReader returns err on every Read
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (n int, err error) {
+	return 0, r.err
+}
+
+func TestReOpen(t *testing.T) {
+	oldSleep := retryReadsSleep
+	retryReadsSleep = time.Millisecond
+	defer func() { retryReadsSleep = oldSleep }()
+
+	contents := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	for _, test := range []struct {
+		what      string
+		options   []OpenOption
+		breaks    []int64
+		maxTries  int
+		want      string
+		wantOpens []string
+		wantErr   bool
+	}{
+		{
+			what:      "no errors",
+			want:      string(contents),
+			wantOpens: []string{""},
+		},
+		{
+			what:      "one failure mid stream",
+			breaks:    []int64{10},
+			maxTries:  3,
+			want:      string(contents),
+			wantOpens: []string{"", "bytes=10-"},
+		},
+		{
+			what:      "failures with no progress",
+			breaks:    []int64{10, 0, 0},
+			maxTries:  3,
+			want:      string(contents),
+			wantOpens: []string{"", "bytes=10-", "bytes=10-", "bytes=10-"},
+		},
+		{
+			what:      "seek",
+			options:   []OpenOption{&SeekOption{Offset: 5}},
+			breaks:    []int64{10},
+			maxTries:  3,
+			want:      string(contents[5:]),
+			wantOpens: []string{"bytes=5-", "bytes=15-"},
+		},
+		{
+			what:      "range",
+			options:   []OpenOption{&RangeOption{Start: 5, End: 24}},
+			breaks:    []int64{10},
+			maxTries:  3,
+			want:      string(contents[5:25]),
+			wantOpens: []string{"bytes=5-24", "bytes=15-24"},
+		},
+		{
+			what:      "too many failures",
+			breaks:    []int64{10, 0, 0},
+			maxTries:  2,
+			wantOpens: []string{"", "bytes=10-", "bytes=10-"},
+			wantErr:   true,
+		},
+	} {
+		var opens []string
+		o := &reOpenTestObject{
+			mockObject: mockObject("potato"),
+			contents:   contents,
+			breaks:     test.breaks,
+			opens:      &opens,
+		}
+		rc, err := NewReOpen(o, test.maxTries, test.options...)
+		require.NoError(t, err, test.what)
+		got, err := ioutil.ReadAll(rc)
+		if test.wantErr {
+			require.Error(t, err, test.what)
+			assert.Contains(t, err.Error(), errorTestRead.Error(), test.what)
+		} else {
+			require.NoError(t, err, test.what)
+			assert.Equal(t, test.want, string(got), test.what)
+		}
+		assert.Equal(t, test.wantOpens, opens, test.what)
+		require.NoError(t, rc.Close(), test.what)
+		assert.Equal(t, errorFileClosed, rc.Close(), test.what)
+		_, err = rc.Read(make([]byte, 1))
+		assert.Equal(t, errorFileClosed, err, test.what)
+	}
+}
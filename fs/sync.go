@@ -4,6 +4,7 @@ package fs
 
 import (
 	"fmt"
+	"path"
 	"sort"
 	"sync"
 	"time"
@@ -37,7 +38,8 @@ type syncCopyMove struct {
 	dstEmptyDirsMu sync.Mutex          // protect dstEmptyDirs
 	dstEmptyDirs   []DirEntry          // potentially empty directories
 	srcEmptyDirsMu sync.Mutex          // protect srcEmptyDirs
-	srcEmptyDirs   []DirEntry          // potentially empty directories
+	srcEmptyDirs   []DirEntry          // potentially empty directories - all the src directories
+	dirModTimes    bool                // set if we should copy directory mod times to fdst
 	checkerWg      sync.WaitGroup      // wait for checkers
 	toBeChecked    ObjectPairChan      // checkers channel
 	transfersWg    sync.WaitGroup      // wait for transfers
@@ -56,6 +58,9 @@ type syncCopyMove struct {
 	renameCheck    []Object            // accumulate files to check for rename here
 	backupDir      Fs                  // place to store overwrites/deletes
 	suffix         string              // suffix to add to files placed in backupDir
+	freeSpace      *freeSpaceGuard     // holds up transfers if --min-free-space is set
+	dirLimits      *dirLimiter         // limits transfers per directory if --transfers-per-dir is set
+	uploads        *uploadDeduper      // uploads identical files once if --dedupe-uploads is set
 }
 
 func newSyncCopyMove(fdst, fsrc Fs, deleteMode DeleteMode, DoMove bool) (*syncCopyMove, error) {
@@ -71,13 +76,24 @@ func newSyncCopyMove(fdst, fsrc Fs, deleteMode DeleteMode, DoMove bool) (*syncCo
 		noTraverse:     Config.NoTraverse,
 		toBeChecked:    make(ObjectPairChan, Config.Transfers),
 		toBeUploaded:   make(ObjectPairChan, Config.Transfers),
+		freeSpace:      newFreeSpaceGuard(fdst),
+		dirLimits:      newDirLimiter(Config.TransfersPerDir),
+		uploads:        newUploadDeduper(fdst, fsrc, DoMove),
 		deleteFilesCh:  make(chan Object, Config.Checkers),
 		trackRenames:   Config.TrackRenames,
-		commonHash:     fsrc.Hashes().Overlap(fdst.Hashes()).GetOne(),
+		dirModTimes:    Config.PreserveDirModTimes,
+		commonHash:     commonHash(fsrc.Hashes().Overlap(fdst.Hashes())),
 		toBeRenamed:    make(ObjectPairChan, Config.Transfers),
 		trackRenamesCh: make(chan Object, Config.Checkers),
 	}
+	if err := CheckStrictHash(fdst, fsrc); err != nil {
+		return nil, FatalError(err)
+	}
 	s.ctx, s.cancel = context.WithCancel(context.Background())
+	if s.trackRenames && Config.AddOnly {
+		Errorf(nil, "Ignoring --track-renames as --add-only is set")
+		s.trackRenames = false
+	}
 	if s.noTraverse && s.deleteMode != DeleteModeOff {
 		Errorf(nil, "Ignoring --no-traverse with sync")
 		s.noTraverse = false
@@ -93,6 +109,10 @@ func newSyncCopyMove(fdst, fsrc Fs, deleteMode DeleteMode, DoMove bool) (*syncCo
 			s.trackRenames = false
 		}
 	}
+	if s.dirModTimes && fdst.Features().DirSetModTime == nil {
+		Errorf(fdst, "Ignoring --preserve-dir-modtimes as the destination does not support setting directory modification times")
+		s.dirModTimes = false
+	}
 	if s.trackRenames {
 		// track renames needs delete after
 		if s.deleteMode != DeleteModeOff {
@@ -265,7 +285,10 @@ func (s *syncCopyMove) pairChecker(in ObjectPairChan, out ObjectPairChan, wg *sy
 			Stats.Checking(src.Remote())
 			// Check to see if can store this
 			if src.Storable() {
-				if NeedTransfer(pair.dst, pair.src) {
+				release := checkerLimits.acquire(s.fsrc.Name(), s.fdst.Name())
+				needTransfer := NeedTransfer(pair.dst, pair.src)
+				release()
+				if needTransfer {
 					// If files are treated as immutable, fail if destination exists and does not match
 					if Config.Immutable && pair.dst != nil {
 						Errorf(pair.dst, "Source and destination exist but do not match: immutable file modified")
@@ -329,7 +352,6 @@ func (s *syncCopyMove) pairRenamer(in ObjectPairChan, out ObjectPairChan, wg *sy
 // pairCopyOrMove reads Objects on in and moves or copies them.
 func (s *syncCopyMove) pairCopyOrMove(in ObjectPairChan, fdst Fs, wg *sync.WaitGroup) {
 	defer wg.Done()
-	var err error
 	for {
 		if s.aborting() {
 			return
@@ -339,21 +361,91 @@ func (s *syncCopyMove) pairCopyOrMove(in ObjectPairChan, fdst Fs, wg *sync.WaitG
 			if !ok {
 				return
 			}
-			src := pair.src
-			Stats.Transferring(src.Remote())
-			if s.DoMove {
-				err = Move(fdst, pair.dst, src.Remote(), src)
-			} else {
-				err = Copy(fdst, pair.dst, src.Remote(), src)
+			// If the directory is at its --transfers-per-dir
+			// limit the pair is queued and transferred by
+			// whoever finishes a transfer there
+			dir := path.Dir(pair.src.Remote())
+			if !s.dirLimits.start(dir, pair) {
+				continue
+			}
+			for {
+				if !s.copyOrMove(pair, fdst) {
+					return
+				}
+				pair, ok = s.dirLimits.finish(dir)
+				if !ok {
+					break
+				}
 			}
-			s.processError(err)
-			Stats.DoneTransferring(src.Remote(), err == nil)
 		case <-s.ctx.Done():
 			return
 		}
 	}
 }
 
+// copyOrMove moves or copies a single pair returning false if the
+// sync has been cancelled
+func (s *syncCopyMove) copyOrMove(pair ObjectPair, fdst Fs) bool {
+	var err error
+	src := pair.src
+	// Wait for space on the destination if required
+	if !s.freeSpace.wait(s.ctx) {
+		return false
+	}
+	Stats.Transferring(src.Remote())
+	// Normalize the name if required by --unicode-normalization
+	remote := NormalizeUnicode(src.Remote())
+	release := transferLimits.acquire(s.fsrc.Name(), fdst.Name())
+	upload, first := s.uploads.claim(src)
+	if upload == nil || first || !s.copyDuplicate(upload, pair, remote, fdst) {
+		if s.DoMove {
+			err = Move(fdst, pair.dst, remote, src)
+		} else {
+			err = Copy(fdst, pair.dst, remote, src)
+		}
+		if first {
+			var dst Object
+			if err == nil && !Config.DryRun {
+				dst, _ = fdst.NewObject(remote)
+			}
+			upload.finish(dst)
+		}
+	}
+	release()
+	s.processError(err)
+	doneTransferring(src.Remote(), src.Size(), err)
+	return true
+}
+
+// copyDuplicate waits for upload to finish then server side copies
+// it to remote instead of uploading pair.src again.  It returns false
+// if this wasn't possible and pair should be transferred as normal.
+func (s *syncCopyMove) copyDuplicate(upload *dedupedUpload, pair ObjectPair, remote string, fdst Fs) bool {
+	uploaded := upload.wait(s.ctx)
+	if uploaded == nil {
+		return false
+	}
+	src := pair.src
+	dst, err := fdst.Features().Copy(uploaded, remote)
+	if err != nil {
+		Debugf(src, "Failed to copy duplicate %q so uploading: %v", uploaded.Remote(), err)
+		return false
+	}
+	Infof(src, "Copied (server side copy of duplicate %q)", uploaded.Remote())
+	// The copy has the modification time of the upload
+	if dt := dst.ModTime().Sub(src.ModTime()); dt >= Config.ModifyWindow || dt <= -Config.ModifyWindow {
+		err = dst.SetModTime(src.ModTime())
+		if err != nil && err != ErrorCantSetModTime && err != ErrorCantSetModTimeWithoutDelete {
+			Errorf(dst, "Failed to set modification time: %v", err)
+			s.processError(err)
+		}
+	}
+	if s.DoMove {
+		s.processError(DeleteFile(src))
+	}
+	return true
+}
+
 // This starts the background checkers.
 func (s *syncCopyMove) startCheckers() {
 	s.checkerWg.Add(Config.Checkers)
@@ -521,6 +613,40 @@ func deleteEmptyDirectories(f Fs, entries DirEntries) error {
 	return nil
 }
 
+// setDirModTimes sets the modification times of the directories in f
+// to those of the source directories in entries.
+//
+// Directories which don't exist in f are ignored.
+func setDirModTimes(f Fs, entries DirEntries) error {
+	var errorCount int
+	for _, entry := range entries {
+		dir, ok := entry.(Directory)
+		if !ok {
+			Errorf(f, "Not a directory: %v", entry)
+			continue
+		}
+		if Config.DryRun {
+			Logf(logDirName(f, dir.Remote()), "Not setting directory modification time as --dry-run")
+			continue
+		}
+		err := f.Features().DirSetModTime(dir.Remote(), dir.ModTime())
+		switch err {
+		case nil:
+			Debugf(logDirName(f, dir.Remote()), "Set directory modification time to %v", dir.ModTime())
+		case ErrorDirNotFound:
+			Debugf(logDirName(f, dir.Remote()), "Not setting directory modification time as directory not found")
+		default:
+			Stats.Error()
+			Errorf(logDirName(f, dir.Remote()), "Failed to set directory modification time: %v", err)
+			errorCount++
+		}
+	}
+	if errorCount > 0 {
+		return errors.Errorf("failed to set modification time on %d directories", errorCount)
+	}
+	return nil
+}
+
 // renameHash makes a string with the size and the hash for rename detection
 //
 // it may return an empty string in which case no hash could be made
@@ -697,6 +823,12 @@ func (s *syncCopyMove) run() error {
 		}
 	}
 
+	// Set the directory modification times last as putting files
+	// into the directories or removing them will change them
+	if s.dirModTimes && s.deleteMode != DeleteModeOnly {
+		s.processError(setDirModTimes(s.fdst, s.srcEmptyDirs))
+	}
+
 	// if DoMove, delete fsrc directory after
 	if s.DoMove {
 		//first delete any subdirectories in fsrc
@@ -782,7 +914,9 @@ func (s *syncCopyMove) Match(dst, src DirEntry) (recurse bool) {
 			return false
 		}
 		dstX, ok := dst.(Object)
-		if ok {
+		if ok && Config.AddOnly {
+			Debugf(srcX, "Destination exists, skipping as --add-only is set")
+		} else if ok {
 			s.toBeChecked <- ObjectPair{srcX, dstX}
 		} else {
 			// FIXME src is file, dst is directory
@@ -824,6 +958,10 @@ func runSyncCopyMove(fdst, fsrc Fs, deleteMode DeleteMode, DoMove bool) error {
 	if deleteMode != DeleteModeOff && DoMove {
 		return FatalError(errors.New("can't delete and move at the same time"))
 	}
+	if deleteMode != DeleteModeOff && Config.AddOnly {
+		Errorf(fdst, "Not deleting files as --add-only is set")
+		deleteMode = DeleteModeOff
+	}
 	// Run an extra pass to delete only
 	if deleteMode == DeleteModeBefore {
 		if Config.TrackRenames {
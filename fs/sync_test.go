@@ -3,7 +3,11 @@
 package fs_test
 
 import (
+	"fmt"
+	"io"
+	"path"
 	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -797,6 +801,77 @@ func TestSyncWithTrackRenames(t *testing.T) {
 	}
 }
 
+// concurrencyFs is an Fs which records the maximum number of
+// concurrent uploads in each directory and overall
+type concurrencyFs struct {
+	fs.Fs
+	mu         sync.Mutex
+	running    map[string]int
+	maxRunning map[string]int
+	total      int
+	maxTotal   int
+}
+
+// Put records the concurrency then slows the upload down
+func (f *concurrencyFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	dir := path.Dir(src.Remote())
+	f.mu.Lock()
+	f.running[dir]++
+	if f.running[dir] > f.maxRunning[dir] {
+		f.maxRunning[dir] = f.running[dir]
+	}
+	f.total++
+	if f.total > f.maxTotal {
+		f.maxTotal = f.total
+	}
+	f.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	defer func() {
+		f.mu.Lock()
+		f.running[dir]--
+		f.total--
+		f.mu.Unlock()
+	}()
+	return f.Fs.Put(in, src, options...)
+}
+
+// Test copying with --transfers-per-dir
+func TestCopyTransfersPerDir(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.Mkdir(r.Fremote)
+
+	oldTransfers, oldTransfersPerDir := fs.Config.Transfers, fs.Config.TransfersPerDir
+	fs.Config.Transfers, fs.Config.TransfersPerDir = 8, 2
+	defer func() {
+		fs.Config.Transfers, fs.Config.TransfersPerDir = oldTransfers, oldTransfersPerDir
+	}()
+
+	var items []fstest.Item
+	for i := 0; i < 20; i++ {
+		items = append(items, r.WriteFile(fmt.Sprintf("big/file%d", i), "big", t1))
+	}
+	for _, dir := range []string{"a", "b", "c", "d"} {
+		for i := 0; i < 2; i++ {
+			items = append(items, r.WriteFile(fmt.Sprintf("%s/file%d", dir, i), dir, t2))
+		}
+	}
+
+	fdst := &concurrencyFs{
+		Fs:         r.Fremote,
+		running:    make(map[string]int),
+		maxRunning: make(map[string]int),
+	}
+	err := fs.CopyDir(fdst, r.Flocal)
+	require.NoError(t, err)
+
+	fstest.CheckItems(t, r.Fremote, items...)
+	for dir, maxRunning := range fdst.maxRunning {
+		assert.True(t, maxRunning <= 2, "%q had %d concurrent transfers", dir, maxRunning)
+	}
+	assert.True(t, fdst.maxTotal > 2, "only %d concurrent transfers overall", fdst.maxTotal)
+}
+
 // Test a server side move if possible, or the backup path if not
 func testServerSideMove(t *testing.T, r *fstest.Run, withFilter bool) {
 	FremoteMove, _, finaliseMove, err := fstest.RandomRemote(*fstest.RemoteName, *fstest.SubDir)
@@ -854,6 +929,57 @@ func TestServerSideMove(t *testing.T) {
 	testServerSideMove(t, r, false)
 }
 
+// Test moving a tree with --preserve-dir-modtimes
+func TestMoveDirPreserveDirModTimes(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if r.Fremote.Features().DirSetModTime == nil {
+		t.Skip("Can't set directory modification times on remote")
+	}
+	fs.Config.PreserveDirModTimes = true
+	defer func() { fs.Config.PreserveDirModTimes = false }()
+
+	file1 := r.WriteFile("a/one", "one", t1)
+	file2 := r.WriteFile("a/b/two", "two", t2)
+	file3 := r.WriteFile("c/three", "three", t3)
+	dirModTimes := map[string]time.Time{
+		"a":   t2,
+		"a/b": t1,
+		"c":   t3,
+	}
+	for dir, modTime := range dirModTimes {
+		require.NoError(t, r.Flocal.Features().DirSetModTime(dir, modTime))
+	}
+	r.Mkdir(r.Fremote)
+
+	err := fs.MoveDir(r.Fremote, r.Flocal)
+	require.NoError(t, err)
+
+	fstest.CheckItems(t, r.Flocal)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3)
+
+	precision := fs.Config.ModifyWindow
+	if remotePrecision := r.Fremote.Precision(); remotePrecision > precision {
+		precision = remotePrecision
+	}
+	for _, dir := range []string{"", "a"} {
+		entries, err := fs.ListDirSorted(r.Fremote, false, dir)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			d, ok := entry.(fs.Directory)
+			if !ok {
+				continue
+			}
+			want, found := dirModTimes[d.Remote()]
+			require.True(t, found, d.Remote())
+			dt, ok := fstest.CheckTimeEqualWithPrecision(want, d.ModTime(), precision)
+			assert.True(t, ok, "%s: modification time differs by %v", d.Remote(), dt)
+			delete(dirModTimes, d.Remote())
+		}
+	}
+	assert.Len(t, dirModTimes, 0, "directories not found")
+}
+
 // Test a server side move if possible, or the backup path if not
 func TestServerSideMoveWithFilter(t *testing.T) {
 	r := fstest.NewRun(t)
@@ -997,6 +1123,88 @@ func TestSyncUTFNorm(t *testing.T) {
 	fstest.CheckItems(t, r.Fremote, file1)
 }
 
+// Check --unicode-normalization pairs up and rewrites names
+func TestSyncUnicodeNormalization(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("Can't test UTF normalization on OS X")
+	}
+
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	fs.Config.UnicodeNormalization = "nfc"
+	defer func() { fs.Config.UnicodeNormalization = "" }()
+
+	NFD := "Teste\u0302e\u0301"
+	NFC := "Test\u00ea\u00e9"
+
+	// Same file with an NFD name locally and an NFC name remotely
+	file1 := r.WriteFile(NFD, "This is a test", t1)
+	file2 := r.WriteObject(NFC, "This is a test", t1)
+	fstest.CheckItems(t, r.Fremote, file2)
+
+	// A new file with an NFD name
+	file3 := r.WriteFile(NFD+"2", "This is another test", t1)
+
+	fs.Stats.ResetCounters()
+	err := fs.Sync(r.Fremote, r.Flocal)
+	require.NoError(t, err)
+
+	// Only the new file should be transferred and nothing deleted
+	assert.Equal(t, int64(1), fs.Stats.GetTransfers())
+
+	// Check the names on the remote are NFC
+	fs.Config.UnicodeNormalization = ""
+	fstest.CheckItems(t, r.Flocal, file1, file3)
+	file3.Path = NFC + "2"
+	fstest.CheckItems(t, r.Fremote, file2, file3)
+}
+
+// hashesFs wraps an Fs to advertise a different set of hashes
+type hashesFs struct {
+	fs.Fs
+	hashes fs.HashSet
+}
+
+// Hashes returns the supported hash sets.
+func (f *hashesFs) Hashes() fs.HashSet { return f.hashes }
+
+// Test --strict-hash
+func TestSyncStrictHash(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	defer func() { fs.Config.StrictHash = fs.HashNone }()
+
+	file1 := r.WriteFile("sub dir/hello world", "hello world", t1)
+	r.Mkdir(r.Fremote)
+
+	fsrc := &hashesFs{Fs: r.Flocal, hashes: fs.NewHashSet(fs.HashMD5, fs.HashSHA1)}
+	noHash := &hashesFs{Fs: r.Fremote, hashes: fs.NewHashSet()}
+	weakHash := &hashesFs{Fs: r.Fremote, hashes: fs.NewHashSet(fs.HashMD5)}
+
+	// Without --strict-hash anything goes
+	require.NoError(t, fs.CopyDir(noHash, fsrc))
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	// With no common hash --strict-hash any should fail
+	fs.Config.StrictHash = fs.HashMD5
+	err := fs.Sync(noHash, fsrc)
+	require.Error(t, err)
+	assert.True(t, fs.IsFatalError(err))
+	assert.Contains(t, err.Error(), "--strict-hash")
+
+	// A weak common hash should satisfy --strict-hash any
+	require.NoError(t, fs.Sync(weakHash, fsrc))
+
+	// But not --strict-hash sha1
+	fs.Config.StrictHash = fs.HashSHA1
+	err = fs.Sync(weakHash, fsrc)
+	require.Error(t, err)
+	assert.True(t, fs.IsFatalError(err))
+
+	fstest.CheckItems(t, r.Fremote, file1)
+}
+
 // Test --immutable
 func TestSyncImmutable(t *testing.T) {
 	r := fstest.NewRun(t)
@@ -1029,3 +1237,125 @@ func TestSyncImmutable(t *testing.T) {
 	fstest.CheckItems(t, r.Flocal, file2)
 	fstest.CheckItems(t, r.Fremote, file1)
 }
+
+// copyCountFs is an Fs which supports server side copy and counts
+// the uploads and copies
+type copyCountFs struct {
+	fs.Fs
+	mu     sync.Mutex
+	puts   []string
+	copies []string
+}
+
+// Name is different from the wrapped Fs so copies into it aren't
+// server side
+func (f *copyCountFs) Name() string {
+	return "copycount"
+}
+
+// Features returns the features of the wrapped Fs with Copy
+func (f *copyCountFs) Features() *fs.Features {
+	features := *f.Fs.Features()
+	features.Copy = f.Copy
+	return &features
+}
+
+// Put records the upload
+func (f *copyCountFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	f.mu.Lock()
+	f.puts = append(f.puts, src.Remote())
+	f.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	return f.Fs.Put(in, src, options...)
+}
+
+// Copy records the copy then does it with the wrapped Fs
+func (f *copyCountFs) Copy(src fs.Object, remote string) (dst fs.Object, err error) {
+	f.mu.Lock()
+	f.copies = append(f.copies, remote)
+	f.mu.Unlock()
+	// Read from a new object as copies may run concurrently
+	src, err = f.Fs.NewObject(src.Remote())
+	if err != nil {
+		return nil, err
+	}
+	in, err := src.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.CheckClose(in, &err)
+	info := fs.NewStaticObjectInfo(remote, src.ModTime(), src.Size(), true, nil, f)
+	return f.Fs.Put(in, info)
+}
+
+// Test copying with --dedupe-uploads
+func TestCopyDedupeUploads(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.Mkdir(r.Fremote)
+
+	fs.Config.DedupeUploads = true
+	defer func() { fs.Config.DedupeUploads = false }()
+
+	file1 := r.WriteFile("a/one", "duplicated content", t1)
+	file2 := r.WriteFile("b/two", "duplicated content", t2)
+	file3 := r.WriteFile("three", "duplicated content", t3)
+	file4 := r.WriteFile("four", "unique content", t1)
+
+	fdst := &copyCountFs{Fs: r.Fremote}
+	err := fs.CopyDir(fdst, r.Flocal)
+	require.NoError(t, err)
+
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3, file4)
+	assert.Equal(t, 2, len(fdst.puts), "puts %v", fdst.puts)
+	assert.Equal(t, 2, len(fdst.copies), "copies %v", fdst.copies)
+	assert.Contains(t, fdst.puts, "four")
+
+	// Without the flag each file is uploaded
+	fs.Config.DedupeUploads = false
+	require.NoError(t, fs.Purge(r.Fremote))
+	r.Mkdir(r.Fremote)
+	fdst = &copyCountFs{Fs: r.Fremote}
+	err = fs.CopyDir(fdst, r.Flocal)
+	require.NoError(t, err)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3, file4)
+	assert.Equal(t, 4, len(fdst.puts), "puts %v", fdst.puts)
+	assert.Equal(t, 0, len(fdst.copies), "copies %v", fdst.copies)
+}
+
+// Test syncing with --add-only
+func TestSyncAddOnly(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("existing", "potato", t1)
+	file2 := r.WriteObject("existing", "different potato", t2)
+	file3 := r.WriteFile("sub dir/new", "new potato", t3)
+	file4 := r.WriteObject("remote only", "old potato", t1)
+
+	fs.Config.AddOnly = true
+	defer func() { fs.Config.AddOnly = false }()
+
+	fs.Stats.ResetCounters()
+	err := fs.Sync(r.Fremote, r.Flocal)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), fs.Stats.GetTransfers())
+	assert.Equal(t, int64(0), fs.Stats.GetErrors())
+
+	// Only the new file is copied - the existing one isn't updated
+	// and nothing is deleted
+	fstest.CheckItems(t, r.Flocal, file1, file3)
+	fstest.CheckItems(t, r.Fremote, file2, file3, file4)
+
+	// Moving only moves the new file leaving the existing ones
+	file5 := r.WriteFile("another new", "another potato", t2)
+	fs.Stats.ResetCounters()
+	err = fs.MoveDir(r.Fremote, r.Flocal)
+	require.NoError(t, err)
+	fstest.CheckItems(t, r.Flocal, file1, file3)
+	fstest.CheckItems(t, r.Fremote, file2, file3, file4, file5)
+
+	// Single files are skipped too
+	err = fs.CopyFile(r.Fremote, r.Flocal, "existing", "existing")
+	require.NoError(t, err)
+	fstest.CheckItems(t, r.Fremote, file2, file3, file4, file5)
+}
@@ -0,0 +1,159 @@
+// Repair modification time drift between two remotes
+
+package fs
+
+import (
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// modTimeMarch is used to march over two Fses setting the
+// modification times of identical objects in the destination to those
+// in the source
+type modTimeMarch struct {
+	fdst, fsrc Fs
+	corrected  int64
+	skipped    int64
+	noHashes   int64
+	errors     int64
+}
+
+// DstOnly have an object which is in the destination only
+func (c *modTimeMarch) DstOnly(dst DirEntry) (recurse bool) {
+	switch dst.(type) {
+	case Object:
+		Debugf(dst, "Not in %v - ignoring", c.fsrc)
+	case Directory:
+		// Do the same thing to the entire contents of the directory
+		return true
+	default:
+		panic("Bad object in DirEntries")
+	}
+	return false
+}
+
+// SrcOnly have an object which is in the source only
+func (c *modTimeMarch) SrcOnly(src DirEntry) (recurse bool) {
+	switch src.(type) {
+	case Object:
+		Debugf(src, "Not in %v - ignoring", c.fdst)
+	case Directory:
+		// Do the same thing to the entire contents of the directory
+		return true
+	default:
+		panic("Bad object in DirEntries")
+	}
+	return false
+}
+
+// syncModTime sets the modification time of dst to that of src if
+// they have the same contents
+func (c *modTimeMarch) syncModTime(dst, src Object) {
+	Stats.Checking(src.Remote())
+	defer Stats.DoneChecking(src.Remote())
+	srcModTime := src.ModTime()
+	dt := dst.ModTime().Sub(srcModTime)
+	if dt < Config.ModifyWindow && dt > -Config.ModifyWindow {
+		Debugf(src, "Modification times the same (differ by %s, within tolerance %s)", dt, Config.ModifyWindow)
+		return
+	}
+	if src.Size() != dst.Size() {
+		Infof(src, "Sizes differ - not setting modification time")
+		atomic.AddInt64(&c.skipped, 1)
+		return
+	}
+	same, hash, err := CheckHashes(src, dst)
+	if err != nil {
+		// error already logged and counted
+		atomic.AddInt64(&c.errors, 1)
+		return
+	}
+	if !same {
+		Infof(src, "%v differ - not setting modification time", hash)
+		atomic.AddInt64(&c.skipped, 1)
+		return
+	}
+	if hash == HashNone && !Config.SizeOnly {
+		Infof(src, "No common hash to check - not setting modification time (use --size-only to set it anyway)")
+		atomic.AddInt64(&c.noHashes, 1)
+		return
+	}
+	if Config.DryRun {
+		Logf(dst, "Not setting modification time as --dry-run (differs by %s)", dt)
+		atomic.AddInt64(&c.corrected, 1)
+		return
+	}
+	err = dst.SetModTime(srcModTime)
+	switch err {
+	case nil:
+		Infof(dst, "Corrected modification time (differed by %s)", dt)
+		atomic.AddInt64(&c.corrected, 1)
+		return
+	case ErrorCantSetModTime, ErrorCantSetModTimeWithoutDelete:
+		Errorf(dst, "Can't set modification time without re-uploading")
+	default:
+		Errorf(dst, "Failed to set modification time: %v", err)
+	}
+	Stats.Error()
+	atomic.AddInt64(&c.errors, 1)
+}
+
+// Match is called when src and dst are present
+func (c *modTimeMarch) Match(dst, src DirEntry) (recurse bool) {
+	switch srcX := src.(type) {
+	case Object:
+		dstX, ok := dst.(Object)
+		if ok {
+			c.syncModTime(dstX, srcX)
+		} else {
+			Debugf(src, "is file on %v but directory on %v - ignoring", c.fsrc, c.fdst)
+		}
+	case Directory:
+		// Do the same thing to the entire contents of the directory
+		_, ok := dst.(Directory)
+		if ok {
+			return true
+		}
+		Debugf(dst, "is file on %v but directory on %v - ignoring", c.fdst, c.fsrc)
+	default:
+		panic("Bad object in DirEntries")
+	}
+	return false
+}
+
+// SyncModTimes sets the modification times of the objects in fdst to
+// those of the objects in fsrc where they have the same size and hash,
+// without transferring any data.
+//
+// With --size-only objects which can't be compared by hash are
+// corrected if their sizes match.
+//
+// It returns the number of modification times corrected.
+func SyncModTimes(fdst, fsrc Fs) (corrected int64, err error) {
+	if Config.ModifyWindow == ModTimeNotSupported {
+		return 0, errors.New("modification times not supported")
+	}
+	c := &modTimeMarch{
+		fdst: fdst,
+		fsrc: fsrc,
+	}
+
+	// set up a march over fdst and fsrc
+	m := newMarch(context.Background(), fdst, fsrc, "", c)
+	Infof(fdst, "Waiting for checks to finish")
+	m.run()
+
+	Logf(fdst, "%d modification times corrected", c.corrected)
+	if c.skipped > 0 {
+		Logf(fdst, "%d files skipped as their contents differ", c.skipped)
+	}
+	if c.noHashes > 0 {
+		Logf(fdst, "%d files skipped as their hashes could not be checked", c.noHashes)
+	}
+	if c.errors > 0 {
+		return c.corrected, errors.Errorf("failed to correct %d modification times", c.errors)
+	}
+	return c.corrected, nil
+}
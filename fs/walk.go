@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/net/context"
 )
 
 // ErrorSkipDir is used as a return value from Walk to indicate that the
@@ -23,6 +24,10 @@ var ErrorSkipDir = errors.New("skip this directory")
 // capable of doing a recursive listing.
 var ErrorCantListR = errors.New("recursive directory listing not available")
 
+// errFastListLimit is returned by walkRDirTree if the recursive
+// listing returned more than --fast-list-max-entries entries
+var errFastListLimit = errors.New("too many entries for --fast-list")
+
 // WalkFunc is the type of the function called for directory
 // visited by Walk. The path argument contains remote path to the directory.
 //
@@ -52,6 +57,10 @@ type WalkFunc func(path string, entries DirEntries, err error) error
 // This is implemented by WalkR if Config.UseRecursiveListing is true
 // and f supports it and level > 1, or WalkN otherwise.
 //
+// If the recursive listing returns more than
+// Config.FastListMaxEntries entries it is abandoned and WalkN is
+// used instead.
+//
 // NB (f, path) to be replaced by fs.Dir at some point
 func Walk(f Fs, path string, includeAll bool, maxLevel int, fn WalkFunc) error {
 	if (maxLevel < 0 || maxLevel > 1) && Config.UseListR && f.Features().ListR != nil {
@@ -76,12 +85,18 @@ func WalkR(f Fs, path string, includeAll bool, maxLevel int, fn WalkFunc) error
 	if listR == nil {
 		return ErrorCantListR
 	}
-	return walkR(f, path, includeAll, maxLevel, fn, listR)
+	return walkR(f, path, includeAll, maxLevel, fn, listR, ListDirSorted)
 }
 
 type listDirFunc func(fs Fs, includeAll bool, dir string) (entries DirEntries, err error)
 
 func walk(f Fs, path string, includeAll bool, maxLevel int, fn WalkFunc, listDir listDirFunc) error {
+	return walkParallel(context.Background(), f, path, includeAll, maxLevel, Config.Checkers, fn, listDir)
+}
+
+// walkParallel implements walk listing up to checkers directories at
+// once.  If ctx is cancelled the walk stops and returns ctx.Err().
+func walkParallel(ctx context.Context, f Fs, path string, includeAll bool, maxLevel int, checkers int, fn WalkFunc, listDir listDirFunc) error {
 	var (
 		wg         sync.WaitGroup // sync closing of go routines
 		traversing sync.WaitGroup // running directory traversals
@@ -94,9 +109,11 @@ func walk(f Fs, path string, includeAll bool, maxLevel int, fn WalkFunc, listDir
 		depth  int
 	}
 
-	in := make(chan listJob, Config.Checkers)
+	in := make(chan listJob, checkers)
 	errs := make(chan error, 1)
 	quit := make(chan struct{})
+	finished := make(chan struct{})
+	watcherDone := make(chan struct{})
 	closeQuit := func() {
 		doClose.Do(func() {
 			close(quit)
@@ -107,7 +124,20 @@ func walk(f Fs, path string, includeAll bool, maxLevel int, fn WalkFunc, listDir
 			}()
 		})
 	}
-	for i := 0; i < Config.Checkers; i++ {
+	// Stop if the context is cancelled
+	go func() {
+		defer close(watcherDone)
+		select {
+		case <-ctx.Done():
+			closeQuit()
+			select {
+			case errs <- ctx.Err():
+			default:
+			}
+		case <-finished:
+		}
+	}()
+	for i := 0; i < checkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -170,6 +200,8 @@ func walk(f Fs, path string, includeAll bool, maxLevel int, fn WalkFunc, listDir
 	traversing.Wait()
 	close(in)
 	wg.Wait()
+	close(finished)
+	<-watcherDone
 	close(errs)
 	// return the first error returned or nil
 	return <-errs
@@ -266,12 +298,22 @@ func (dt DirTree) String() string {
 	return out.String()
 }
 
+// Create a DirTree using ListR
+//
+// If more than Config.FastListMaxEntries entries are returned it
+// abandons the listing and returns errFastListLimit so the caller can
+// list the directories individually instead.
 func walkRDirTree(f Fs, path string, includeAll bool, maxLevel int, listR ListRFn) (DirTree, error) {
 	dirs := make(DirTree)
 	var mu sync.Mutex
+	count := 0
 	err := listR(path, func(entries DirEntries) error {
 		mu.Lock()
 		defer mu.Unlock()
+		count += len(entries)
+		if Config.FastListMaxEntries > 0 && count > Config.FastListMaxEntries {
+			return errFastListLimit
+		}
 		for _, entry := range entries {
 			slashes := strings.Count(entry.Remote(), "/")
 			switch x := entry.(type) {
@@ -308,6 +350,10 @@ func walkRDirTree(f Fs, path string, includeAll bool, maxLevel int, listR ListRF
 		}
 		return nil
 	})
+	if errors.Cause(err) == errFastListLimit {
+		Logf(f, "More than %d entries found with --fast-list so listing directories individually instead", Config.FastListMaxEntries)
+		return nil, errFastListLimit
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -348,14 +394,29 @@ func walkNDirTree(f Fs, path string, includeAll bool, maxLevel int, listDir list
 //
 // NB (f, path) to be replaced by fs.Dir at some point
 func NewDirTree(f Fs, path string, includeAll bool, maxLevel int) (DirTree, error) {
+	dirs, err := newDirTree(f, path, includeAll, maxLevel)
+	if err == errFastListLimit {
+		return walkNDirTree(f, path, includeAll, maxLevel, ListDirSorted)
+	}
+	return dirs, err
+}
+
+// newDirTree is NewDirTree except it returns errFastListLimit rather
+// than falling back if there are too many entries for --fast-list
+func newDirTree(f Fs, path string, includeAll bool, maxLevel int) (DirTree, error) {
 	if ListR := f.Features().ListR; (maxLevel < 0 || maxLevel > 1) && Config.UseListR && ListR != nil {
 		return walkRDirTree(f, path, includeAll, maxLevel, ListR)
 	}
 	return walkNDirTree(f, path, includeAll, maxLevel, ListDirSorted)
 }
 
-func walkR(f Fs, path string, includeAll bool, maxLevel int, fn WalkFunc, listR ListRFn) error {
+// walkR implements Walk using listR, falling back to listDir if there
+// are too many entries for --fast-list
+func walkR(f Fs, path string, includeAll bool, maxLevel int, fn WalkFunc, listR ListRFn, listDir listDirFunc) error {
 	dirs, err := walkRDirTree(f, path, includeAll, maxLevel, listR)
+	if err == errFastListLimit {
+		return walk(f, path, includeAll, maxLevel, fn, listDir)
+	}
 	if err != nil {
 		return err
 	}
@@ -388,25 +449,94 @@ func walkR(f Fs, path string, includeAll bool, maxLevel int, fn WalkFunc, listR
 	return nil
 }
 
-// WalkGetAll runs Walk getting all the results
-func WalkGetAll(f Fs, path string, includeAll bool, maxLevel int) (objs []Object, dirs []Directory, err error) {
-	err = Walk(f, path, includeAll, maxLevel, func(dirPath string, entries DirEntries, err error) error {
+// WalkCallbackFunc is called by WalkCallback for each entry found.
+// Exactly one of obj and dir will be set.
+type WalkCallbackFunc func(obj Object, dir Directory) error
+
+// WalkCallback runs Walk calling fn for each object and directory as
+// they are found rather than collecting them all first, so it uses
+// bounded memory on large directory trees.
+//
+// If fn returns an error the walk stops and the error is returned.
+func WalkCallback(f Fs, path string, includeAll bool, maxLevel int, fn WalkCallbackFunc) error {
+	return Walk(f, path, includeAll, maxLevel, walkCallbackFn(fn))
+}
+
+// walkCallbackFn makes a WalkFunc which calls fn for each entry
+func walkCallbackFn(fn WalkCallbackFunc) WalkFunc {
+	return func(dirPath string, entries DirEntries, err error) error {
 		if err != nil {
 			return err
 		}
 		for _, entry := range entries {
 			switch x := entry.(type) {
 			case Object:
-				objs = append(objs, x)
+				err = fn(x, nil)
 			case Directory:
-				dirs = append(dirs, x)
+				err = fn(nil, x)
 			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WalkGetAll runs Walk getting all the results
+func WalkGetAll(f Fs, path string, includeAll bool, maxLevel int) (objs []Object, dirs []Directory, err error) {
+	err = WalkCallback(f, path, includeAll, maxLevel, func(obj Object, dir Directory) error {
+		if obj != nil {
+			objs = append(objs, obj)
+		} else {
+			dirs = append(dirs, dir)
 		}
 		return nil
 	})
 	return
 }
 
+// WalkGetAllParallel is like WalkGetAll but lists up to checkers
+// directories at once, or --checkers if checkers is 0.  This is much
+// quicker on wide directory trees on remotes with slow listings.
+//
+// The objects and directories are returned sorted by path so the
+// results don't depend on the order the listings finished in.
+//
+// If ctx is cancelled or any listing fails the walk is stopped and
+// the error returned.
+func WalkGetAllParallel(ctx context.Context, f Fs, path string, includeAll bool, maxLevel int, checkers int) (objs []Object, dirs []Directory, err error) {
+	return walkGetAllParallel(ctx, f, path, includeAll, maxLevel, checkers, ListDirSorted)
+}
+
+// walkGetAllParallel implements WalkGetAllParallel using listDir
+func walkGetAllParallel(ctx context.Context, f Fs, path string, includeAll bool, maxLevel int, checkers int, listDir listDirFunc) (objs []Object, dirs []Directory, err error) {
+	if checkers <= 0 {
+		checkers = Config.Checkers
+	}
+	var entries DirEntries
+	err = walkParallel(ctx, f, path, includeAll, maxLevel, checkers, func(dirPath string, dirEntries DirEntries, err error) error {
+		if err != nil {
+			return err
+		}
+		entries = append(entries, dirEntries...)
+		return nil
+	}, listDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(entries)
+	for _, entry := range entries {
+		switch x := entry.(type) {
+		case Object:
+			objs = append(objs, x)
+		case Directory:
+			dirs = append(dirs, x)
+		}
+	}
+	return objs, dirs, nil
+}
+
 // ListRHelper is used in the implementation of ListR to accumulate DirEntries
 type ListRHelper struct {
 	callback ListRCallback
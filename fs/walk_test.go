@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
 )
 
 type (
@@ -177,7 +178,7 @@ func (ls *listDirs) Walk() {
 
 // WalkR does the walkR and tests the expectations
 func (ls *listDirs) WalkR() {
-	err := walkR(nil, "", ls.includeAll, ls.maxLevel, ls.WalkFn, ls.ListR)
+	err := walkR(nil, "", ls.includeAll, ls.maxLevel, ls.WalkFn, ls.ListR, ls.ListDir)
 	assert.Equal(ls.t, ls.finalError, err)
 	if ls.finalError == nil {
 		ls.IsFinished()
@@ -588,3 +589,213 @@ a/
 		assert.Equal(t, test.want, r.String(), fmt.Sprintf("%+v", test))
 	}
 }
+
+func TestWalkRFastListLimit(t *testing.T) {
+	oldLimit := Config.FastListMaxEntries
+	Config.FastListMaxEntries = 100
+	defer func() { Config.FastListMaxEntries = oldLimit }()
+
+	// A listing with 1110 entries
+	lr, _ := makeTree(3, false)
+	var (
+		mu        sync.Mutex
+		callbacks int
+		listed    = map[string]bool{}
+	)
+	listR := func(dir string, callback ListRCallback) error {
+		for _, result := range lr {
+			mu.Lock()
+			callbacks++
+			mu.Unlock()
+			if err := callback(result.entries); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	listDir := func(f Fs, includeAll bool, dir string) (DirEntries, error) {
+		mu.Lock()
+		listed[dir] = true
+		mu.Unlock()
+		return lr[dir].entries, lr[dir].err
+	}
+
+	// The recursive listing should be abandoned
+	_, err := walkRDirTree(nil, "", true, -1, listR)
+	assert.Equal(t, errFastListLimit, err)
+	assert.True(t, callbacks < len(lr), "ListR wasn't abandoned")
+
+	// The walk should list every directory individually instead
+	callbacks = 0
+	got := map[string]DirEntries{}
+	err = walkR(nil, "", true, -1, func(dir string, entries DirEntries, err error) error {
+		got[dir] = entries
+		return err
+	}, listR, listDir)
+	require.NoError(t, err)
+	assert.True(t, callbacks < len(lr), "ListR wasn't abandoned")
+	assert.Equal(t, len(lr), len(listed))
+	require.Equal(t, len(lr), len(got))
+	for dir, result := range lr {
+		assert.Equal(t, result.entries, got[dir], dir)
+	}
+
+	// Under the limit ListR is used
+	Config.FastListMaxEntries = len(lr)
+	callbacks = 0
+	listed = map[string]bool{}
+	dirs, err := walkRDirTree(nil, "", true, -1, listR)
+	require.NoError(t, err)
+	assert.Equal(t, len(lr), callbacks)
+	assert.Equal(t, len(lr), len(dirs))
+	assert.Equal(t, 0, len(listed))
+}
+
+// wideTree is a listDirFunc for a synthetic directory tree with width
+// directories in the root each containing width files, where each
+// listing takes latency
+type wideTree struct {
+	width   int
+	latency time.Duration
+	fail    string // directory whose listing fails
+	cancel  func() // called when a subdirectory is listed if set
+}
+
+func (wt *wideTree) ListDir(f Fs, includeAll bool, dir string) (entries DirEntries, err error) {
+	time.Sleep(wt.latency)
+	if wt.fail != "" && dir == wt.fail {
+		return nil, errors.New("list failed")
+	}
+	if dir == "" {
+		for i := 0; i < wt.width; i++ {
+			entries = append(entries, newDir(fmt.Sprintf("dir%03d", i)))
+		}
+		entries = append(entries, mockObject("file"))
+		return entries, nil
+	}
+	if wt.cancel != nil {
+		wt.cancel()
+	}
+	for i := 0; i < wt.width; i++ {
+		entries = append(entries, mockObject(fmt.Sprintf("%s/file%03d", dir, i)))
+	}
+	return entries, nil
+}
+
+func TestWalkGetAllParallel(t *testing.T) {
+	wt := &wideTree{width: 20}
+	var firstObjs []Object
+	var firstDirs []Directory
+	for _, checkers := range []int{1, 4, 16} {
+		objs, dirs, err := walkGetAllParallel(context.Background(), nil, "", true, -1, checkers, wt.ListDir)
+		require.NoError(t, err)
+		require.Equal(t, 20*20+1, len(objs))
+		require.Equal(t, 20, len(dirs))
+		assert.Equal(t, "dir000/file000", objs[0].Remote())
+		assert.Equal(t, "file", objs[len(objs)-1].Remote())
+		assert.Equal(t, "dir019", dirs[19].Remote())
+		if firstObjs == nil {
+			firstObjs, firstDirs = objs, dirs
+		} else {
+			assert.Equal(t, firstObjs, objs, "checkers=%d", checkers)
+			assert.Equal(t, firstDirs, dirs, "checkers=%d", checkers)
+		}
+	}
+
+	// Check maxLevel is obeyed
+	objs, dirs, err := walkGetAllParallel(context.Background(), nil, "", true, 1, 4, wt.ListDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(objs))
+	assert.Equal(t, 20, len(dirs))
+}
+
+func TestWalkGetAllParallelError(t *testing.T) {
+	wt := &wideTree{width: 20, fail: "dir007"}
+	objs, dirs, err := walkGetAllParallel(context.Background(), nil, "", true, -1, 4, wt.ListDir)
+	require.Error(t, err)
+	assert.Equal(t, "list failed", err.Error())
+	assert.Nil(t, objs)
+	assert.Nil(t, dirs)
+}
+
+func TestWalkGetAllParallelCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wt := &wideTree{width: 100, latency: time.Millisecond, cancel: cancel}
+	_, _, err := walkGetAllParallel(ctx, nil, "", true, -1, 4, wt.ListDir)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func BenchmarkWalkGetAllParallel(b *testing.B) {
+	wt := &wideTree{width: 50, latency: time.Millisecond}
+	for _, checkers := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("checkers=%d", checkers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _, err := walkGetAllParallel(context.Background(), nil, "", true, -1, checkers, wt.ListDir)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestWalkCallback(t *testing.T) {
+	a := mockObject("a")
+	b := mockObject("b")
+	c := mockObject("dir/c")
+	dir := newDir("dir")
+	ls := newListDirs(t, nil, false,
+		listResults{
+			"":    {entries: DirEntries{a, b, dir}, err: nil},
+			"dir": {entries: DirEntries{c}, err: nil},
+		},
+		errorMap{},
+		nil,
+	)
+	var objs []Object
+	var dirs []Directory
+	err := walk(nil, "", false, -1, walkCallbackFn(func(obj Object, dir Directory) error {
+		if obj != nil {
+			assert.Nil(t, dir)
+			objs = append(objs, obj)
+		} else {
+			dirs = append(dirs, dir)
+		}
+		return nil
+	}), ls.ListDir)
+	require.NoError(t, err)
+	assert.Equal(t, []Object{a, b, c}, objs)
+	assert.Equal(t, []Directory{dir}, dirs)
+
+	// Check returning an error stops the walk
+	ls = newListDirs(t, nil, false,
+		listResults{
+			"": {entries: DirEntries{a, b}, err: nil},
+		},
+		errorMap{},
+		nil,
+	)
+	stop := errors.New("stop")
+	calls := 0
+	err = walk(nil, "", false, -1, walkCallbackFn(func(obj Object, dir Directory) error {
+		calls++
+		return stop
+	}), ls.ListDir)
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	// Check listing errors are returned
+	listErr := errors.New("list failed")
+	ls = newListDirs(t, nil, false,
+		listResults{
+			"": {entries: nil, err: listErr},
+		},
+		errorMap{},
+		nil,
+	)
+	err = walk(nil, "", false, -1, walkCallbackFn(func(obj Object, dir Directory) error {
+		t.Error("Unexpected call")
+		return nil
+	}), ls.ListDir)
+	assert.Equal(t, listErr, err)
+}
@@ -321,6 +321,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 		ReadMimeType:  true,
 		WriteMimeType: true,
 		BucketBased:   true,
+		MaxPathLength: 1024,
 	}).Fill(f)
 	if f.objectACL == "" {
 		f.objectACL = "private"
@@ -434,6 +434,32 @@ func (o *Object) Open(options ...fs.OpenOption) (in io.ReadCloser, err error) {
 	return res.Body, nil
 }
 
+// RangeOpen opens the object for reading length bytes from offset, or
+// to the end if length is < 0, using an HTTP Range request.  If the
+// server ignores the Range request the data before offset is
+// discarded.
+func (o *Object) RangeOpen(offset, length int64) (io.ReadCloser, error) {
+	end := int64(-1)
+	if length > 0 {
+		end = offset + length - 1
+	}
+	req, err := http.NewRequest("GET", o.url(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "RangeOpen failed")
+	}
+	req.Header.Add((&fs.RangeOption{Start: offset, End: end}).Header())
+	res, err := o.fs.httpClient.Do(req)
+	err = statusError(res, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "RangeOpen failed")
+	}
+	if res.StatusCode == http.StatusPartialContent {
+		return res.Body, nil
+	}
+	fs.Debugf(o, "Server ignored Range request so discarding %d bytes", offset)
+	return fs.NewRangeReadCloser(res.Body, offset, length)
+}
+
 // Hashes returns fs.HashNone to indicate remote hashing is unavailable
 func (f *Fs) Hashes() fs.HashSet {
 	return fs.HashSet(fs.HashNone)
@@ -470,4 +496,5 @@ var (
 	_ fs.PutStreamer = &Fs{}
 	_ fs.Object      = &Object{}
 	_ fs.MimeTyper   = &Object{}
+	_ fs.RangeOpener = &Object{}
 )
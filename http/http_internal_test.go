@@ -161,6 +161,55 @@ func TestOpen(t *testing.T) {
 	assert.Equal(t, "eetro", string(data))
 }
 
+func TestRangeOpen(t *testing.T) {
+	f, tidy := prepare(t)
+	defer tidy()
+
+	o, err := f.NewObject("four/under four.txt")
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		offset int64
+		length int64
+		want   string
+	}{
+		{0, -1, "beetroot\n"},
+		{1, 5, "eetro"},
+		{3, -1, "troot\n"},
+	} {
+		fd, err := o.(fs.RangeOpener).RangeOpen(test.offset, test.length)
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(fd)
+		require.NoError(t, err)
+		require.NoError(t, fd.Close())
+		assert.Equal(t, test.want, string(data))
+	}
+}
+
+func TestRangeOpenIgnored(t *testing.T) {
+	// A server which ignores Range requests
+	fileServer := http.FileServer(http.Dir(filesPath))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del("Range")
+		fileServer.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	fs.LoadConfig()
+	fs.ConfigFileSet(remoteName, "type", "http")
+	fs.ConfigFileSet(remoteName, "url", ts.URL)
+	f, err := NewFs(remoteName, "")
+	require.NoError(t, err)
+
+	o, err := f.NewObject("four/under four.txt")
+	require.NoError(t, err)
+	fd, err := o.(fs.RangeOpener).RangeOpen(1, 5)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(fd)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	assert.Equal(t, "eetro", string(data))
+}
+
 func TestMimeType(t *testing.T) {
 	f, tidy := prepare(t)
 	defer tidy()
@@ -0,0 +1,38 @@
+// About for unix like systems
+
+// +build darwin freebsd linux
+
+package local
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// About gets quota information
+func (f *Fs) About() (*fs.Usage, error) {
+	var s syscall.Statfs_t
+	err := syscall.Statfs(f.root, &s)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fs.ErrorDirNotFound
+		}
+		return nil, errors.Wrap(err, "failed to read disk usage")
+	}
+	bs := int64(s.Bsize)
+	total := bs * int64(s.Blocks)
+	used := bs * int64(s.Blocks-s.Bfree)
+	free := bs * int64(s.Bavail)
+	usage := &fs.Usage{
+		Total: &total,
+		Used:  &used,
+		Free:  &free,
+	}
+	return usage, nil
+}
+
+// check interface
+var _ fs.Abouter = &Fs{}
@@ -265,7 +265,8 @@ func (f *Fs) List(dir string) (entries fs.DirEntries, err error) {
 // cleanRemote makes string a valid UTF-8 string for remote strings.
 //
 // Any invalid UTF-8 characters will be replaced with utf8.RuneError
-// It also normalises the UTF-8 and converts the slashes if necessary.
+// It also normalises the UTF-8 if --unicode-normalization is set and
+// converts the slashes if necessary.
 func (f *Fs) cleanRemote(name string) string {
 	if !utf8.ValidString(name) {
 		f.wmu.Lock()
@@ -276,6 +277,7 @@ func (f *Fs) cleanRemote(name string) string {
 		f.wmu.Unlock()
 		name = string([]rune(name))
 	}
+	name = fs.NormalizeUnicode(name)
 	name = filepath.ToSlash(name)
 	return name
 }
@@ -361,6 +363,16 @@ func (f *Fs) Rmdir(dir string) error {
 	return os.Remove(root)
 }
 
+// DirSetModTime sets the modification time of the directory dir
+func (f *Fs) DirSetModTime(dir string, modTime time.Time) error {
+	root := f.cleanPath(filepath.Join(f.root, dir))
+	err := os.Chtimes(root, modTime, modTime)
+	if os.IsNotExist(err) {
+		return fs.ErrorDirNotFound
+	}
+	return err
+}
+
 // Precision of the file system
 func (f *Fs) Precision() (precision time.Duration) {
 	f.precisionOk.Do(func() {
@@ -706,6 +718,23 @@ func (o *Object) Open(options ...fs.OpenOption) (in io.ReadCloser, err error) {
 	return in, nil
 }
 
+// RangeOpen opens the file for reading length bytes from offset, or
+// to the end if length is < 0
+func (o *Object) RangeOpen(offset, length int64) (io.ReadCloser, error) {
+	fd, err := os.Open(o.path)
+	if err != nil {
+		return nil, err
+	}
+	if offset != 0 {
+		_, err = fd.Seek(offset, 0)
+		if err != nil {
+			_ = fd.Close()
+			return nil, err
+		}
+	}
+	return fs.NewRangeReadCloser(fd, 0, length)
+}
+
 // mkdirAll makes all the directories needed to store the object
 func (o *Object) mkdirAll() error {
 	dir, _ := getDirFile(o.path)
@@ -917,10 +946,13 @@ func cleanWindowsName(f *Fs, name string) string {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs          = &Fs{}
-	_ fs.Purger      = &Fs{}
-	_ fs.PutStreamer = &Fs{}
-	_ fs.Mover       = &Fs{}
-	_ fs.DirMover    = &Fs{}
-	_ fs.Object      = &Object{}
+	_ fs.Fs             = &Fs{}
+	_ fs.Purger         = &Fs{}
+	_ fs.PutStreamer    = &Fs{}
+	_ fs.Mover          = &Fs{}
+	_ fs.DirMover       = &Fs{}
+	_ fs.DirSetModTimer = &Fs{}
+	_ fs.Object         = &Object{}
+	_ fs.RangeOpener    = &Object{}
+	_ fs.Metadataer     = &Object{}
 )
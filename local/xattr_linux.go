@@ -0,0 +1,97 @@
+// Metadata stored as extended attributes
+
+// +build linux
+
+package local
+
+import (
+	"strings"
+	"syscall"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// listXattr returns the names of the extended attributes of path
+func listXattr(path string) ([]string, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err == syscall.ENOTSUP {
+		return nil, fs.ErrorMetadataNotSupported
+	}
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(string(buf[:size]), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// getXattr returns the value of the extended attribute name of path
+func getXattr(path, name string) (string, error) {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil || size == 0 {
+		return "", err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Getxattr(path, name, buf)
+	if err != nil {
+		return "", err
+	}
+	return string(buf[:size]), nil
+}
+
+// Metadata returns the extended attributes of the file
+func (o *Object) Metadata() (map[string]string, error) {
+	names, err := listXattr(o.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list extended attributes")
+	}
+	metadata := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := getXattr(o.path, name)
+		if err == syscall.ENODATA {
+			// Removed since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read extended attribute %q", name)
+		}
+		metadata[name] = value
+	}
+	return metadata, nil
+}
+
+// SetMetadata replaces the extended attributes of the file with
+// metadata
+func (o *Object) SetMetadata(metadata map[string]string) error {
+	names, err := listXattr(o.path)
+	if err != nil {
+		return errors.Wrap(err, "failed to list extended attributes")
+	}
+	for _, name := range names {
+		if _, ok := metadata[name]; ok {
+			continue
+		}
+		err = unix.Removexattr(o.path, name)
+		if err != nil && err != syscall.ENODATA {
+			return errors.Wrapf(err, "failed to remove extended attribute %q", name)
+		}
+	}
+	for name, value := range metadata {
+		err = unix.Setxattr(o.path, name, []byte(value), 0)
+		if err != nil {
+			return errors.Wrapf(err, "failed to set extended attribute %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+// Metadata stored as extended attributes
+
+// +build !linux
+
+package local
+
+import "github.com/ncw/rclone/fs"
+
+// Metadata returns fs.ErrorMetadataNotSupported as extended
+// attributes are only supported on Linux
+func (o *Object) Metadata() (map[string]string, error) {
+	return nil, fs.ErrorMetadataNotSupported
+}
+
+// SetMetadata returns fs.ErrorMetadataNotSupported as extended
+// attributes are only supported on Linux
+func (o *Object) SetMetadata(metadata map[string]string) error {
+	return fs.ErrorMetadataNotSupported
+}
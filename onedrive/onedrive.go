@@ -1221,6 +1221,12 @@ func (o *Object) Remove() error {
 	return o.fs.deleteObject(o.id)
 }
 
+// Trash sends the object to the recycle bin.  OneDrive deletes
+// always go to the recycle bin so this is the same as Remove.
+func (o *Object) Trash() error {
+	return o.Remove()
+}
+
 // MimeType of an Object if known, "" otherwise
 func (o *Object) MimeType() string {
 	return o.mimeType
@@ -1236,4 +1242,5 @@ var (
 	_ fs.DirCacheFlusher = (*Fs)(nil)
 	_ fs.Object          = (*Object)(nil)
 	_ fs.MimeTyper       = &Object{}
+	_ fs.Trasher         = &Object{}
 )
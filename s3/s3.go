@@ -213,6 +213,22 @@ func init() {
 				Value: "STANDARD_IA",
 				Help:  "Standard Infrequent Access storage class",
 			}},
+		}, {
+			Name:     "ca_cert",
+			Help:     "CA certificate file (PEM) to verify the server with - leave blank to use --ca-cert or the system ones.",
+			Optional: true,
+		}, {
+			Name:     "client_cert",
+			Help:     "Client certificate file (PEM) for mutual TLS - leave blank to use --client-cert.",
+			Optional: true,
+		}, {
+			Name:     "client_key",
+			Help:     "Client private key file (PEM) for client_cert - leave blank to use --client-key.",
+			Optional: true,
+		}, {
+			Name:     "no_check_certificate",
+			Help:     "Set to true to not verify the server's certificate. Insecure. Leave blank to use --no-check-certificate.",
+			Optional: true,
 		}},
 	})
 }
@@ -263,6 +279,7 @@ type Object struct {
 	lastModified time.Time          // Last modified
 	meta         map[string]*string // The object metadata if known - may be nil
 	mimeType     string             // MimeType of object - may be ""
+	storageClass string             // eg GLACIER - may be "" if not read yet
 }
 
 // ------------------------------------------------------------
@@ -356,12 +373,16 @@ func s3Connection(name string) (*s3.S3, *session.Session, error) {
 	if region == "" {
 		region = "us-east-1"
 	}
+	client, err := fs.Config.ClientForRemote(name)
+	if err != nil {
+		return nil, nil, err
+	}
 	awsConfig := aws.NewConfig().
 		WithRegion(region).
 		WithMaxRetries(maxRetries).
 		WithCredentials(cred).
 		WithEndpoint(endpoint).
-		WithHTTPClient(fs.Config.Client()).
+		WithHTTPClient(client).
 		WithS3ForcePathStyle(true)
 	// awsConfig.WithLogLevel(aws.LogDebugWithSigning)
 	ses := session.New()
@@ -404,9 +425,11 @@ func NewFs(name, root string) (fs.Fs, error) {
 		storageClass:       fs.ConfigFileGet(name, "storage_class"),
 	}
 	f.features = (&fs.Features{
-		ReadMimeType:  true,
-		WriteMimeType: true,
-		BucketBased:   true,
+		ReadMimeType:         true,
+		WriteMimeType:        true,
+		BucketBased:          true,
+		EventuallyConsistent: true,
+		MaxPathLength:        1024,
 	}).Fill(f)
 	if *s3ACL != "" {
 		f.acl = *s3ACL
@@ -455,6 +478,7 @@ func (f *Fs) newObjectWithInfo(remote string, info *s3.Object) (fs.Object, error
 		}
 		o.etag = aws.StringValue(info.ETag)
 		o.bytes = aws.Int64Value(info.Size)
+		o.storageClass = aws.StringValue(info.StorageClass)
 	} else {
 		err := o.readMetaData() // reads info and meta, returning an error
 		if err != nil {
@@ -794,6 +818,49 @@ func (f *Fs) Hashes() fs.HashSet {
 	return fs.HashSet(fs.HashMD5)
 }
 
+// ListMultipartUploads lists the incomplete multipart uploads under
+// the root
+func (f *Fs) ListMultipartUploads() (uploads []fs.MultipartUpload, err error) {
+	if f.bucket == "" {
+		return nil, errors.New("can't list multipart uploads without a bucket")
+	}
+	req := s3.ListMultipartUploadsInput{
+		Bucket: &f.bucket,
+		Prefix: &f.root,
+	}
+	err = f.c.ListMultipartUploadsPages(&req, func(resp *s3.ListMultipartUploadsOutput, lastPage bool) bool {
+		for _, upload := range resp.Uploads {
+			key := aws.StringValue(upload.Key)
+			if !strings.HasPrefix(key, f.root) {
+				fs.Logf(f, "Odd name received %q", key)
+				continue
+			}
+			uploads = append(uploads, fs.MultipartUpload{
+				Remote:    key[len(f.root):],
+				ID:        aws.StringValue(upload.UploadId),
+				Initiated: aws.TimeValue(upload.Initiated),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+// AbortMultipartUpload aborts the incomplete multipart upload passed in
+func (f *Fs) AbortMultipartUpload(upload fs.MultipartUpload) error {
+	key := f.root + upload.Remote
+	req := s3.AbortMultipartUploadInput{
+		Bucket:   &f.bucket,
+		Key:      &key,
+		UploadId: &upload.ID,
+	}
+	_, err := f.c.AbortMultipartUpload(&req)
+	return err
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -872,6 +939,11 @@ func (o *Object) readMetaData() (err error) {
 		o.lastModified = *resp.LastModified
 	}
 	o.mimeType = aws.StringValue(resp.ContentType)
+	// HEAD only returns the storage class if it isn't STANDARD
+	o.storageClass = aws.StringValue(resp.StorageClass)
+	if o.storageClass == "" {
+		o.storageClass = s3.StorageClassStandard
+	}
 	return nil
 }
 
@@ -911,7 +983,61 @@ func (o *Object) SetModTime(modTime time.Time) error {
 		fs.Debugf(o, "SetModTime is unsupported for objects bigger than %v bytes", fs.SizeSuffix(maxSizeForCopy))
 		return nil
 	}
+	return o.replaceMetadata(o.meta)
+}
+
+// Metadata returns the user metadata of the object, not including
+// the modification time rclone stores there
+func (o *Object) Metadata() (map[string]string, error) {
+	err := o.readMetaData()
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]string, len(o.meta))
+	for key, value := range o.meta {
+		if strings.EqualFold(key, metaMtime) {
+			continue
+		}
+		// S3 metadata keys are case insensitive and stored in
+		// lower case
+		metadata[strings.ToLower(key)] = aws.StringValue(value)
+	}
+	return metadata, nil
+}
 
+// SetMetadata replaces the user metadata of the object, keeping the
+// modification time
+func (o *Object) SetMetadata(metadata map[string]string) error {
+	err := o.readMetaData()
+	if err != nil {
+		return err
+	}
+	if o.bytes >= maxSizeForCopy {
+		return errors.Errorf("can't set metadata on objects bigger than %v bytes", fs.SizeSuffix(maxSizeForCopy))
+	}
+	meta := make(map[string]*string, len(metadata)+1)
+	for key, value := range metadata {
+		if strings.EqualFold(key, metaMtime) {
+			return errors.Errorf("can't set metadata %q as it is used for the modification time", key)
+		}
+		meta[key] = aws.String(value)
+	}
+	if mtime, ok := o.meta[metaMtime]; ok {
+		meta[metaMtime] = mtime
+	}
+	err = o.replaceMetadata(meta)
+	if err != nil {
+		return err
+	}
+	// Read the metadata again next time to get the keys as
+	// returned by S3
+	o.meta = nil
+	return nil
+}
+
+// replaceMetadata replaces the metadata of the object with meta by
+// copying the object to itself
+func (o *Object) replaceMetadata(meta map[string]*string) error {
 	// Guess the content type
 	mimeType := fs.MimeType(o)
 
@@ -925,10 +1051,10 @@ func (o *Object) SetModTime(modTime time.Time) error {
 		Key:               &key,
 		ContentType:       &mimeType,
 		CopySource:        aws.String(url.QueryEscape(sourceKey)),
-		Metadata:          o.meta,
+		Metadata:          meta,
 		MetadataDirective: &directive,
 	}
-	_, err = o.fs.c.CopyObject(&req)
+	_, err := o.fs.c.CopyObject(&req)
 	return err
 }
 
@@ -967,6 +1093,16 @@ func (o *Object) Open(options ...fs.OpenOption) (in io.ReadCloser, err error) {
 	return resp.Body, nil
 }
 
+// RangeOpen opens the object for reading length bytes from offset,
+// or to the end if length is < 0, using an HTTP Range request
+func (o *Object) RangeOpen(offset, length int64) (io.ReadCloser, error) {
+	end := int64(-1)
+	if length > 0 {
+		end = offset + length - 1
+	}
+	return o.Open(&fs.RangeOption{Start: offset, End: end})
+}
+
 // Update the Object from in with modTime and size
 func (o *Object) Update(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
 	err := o.fs.Mkdir("")
@@ -974,18 +1110,27 @@ func (o *Object) Update(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOptio
 		return err
 	}
 	modTime := src.ModTime()
+	partSize := s3manager.MinUploadPartSize
+	for _, option := range options {
+		if x, ok := option.(*fs.ChunkOption); ok && x.ChunkSize > partSize {
+			partSize = x.ChunkSize
+		}
+	}
 
 	uploader := s3manager.NewUploader(o.fs.ses, func(u *s3manager.Uploader) {
 		u.Concurrency = 2
 		u.LeavePartsOnError = false
 		u.S3 = o.fs.c
-		u.PartSize = s3manager.MinUploadPartSize
+		u.PartSize = partSize
 		size := src.Size()
 
 		if size == -1 {
 			// Make parts as small as possible while still being able to upload to the
 			// S3 file size limit. Rounded up to nearest MB.
-			u.PartSize = (((maxFileSize / s3manager.MaxUploadParts) >> 20) + 1) << 20
+			minPartSize := int64((((maxFileSize / s3manager.MaxUploadParts) >> 20) + 1) << 20)
+			if u.PartSize < minPartSize {
+				u.PartSize = minPartSize
+			}
 			return
 		}
 		// Adjust PartSize until the number of parts is small enough.
@@ -1051,12 +1196,29 @@ func (o *Object) MimeType() string {
 	return o.mimeType
 }
 
+// StorageClass returns the storage class of the object, eg GLACIER
+func (o *Object) StorageClass() string {
+	if o.storageClass == "" {
+		err := o.readMetaData()
+		if err != nil {
+			fs.Logf(o, "Failed to read metadata: %v", err)
+			return ""
+		}
+	}
+	return o.storageClass
+}
+
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs          = &Fs{}
-	_ fs.Copier      = &Fs{}
-	_ fs.PutStreamer = &Fs{}
-	_ fs.ListRer     = &Fs{}
-	_ fs.Object      = &Object{}
-	_ fs.MimeTyper   = &Object{}
+	_ fs.Fs               = &Fs{}
+	_ fs.Copier           = &Fs{}
+	_ fs.PutStreamer      = &Fs{}
+	_ fs.ListRer          = &Fs{}
+	_ fs.MultipartLister  = &Fs{}
+	_ fs.MultipartAborter = &Fs{}
+	_ fs.Object           = &Object{}
+	_ fs.MimeTyper        = &Object{}
+	_ fs.StorageClasser   = &Object{}
+	_ fs.RangeOpener      = &Object{}
+	_ fs.Metadataer       = &Object{}
 )
@@ -245,9 +245,11 @@ func NewFsWithConnection(name, root string, c *swift.Connection, noCheckContaine
 		noCheckContainer:  noCheckContainer,
 	}
 	f.features = (&fs.Features{
-		ReadMimeType:  true,
-		WriteMimeType: true,
-		BucketBased:   true,
+		ReadMimeType:         true,
+		WriteMimeType:        true,
+		BucketBased:          true,
+		EventuallyConsistent: true,
+		MaxPathLength:        1024,
 	}).Fill(f)
 	// StorageURL overloading
 	storageURL := fs.ConfigFileGet(name, "storage_url")
@@ -531,6 +533,28 @@ func (f *Fs) Rmdir(dir string) error {
 	return err
 }
 
+// ListDirMarkers lists the directory marker objects under the root
+func (f *Fs) ListDirMarkers() (markers []string, err error) {
+	if f.container == "" {
+		return nil, fs.ErrorListBucketRequired
+	}
+	err = f.listContainerRoot(f.container, f.root, "", true, func(remote string, object *swift.Object, isDirectory bool) error {
+		if object.ContentType == directoryMarkerContentType {
+			markers = append(markers, remote)
+		}
+		return nil
+	})
+	if err == swift.ContainerNotFound {
+		err = fs.ErrorDirNotFound
+	}
+	return markers, err
+}
+
+// RemoveDirMarker removes the directory marker object called marker
+func (f *Fs) RemoveDirMarker(marker string) error {
+	return f.c.ObjectDelete(f.container, f.root+marker)
+}
+
 // Precision of the remote
 func (f *Fs) Precision() time.Duration {
 	return time.Nanosecond
@@ -930,6 +954,7 @@ var (
 	_ fs.PutStreamer = &Fs{}
 	_ fs.Copier      = &Fs{}
 	_ fs.ListRer     = &Fs{}
+	_ fs.DirMarkerer = &Fs{}
 	_ fs.Object      = &Object{}
 	_ fs.MimeTyper   = &Object{}
 )
@@ -0,0 +1,51 @@
+// Bandwidth limiting for a single VFS
+
+package vfs
+
+import (
+	"github.com/ncw/rclone/fs"
+	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
+)
+
+// maxBwLimitBurst is the most which can be transferred at once
+// without waiting for the limit
+const maxBwLimitBurst = 1024 * 1024
+
+// newBwLimiter makes a token bucket for the bandwidth given, or nil
+// if bandwidth is 0 or less meaning there is no limit
+func newBwLimiter(bandwidth fs.SizeSuffix) *rate.Limiter {
+	if bandwidth <= 0 {
+		return nil
+	}
+	burst := int(bandwidth)
+	if burst > maxBwLimitBurst {
+		burst = maxBwLimitBurst
+	}
+	return rate.NewLimiter(rate.Limit(bandwidth), burst)
+}
+
+// limitBandwidth waits until n bytes may be transferred if
+// --vfs-bwlimit is set.  This is as well as any --bwlimit which is
+// applied to all transfers, so the stricter of the two wins.
+func (vfs *VFS) limitBandwidth(n int) {
+	vfs.optMu.Lock()
+	bwLimiter := vfs.bwLimiter
+	vfs.optMu.Unlock()
+	if bwLimiter == nil {
+		return
+	}
+	burst := bwLimiter.Burst()
+	for n > 0 {
+		chunk := n
+		if chunk > burst {
+			chunk = burst
+		}
+		err := bwLimiter.WaitN(context.Background(), chunk)
+		if err != nil {
+			fs.Errorf(nil, "VFS token bucket error: %v", err)
+			return
+		}
+		n -= chunk
+	}
+}
@@ -0,0 +1,58 @@
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSBwLimit(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	const size = 512 * 1024
+	contents := strings.Repeat("x", size)
+	r.WriteObject("file1", contents, t1)
+
+	opt := DefaultOpt
+	opt.BwLimit = size / 2
+	vfs := New(r.Fremote, &opt)
+
+	// The first half comes from the burst and the second half
+	// should take a second
+	start := time.Now()
+	fd, err := vfs.OpenFile("file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(fd)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	assert.Equal(t, size, len(got))
+	elapsed := time.Since(start)
+	assert.True(t, elapsed > 800*time.Millisecond, "read too quickly in %v", elapsed)
+
+	// Writes share the same limit so this should take two seconds
+	start = time.Now()
+	fd, err = vfs.OpenFile("file2", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = fd.Write([]byte(contents))
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	elapsed = time.Since(start)
+	assert.True(t, elapsed > 1800*time.Millisecond, "wrote too quickly in %v", elapsed)
+
+	// Check a VFS without the limit isn't limited
+	vfs = New(r.Fremote, nil)
+	start = time.Now()
+	fd, err = vfs.OpenFile("file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(fd)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	elapsed = time.Since(start)
+	assert.True(t, elapsed < 800*time.Millisecond, "read too slowly in %v", elapsed)
+}
@@ -0,0 +1,101 @@
+// Pass changes on the remote on to users of the VFS
+
+package vfs
+
+import (
+	"path"
+	"strings"
+
+	"github.com/ncw/rclone/fs"
+)
+
+// ChangeNotifyFunc is called when a directory whose listing was cached
+// changes on the remote.  nodes are the entries which were cached in
+// the directory and have now been forgotten.
+type ChangeNotifyFunc func(dir *Dir, nodes Nodes)
+
+// AddChangeNotify registers fn to be called when cached directories
+// change on the remote.  Changes are only noticed on remotes which
+// can poll for them and only if --poll-interval is set.
+func (vfs *VFS) AddChangeNotify(fn ChangeNotifyFunc) {
+	vfs.notifyMu.Lock()
+	vfs.notify = append(vfs.notify, fn)
+	vfs.notifyMu.Unlock()
+}
+
+// changeNotify is called by the remote with the path of a directory
+// which has changed.  It forgets the directory listing and tells
+// anything registered with AddChangeNotify.
+//
+// Directories which aren't in the cache are ignored.
+func (vfs *VFS) changeNotify(relativePath string) {
+	dir := vfs.root.cachedDir(strings.Trim(relativePath, "/"))
+	if dir == nil {
+		return
+	}
+	vfs.forget(dir, dir.cachedNodes())
+}
+
+// ForgetDir forgets the cached listings of the directory at dirPath
+// and the directories below it, so they are read from the remote again
+// next time they are used.  Anything registered with AddChangeNotify
+// is told, so rclone mount tells the kernel to forget them too.
+//
+// Directories which aren't in the cache are ignored.
+func (vfs *VFS) ForgetDir(dirPath string) {
+	dir := vfs.root.cachedDir(vfs.remotePathOf(strings.Trim(dirPath, "/")))
+	if dir == nil {
+		return
+	}
+	vfs.forget(dir, dir.cachedNodes())
+}
+
+// ForgetPath forgets the entry at entryPath so it is read from the
+// remote again next time it is used.  If it is a directory with a
+// cached listing then this is the same as ForgetDir, otherwise the
+// listing of the directory containing it is forgotten and anything
+// registered with AddChangeNotify is told about the entry only.
+//
+// Entries which aren't in the cache are ignored.
+func (vfs *VFS) ForgetPath(entryPath string) {
+	remote := vfs.remotePathOf(strings.Trim(entryPath, "/"))
+	if dir := vfs.root.cachedDir(remote); dir != nil {
+		vfs.forget(dir, dir.cachedNodes())
+		return
+	}
+	parentPath := path.Dir(remote)
+	if parentPath == "." {
+		parentPath = ""
+	}
+	parent := vfs.root.cachedDir(parentPath)
+	if parent == nil {
+		return
+	}
+	leaf := vfs.displayName(path.Base(remote))
+	var nodes Nodes
+	for _, node := range parent.cachedNodes() {
+		if node.Name() == leaf {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return
+	}
+	vfs.forget(parent, nodes)
+}
+
+// forget forgets the cached listing of dir and the directories below
+// it and tells anything registered with AddChangeNotify that nodes
+// have been forgotten.
+func (vfs *VFS) forget(dir *Dir, nodes Nodes) {
+	dir.ForgetAll()
+	vfs.notifyMu.Lock()
+	notify := vfs.notify
+	vfs.notifyMu.Unlock()
+	if len(notify) > 0 {
+		fs.Debugf(dir, "Notifying change of %d cached entries", len(nodes))
+	}
+	for _, fn := range notify {
+		fn(dir, nodes)
+	}
+}
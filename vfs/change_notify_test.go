@@ -0,0 +1,141 @@
+package vfs
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notifyFs is an fs.Fs which can send change notifications
+type notifyFs struct {
+	fs.Fs
+	notifyFunc func(string)
+}
+
+// Features returns the optional features with DirChangeNotify set
+func (f *notifyFs) Features() *fs.Features {
+	features := *f.Fs.Features()
+	features.DirChangeNotify = f.dirChangeNotify
+	return &features
+}
+
+// dirChangeNotify remembers notifyFunc so the test can call it
+func (f *notifyFs) dirChangeNotify(notifyFunc func(string), pollInterval time.Duration) chan bool {
+	f.notifyFunc = notifyFunc
+	return make(chan bool)
+}
+
+func TestVFSChangeNotify(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	f := &notifyFs{Fs: r.Fremote}
+	vfs := New(f, nil)
+	require.NotNil(t, f.notifyFunc)
+
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	file2 := r.WriteObject("dir/sub/file2", "file2 contents!", t2)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	type change struct {
+		dir   string
+		names []string
+	}
+	var changes []change
+	vfs.AddChangeNotify(func(dir *Dir, nodes Nodes) {
+		var names []string
+		for _, node := range nodes {
+			names = append(names, node.Name())
+		}
+		changes = append(changes, change{dir: dir.path, names: names})
+	})
+
+	// Changes to directories not in the cache are ignored
+	f.notifyFunc("dir")
+	f.notifyFunc("dir/sub")
+	assert.Equal(t, 0, len(changes))
+
+	node, err := vfs.Stat("dir/file1")
+	require.NoError(t, err)
+	dir := node.(*File).Dir()
+	dir.mu.Lock()
+	assert.NotNil(t, dir.items)
+	dir.mu.Unlock()
+
+	f.notifyFunc("dir/sub")
+	f.notifyFunc("not/cached")
+	assert.Equal(t, 0, len(changes))
+
+	f.notifyFunc("dir/")
+	assert.Equal(t, []change{{dir: "dir", names: []string{"file1", "sub"}}}, changes)
+	dir.mu.Lock()
+	assert.Nil(t, dir.items)
+	dir.mu.Unlock()
+
+	// Check the directory is read again
+	file3 := r.WriteObject("dir/file3", "file3", t3)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3)
+	checkListing(t, dir, []string{"file1,14,false", "file3,5,false", "sub,0,true"})
+}
+
+func TestVFSForgetDirAndPath(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	file2 := r.WriteObject("dir/sub/file2", "file2 contents!", t2)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	f := &countFs{Fs: r.Fremote}
+	vfs := New(f, nil)
+	type change struct {
+		dir   string
+		names []string
+	}
+	var changes []change
+	vfs.AddChangeNotify(func(dir *Dir, nodes Nodes) {
+		var names []string
+		for _, node := range nodes {
+			names = append(names, node.Name())
+		}
+		changes = append(changes, change{dir: dir.path, names: names})
+	})
+
+	// stat looks up dir/sub/file2 returning how many directories
+	// were listed to do it
+	stat := func() int32 {
+		before := atomic.LoadInt32(&f.lists)
+		_, err := vfs.Stat("dir/sub/file2")
+		require.NoError(t, err)
+		return atomic.LoadInt32(&f.lists) - before
+	}
+	assert.Equal(t, int32(3), stat())
+	assert.Equal(t, int32(0), stat())
+
+	// Forgetting uncached paths does nothing
+	vfs.ForgetDir("not/cached")
+	vfs.ForgetPath("dir/potato")
+	assert.Equal(t, 0, len(changes))
+	assert.Equal(t, int32(0), stat())
+
+	// Forgetting a directory re-lists it and those below it
+	vfs.ForgetDir("/dir/")
+	assert.Equal(t, []change{{dir: "dir", names: []string{"file1", "sub"}}}, changes)
+	assert.Equal(t, int32(2), stat())
+
+	// Forgetting a file re-lists the directory it is in
+	changes = nil
+	vfs.ForgetPath("dir/sub/file2")
+	assert.Equal(t, []change{{dir: "dir/sub", names: []string{"file2"}}}, changes)
+	assert.Equal(t, int32(1), stat())
+
+	// Forgetting a directory with ForgetPath is the same as ForgetDir
+	changes = nil
+	vfs.ForgetPath("dir/sub")
+	assert.Equal(t, []change{{dir: "dir/sub", names: []string{"file2"}}}, changes)
+	assert.Equal(t, int32(1), stat())
+}
@@ -0,0 +1,346 @@
+// Control file for sending commands to a running VFS
+
+package vfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// Names of the virtual directory and file used for the control file
+const (
+	controlDirName  = ".rclone"
+	controlFileName = "command"
+)
+
+// controlCommand is a command which can be written to the control file
+type controlCommand struct {
+	help string
+	fn   func(vfs *VFS, args []string) error
+}
+
+// controlCommands are the commands understood by the control file
+var controlCommands = map[string]controlCommand{
+	"forget": {
+		help: "forget [path...] - forget the directory cache for the paths given, or everything",
+		fn: func(vfs *VFS, args []string) error {
+			if len(args) == 0 {
+				vfs.root.ForgetAll()
+				return nil
+			}
+			for _, arg := range args {
+				vfs.ForgetPath(arg)
+			}
+			return nil
+		},
+	},
+	"flush": {
+		help: "flush - flush all the directory caches, the same as sending SIGHUP",
+		fn: func(vfs *VFS, args []string) error {
+			if len(args) != 0 {
+				return errors.New("flush takes no arguments")
+			}
+			vfs.root.ForgetAll()
+			return nil
+		},
+	},
+}
+
+// control implements the control file.  It is the fs.Object for the
+// File at .rclone/command and keeps the status of the last command
+// run.
+type control struct {
+	vfs     *VFS
+	dir     *Dir
+	file    *File
+	mu      sync.Mutex // protects the following
+	status  string     // result of the last command
+	modTime time.Time  // time the last command was run
+}
+
+// newControl makes the virtual directory and control file for vfs
+func newControl(vfs *VFS) *control {
+	c := &control{
+		vfs:     vfs,
+		modTime: time.Now(),
+	}
+	c.dir = newDir(vfs, vfs.f, vfs.root, fs.NewDir(controlDirName, c.modTime))
+	c.dir.virtual = true
+	c.file = newFile(c.dir, c, controlFileName)
+	c.dir.items = map[string]Node{
+		controlFileName: c.file,
+	}
+	return c
+}
+
+// run executes each line in commands, recording the status of the last
+func (c *control) run(commands string) (err error) {
+	for _, line := range strings.Split(commands, "\n") {
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		var out string
+		out, err = c.runCommand(args)
+		c.mu.Lock()
+		if err != nil {
+			fs.Errorf(c, "%s: %v", line, err)
+			c.status = fmt.Sprintf("ERROR: %s: %v\n", line, err)
+		} else {
+			fs.Infof(c, "%s: OK", line)
+			c.status = fmt.Sprintf("OK: %s\n", line) + out
+		}
+		c.modTime = time.Now()
+		c.mu.Unlock()
+		if err != nil {
+			break
+		}
+	}
+	return err
+}
+
+// runCommand runs the single command in args returning any output
+func (c *control) runCommand(args []string) (out string, err error) {
+	name := args[0]
+	if name == "help" {
+		return controlHelp(), nil
+	}
+	command, ok := controlCommands[name]
+	if !ok {
+		return "", errors.Errorf("unknown command %q - try help", name)
+	}
+	return "", command.fn(c.vfs, args[1:])
+}
+
+// controlHelp returns the help for all the commands
+func controlHelp() string {
+	var out []string
+	for _, command := range controlCommands {
+		out = append(out, command.help)
+	}
+	sort.Strings(out)
+	return strings.Join(out, "\n") + "\n"
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (c *control) Fs() fs.Info {
+	return c.vfs.f
+}
+
+// String returns the remote path
+func (c *control) String() string {
+	return c.Remote()
+}
+
+// Remote returns the remote path
+func (c *control) Remote() string {
+	return path.Join(controlDirName, controlFileName)
+}
+
+// ModTime returns the time of the last command
+func (c *control) ModTime() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.modTime
+}
+
+// Size returns the size of the status
+func (c *control) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int64(len(c.status))
+}
+
+// Hash returns the selected checksum of the file
+func (c *control) Hash(fs.HashType) (string, error) {
+	return "", fs.ErrHashUnsupported
+}
+
+// Storable says whether this object can be stored
+func (c *control) Storable() bool {
+	return false
+}
+
+// SetModTime is not supported on the control file
+func (c *control) SetModTime(time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// Open returns the status of the last command
+func (c *control) Open(options ...fs.OpenOption) (io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ioutil.NopCloser(strings.NewReader(c.status)), nil
+}
+
+// Update runs the commands read from in
+func (c *control) Update(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	commands, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	return c.run(string(commands))
+}
+
+// Remove is not allowed on the control file
+func (c *control) Remove() error {
+	return EPERM
+}
+
+var _ fs.Object = (*control)(nil)
+
+// ControlHandle is an open handle on the control file
+//
+// Writes are buffered and run as commands when the handle is flushed
+// or closed.  Reads return the status of the last command at the
+// time the handle was opened.
+type ControlHandle struct {
+	baseHandle
+	mu      sync.Mutex
+	closed  bool // set if handle has been closed
+	c       *control
+	status  []byte       // status when the handle was opened
+	offset  int64        // offset of Read() calls
+	pending bytes.Buffer // commands written but not yet run
+}
+
+// Check interfaces
+var (
+	_ Handle    = (*ControlHandle)(nil)
+	_ Noder     = (*ControlHandle)(nil)
+	_ io.Reader = (*ControlHandle)(nil)
+	_ io.Writer = (*ControlHandle)(nil)
+)
+
+// newControlHandle opens the control file
+func newControlHandle(c *control) *ControlHandle {
+	c.mu.Lock()
+	status := c.status
+	c.mu.Unlock()
+	return &ControlHandle{
+		c:      c,
+		status: []byte(status),
+	}
+}
+
+// String converts it to printable
+func (fh *ControlHandle) String() string {
+	if fh == nil {
+		return "<nil *ControlHandle>"
+	}
+	return fh.c.file.String() + " (c)"
+}
+
+// Node returns the Node assocuated with this - satisfies Noder interface
+func (fh *ControlHandle) Node() Node {
+	return fh.c.file
+}
+
+// Stat returns info about the file
+func (fh *ControlHandle) Stat() (os.FileInfo, error) {
+	return fh.c.file, nil
+}
+
+// ReadAt reads the status at offset off
+func (fh *ControlHandle) ReadAt(p []byte, off int64) (n int, err error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	return fh.readAt(p, off)
+}
+
+// Implementation of ReadAt - call with lock held
+func (fh *ControlHandle) readAt(p []byte, off int64) (n int, err error) {
+	if fh.closed {
+		return 0, ECLOSED
+	}
+	if off >= int64(len(fh.status)) {
+		return 0, io.EOF
+	}
+	n = copy(p, fh.status[off:])
+	if n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Read reads the status
+func (fh *ControlHandle) Read(p []byte) (n int, err error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	n, err = fh.readAt(p, fh.offset)
+	fh.offset += int64(n)
+	return n, err
+}
+
+// WriteAt appends the command in p - the offset is ignored
+func (fh *ControlHandle) WriteAt(p []byte, off int64) (n int, err error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.closed {
+		return 0, ECLOSED
+	}
+	return fh.pending.Write(p)
+}
+
+// Write appends the command in p
+func (fh *ControlHandle) Write(p []byte) (n int, err error) {
+	return fh.WriteAt(p, 0)
+}
+
+// WriteString appends the command in s
+func (fh *ControlHandle) WriteString(s string) (n int, err error) {
+	return fh.Write([]byte(s))
+}
+
+// flush runs any pending commands - call with the lock held
+func (fh *ControlHandle) flush() error {
+	if fh.pending.Len() == 0 {
+		return nil
+	}
+	commands := fh.pending.String()
+	fh.pending.Reset()
+	return fh.c.run(commands)
+}
+
+// Flush runs any commands written so far
+func (fh *ControlHandle) Flush() error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.closed {
+		return nil
+	}
+	return fh.flush()
+}
+
+// Close runs any pending commands and closes the handle
+func (fh *ControlHandle) Close() error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.closed {
+		return ECLOSED
+	}
+	fh.closed = true
+	return fh.flush()
+}
+
+// Release is called when we are finished with the file handle
+func (fh *ControlHandle) Release() error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.closed {
+		return nil
+	}
+	fh.closed = true
+	return fh.flush()
+}
@@ -0,0 +1,128 @@
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Make a VFS with the control file enabled
+func controlCreate(t *testing.T, r *fstest.Run) (*VFS, *Dir) {
+	opt := DefaultOpt
+	opt.ControlFile = true
+	vfs := New(r.Fremote, &opt)
+
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	// Make sure / and dir are in cache
+	_, err := vfs.Stat(file1.Path)
+	require.NoError(t, err)
+	node, err := vfs.Stat("dir")
+	require.NoError(t, err)
+
+	return vfs, node.(*Dir)
+}
+
+// write a command to the control file
+func controlWrite(t *testing.T, vfs *VFS, command string) error {
+	fh, err := vfs.OpenFile(".rclone/command", os.O_WRONLY|os.O_TRUNC, 0777)
+	require.NoError(t, err)
+	_, err = fh.Write([]byte(command))
+	require.NoError(t, err)
+	return fh.Close()
+}
+
+// read the status from the control file
+func controlRead(t *testing.T, vfs *VFS) string {
+	fh, err := vfs.OpenFile(".rclone/command", os.O_RDONLY, 0777)
+	require.NoError(t, err)
+	buf, err := ioutil.ReadAll(fh)
+	require.NoError(t, err)
+	require.NoError(t, fh.Close())
+	return string(buf)
+}
+
+func TestControlListing(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs, _ := controlCreate(t, r)
+
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	checkListing(t, root, []string{".rclone,0,true", "dir,0,true"})
+
+	node, err := vfs.Stat(".rclone")
+	require.NoError(t, err)
+	checkListing(t, node.(*Dir), []string{"command,0,false"})
+
+	// Check the control file survives forgetting the cache
+	root.ForgetAll()
+	checkListing(t, root, []string{".rclone,0,true", "dir,0,true"})
+	checkListing(t, node.(*Dir), []string{"command,0,false"})
+
+	// Check it can't be modified
+	assert.Equal(t, EPERM, vfs.Rename(".rclone/command", "command"))
+	assert.Equal(t, EPERM, vfs.Rename(".rclone", "rclone"))
+	assert.Equal(t, EPERM, node.Remove())
+	_, err = node.(*Dir).Mkdir("sub")
+	assert.Equal(t, EPERM, err)
+	file, err := vfs.Stat(".rclone/command")
+	require.NoError(t, err)
+	assert.Equal(t, EPERM, file.Remove())
+}
+
+func TestControlForget(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs, dir := controlCreate(t, r)
+
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(root.items))
+	assert.Equal(t, 1, len(dir.items))
+	assert.False(t, dir.read.IsZero())
+
+	require.NoError(t, controlWrite(t, vfs, "forget dir\n"))
+	assert.Equal(t, 2, len(root.items))
+	assert.Equal(t, 0, len(dir.items))
+	assert.True(t, dir.read.IsZero())
+	assert.Equal(t, "OK: forget dir\n", controlRead(t, vfs))
+
+	node, err := vfs.Stat(".rclone/command")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("OK: forget dir\n")), node.Size())
+
+	require.NoError(t, controlWrite(t, vfs, "flush"))
+	assert.Equal(t, 0, len(root.items))
+	assert.Equal(t, "OK: flush\n", controlRead(t, vfs))
+}
+
+func TestControlErrors(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs, _ := controlCreate(t, r)
+
+	err := controlWrite(t, vfs, "potato")
+	assert.EqualError(t, err, `unknown command "potato" - try help`)
+	assert.Equal(t, "ERROR: potato: unknown command \"potato\" - try help\n", controlRead(t, vfs))
+
+	err = controlWrite(t, vfs, "flush now")
+	assert.EqualError(t, err, "flush takes no arguments")
+
+	require.NoError(t, controlWrite(t, vfs, "help"))
+	assert.Contains(t, controlRead(t, vfs), "forget [path...]")
+}
+
+func TestControlDisabled(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs := New(r.Fremote, nil)
+
+	_, err := vfs.Stat(".rclone/command")
+	assert.Equal(t, os.ErrNotExist, err)
+}
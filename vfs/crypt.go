@@ -0,0 +1,294 @@
+// Encryption of a path on the remote
+
+package vfs
+
+import (
+	"io"
+	"strings"
+
+	"github.com/ncw/rclone/crypt"
+	"github.com/ncw/rclone/fs"
+)
+
+// cryptFs wraps an Fs for --vfs-crypt-path.  The data of files under
+// the path is encrypted when it is uploaded and decrypted when it is
+// read, using the same scheme as the crypt remote.  Names aren't
+// encrypted and files outside the path are passed through unchanged.
+type cryptFs struct {
+	fs.Fs
+	features *fs.Features
+	path     string       // path on the remote to encrypt
+	cipher   crypt.Cipher // cipher for the files in path
+	err      error        // if set the cipher couldn't be made so files in path can't be used
+}
+
+// newCryptFs wraps f so the files in dir are encrypted with cipher.
+// If err is set then the cipher couldn't be made and reading or
+// writing files in dir returns it.
+func newCryptFs(f fs.Fs, dir string, cipher crypt.Cipher, err error) *cryptFs {
+	c := &cryptFs{
+		Fs:     f,
+		path:   strings.Trim(dir, "/"),
+		cipher: cipher,
+		err:    err,
+	}
+	features := *f.Features()
+	if features.Copy != nil {
+		features.Copy = c.copy
+	}
+	if features.Move != nil {
+		features.Move = c.move
+	}
+	if features.DirMove != nil {
+		features.DirMove = c.dirMove
+	}
+	if features.PutStream != nil {
+		features.PutStream = c.putStream
+	}
+	// These would return or make objects without encrypting them
+	features.PutUnchecked = nil
+	features.ListR = nil
+	features.ReadMimeType = false
+	c.features = &features
+	return c
+}
+
+// Features returns the optional features of the wrapped Fs with the
+// ones which read or write objects replaced
+func (c *cryptFs) Features() *fs.Features {
+	return c.features
+}
+
+// encrypted returns true if the file at remote is encrypted
+func (c *cryptFs) encrypted(remote string) bool {
+	return remote == c.path || strings.HasPrefix(remote, c.path+"/")
+}
+
+// wrapObject wraps o so it is decrypted if it is encrypted
+func (c *cryptFs) wrapObject(o fs.Object) fs.Object {
+	if !c.encrypted(o.Remote()) {
+		return o
+	}
+	return &cryptObject{Object: o, f: c}
+}
+
+// unwrapObject returns the object o wraps if it is a cryptObject
+func unwrapObject(o fs.Object) fs.Object {
+	if x, ok := o.(*cryptObject); ok {
+		return x.Object
+	}
+	return o
+}
+
+// List the objects and directories in dir
+func (c *cryptFs) List(dir string) (entries fs.DirEntries, err error) {
+	entries, err = c.Fs.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = c.wrapObject(o)
+		}
+	}
+	return entries, nil
+}
+
+// NewObject finds the Object at remote
+func (c *cryptFs) NewObject(remote string) (fs.Object, error) {
+	o, err := c.Fs.NewObject(remote)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapObject(o), nil
+}
+
+// putFn is the type of Put and PutStream
+type putFn func(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error)
+
+// put uploads in with put, encrypting it first if src is encrypted
+func (c *cryptFs) put(in io.Reader, src fs.ObjectInfo, options []fs.OpenOption, put putFn) (fs.Object, error) {
+	if !c.encrypted(src.Remote()) {
+		return put(in, src, options...)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	wrappedIn, err := c.cipher.EncryptData(in)
+	if err != nil {
+		return nil, err
+	}
+	o, err := put(wrappedIn, c.newObjectInfo(src), options...)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapObject(o), nil
+}
+
+// Put in to the remote path with the modTime given of the given size
+func (c *cryptFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return c.put(in, src, options, c.Fs.Put)
+}
+
+// putStream uploads to the remote path with the modTime given of indeterminate size
+func (c *cryptFs) putStream(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return c.put(in, src, options, c.Fs.Features().PutStream)
+}
+
+// copy src to remote with a server side copy if they are both
+// encrypted or both not
+func (c *cryptFs) copy(src fs.Object, remote string) (fs.Object, error) {
+	if c.encrypted(src.Remote()) != c.encrypted(remote) {
+		return nil, fs.ErrorCantCopy
+	}
+	o, err := c.Fs.Features().Copy(unwrapObject(src), remote)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapObject(o), nil
+}
+
+// move src to remote with a server side move if they are both
+// encrypted or both not
+func (c *cryptFs) move(src fs.Object, remote string) (fs.Object, error) {
+	if c.encrypted(src.Remote()) != c.encrypted(remote) {
+		return nil, fs.ErrorCantMove
+	}
+	o, err := c.Fs.Features().Move(unwrapObject(src), remote)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapObject(o), nil
+}
+
+// dirMove moves the directory srcRemote to dstRemote unless that
+// would move files into or out of the encrypted path
+func (c *cryptFs) dirMove(src fs.Fs, srcRemote, dstRemote string) error {
+	if c.encrypted(srcRemote) != c.encrypted(dstRemote) || strings.HasPrefix(c.path+"/", srcRemote+"/") {
+		return fs.ErrorCantDirMove
+	}
+	if src == c {
+		src = c.Fs
+	}
+	return c.Fs.Features().DirMove(src, srcRemote, dstRemote)
+}
+
+// cryptObjectInfo describes the upload of an encrypted object
+type cryptObjectInfo struct {
+	fs.ObjectInfo
+	f *cryptFs
+}
+
+// newObjectInfo describes the upload of src once it is encrypted
+func (c *cryptFs) newObjectInfo(src fs.ObjectInfo) *cryptObjectInfo {
+	return &cryptObjectInfo{
+		ObjectInfo: src,
+		f:          c,
+	}
+}
+
+// Size returns the size of the encrypted data or -1 if unknown
+func (o *cryptObjectInfo) Size() int64 {
+	size := o.ObjectInfo.Size()
+	if size < 0 {
+		return size
+	}
+	return o.f.cipher.EncryptedSize(size)
+}
+
+// Hash returns "" as the hashes of the unencrypted data don't match
+func (o *cryptObjectInfo) Hash(hash fs.HashType) (string, error) {
+	return "", nil
+}
+
+// cryptObject is an encrypted Object which is decrypted when read
+type cryptObject struct {
+	fs.Object
+	f *cryptFs
+}
+
+// Fs returns the cryptFs the object is in
+func (o *cryptObject) Fs() fs.Info {
+	return o.f
+}
+
+// Size returns the size of the decrypted data
+func (o *cryptObject) Size() int64 {
+	if o.f.err != nil {
+		return o.Object.Size()
+	}
+	size, err := o.f.cipher.DecryptedSize(o.Object.Size())
+	if err != nil {
+		fs.Debugf(o, "Bad size for decrypt: %v", err)
+	}
+	return size
+}
+
+// Hash returns "" as only the hashes of the encrypted data are known
+func (o *cryptObject) Hash(hash fs.HashType) (string, error) {
+	return "", nil
+}
+
+// Open opens the object for read decrypting it
+func (o *cryptObject) Open(options ...fs.OpenOption) (io.ReadCloser, error) {
+	if o.f.err != nil {
+		return nil, o.f.err
+	}
+	var offset int64
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.SeekOption:
+			offset = x.Offset
+		default:
+			if option.Mandatory() {
+				fs.Logf(o, "Unsupported mandatory option: %v", option)
+			}
+		}
+	}
+	return o.f.cipher.DecryptDataSeek(func(underlyingOffset int64) (io.ReadCloser, error) {
+		if underlyingOffset == 0 {
+			return o.Object.Open()
+		}
+		return o.Object.Open(&fs.SeekOption{Offset: underlyingOffset})
+	}, offset)
+}
+
+// RangeOpen opens the object for reading length bytes from offset
+// decrypting it, or to the end of the object if length is < 0
+func (o *cryptObject) RangeOpen(offset, length int64) (io.ReadCloser, error) {
+	in, err := o.Open(&fs.SeekOption{Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+	return fs.NewRangeReadCloser(in, 0, length)
+}
+
+// Trash sends the object to the trash if the wrapped object can be,
+// otherwise it removes it
+func (o *cryptObject) Trash() error {
+	if do, ok := o.Object.(fs.Trasher); ok {
+		return do.Trash()
+	}
+	return o.Object.Remove()
+}
+
+// Update in to the object encrypting it
+func (o *cryptObject) Update(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	if o.f.err != nil {
+		return o.f.err
+	}
+	wrappedIn, err := o.f.cipher.EncryptData(in)
+	if err != nil {
+		return err
+	}
+	return o.Object.Update(wrappedIn, o.f.newObjectInfo(src), options...)
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs          = (*cryptFs)(nil)
+	_ fs.Object      = (*cryptObject)(nil)
+	_ fs.RangeOpener = (*cryptObject)(nil)
+	_ fs.Trasher     = (*cryptObject)(nil)
+	_ fs.ObjectInfo  = (*cryptObjectInfo)(nil)
+)
@@ -0,0 +1,139 @@
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSCrypt(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	fs.ConfigFileSet("vfscrypttest", "type", "crypt")
+	fs.ConfigFileSet("vfscrypttest", "password", fs.MustObscure("potato"))
+	defer func() {
+		fs.ConfigFileDeleteKey("vfscrypttest", "type")
+		fs.ConfigFileDeleteKey("vfscrypttest", "password")
+	}()
+
+	opt := DefaultOpt
+	opt.CryptPath = "secret"
+	opt.CryptRemote = "vfscrypttest:"
+	vfs := New(r.Fremote, &opt)
+
+	contents := "hello world - some contents which should be encrypted"
+	writeFile := func(name string) {
+		fd, err := vfs.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0777)
+		require.NoError(t, err)
+		_, err = fd.Write([]byte(contents))
+		require.NoError(t, err)
+		require.NoError(t, fd.Close())
+	}
+	readFile := func(name string) string {
+		fd, err := vfs.OpenFile(name, os.O_RDONLY, 0)
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(fd)
+		require.NoError(t, err)
+		require.NoError(t, fd.Close())
+		return string(data)
+	}
+	// readRemote reads the data stored on the remote for name
+	readRemote := func(name string) []byte {
+		o, err := r.Fremote.NewObject(name)
+		require.NoError(t, err)
+		in, err := o.Open()
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(in)
+		require.NoError(t, err)
+		require.NoError(t, in.Close())
+		return data
+	}
+
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	_, err = root.Mkdir("secret")
+	require.NoError(t, err)
+	writeFile("secret/file1")
+	writeFile("plain")
+
+	// Files are read back the same through the VFS
+	assert.Equal(t, contents, readFile("secret/file1"))
+	assert.Equal(t, contents, readFile("plain"))
+	node, err := vfs.Stat("secret/file1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(contents)), node.Size())
+
+	// Only the one in secret is encrypted on the remote
+	assert.Equal(t, contents, string(readRemote("plain")))
+	data := readRemote("secret/file1")
+	assert.NotContains(t, string(data), "hello")
+	assert.Equal(t, "RCLONE\x00\x00", string(data[:8]))
+
+	// Renaming files in and out of secret encrypts and decrypts them
+	require.NoError(t, vfs.Rename("plain", "secret/file2"))
+	require.NoError(t, vfs.Rename("secret/file1", "file1"))
+	assert.Equal(t, contents, readFile("secret/file2"))
+	assert.Equal(t, contents, readFile("file1"))
+	assert.NotContains(t, string(readRemote("secret/file2")), "hello")
+	assert.Equal(t, contents, string(readRemote("file1")))
+
+	// Seeking in an encrypted file works
+	fd, err := vfs.OpenFile("secret/file2", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = fd.ReadAt(buf, 6)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(buf))
+	require.NoError(t, fd.Close())
+
+	// Range reads of the wrapped object are decrypted
+	o, err := vfs.f.NewObject("secret/file2")
+	require.NoError(t, err)
+	in, err := fs.RangeOpen(o, 6, 5)
+	require.NoError(t, err)
+	data, err = ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "world", string(data))
+
+	// The wrapped object can be trashed
+	_, ok := o.(fs.Trasher)
+	assert.True(t, ok)
+
+	assert.NoError(t, opt.Check())
+}
+
+func TestVFSCryptBadRemote(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	opt := DefaultOpt
+	opt.CryptPath = "secret"
+	opt.CryptRemote = "vfscryptnotfound:"
+	assert.Error(t, opt.Check())
+	vfs := New(r.Fremote, &opt)
+
+	// writeFile writes hello to name returning the error from
+	// uploading it
+	writeFile := func(name string) error {
+		fd, err := vfs.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0777)
+		require.NoError(t, err)
+		_, _ = fd.Write([]byte("hello"))
+		return fd.Close()
+	}
+
+	// Files outside the path can be written but not inside
+	require.NoError(t, writeFile("plain"))
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	_, err = root.Mkdir("secret")
+	require.NoError(t, err)
+	assert.Error(t, writeFile("secret/file"))
+	_, err = r.Fremote.NewObject("secret/file")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ncw/rclone/fs"
 	"github.com/pkg/errors"
+	"golang.org/x/net/context"
 )
 
 // Dir represents a directory entry
@@ -21,9 +22,14 @@ type Dir struct {
 	path    string
 	modTime time.Time
 	entry   fs.Directory
-	mu      sync.Mutex      // protects the following
-	read    time.Time       // time directory entry last read
-	items   map[string]Node // NB can be nil when directory not read yet
+	virtual bool             // set if the directory only exists in the VFS
+	exclMu  sync.Mutex       // serialises CreateExclusive
+	mu      sync.Mutex       // protects the following
+	read    time.Time        // time directory entry last read
+	items   map[string]Node  // NB can be nil when directory not read yet
+	pending map[string]*File // files created but not yet uploaded
+	reread  bool             // set if a background re-read is scheduled
+	changed time.Time        // time the directory was last changed through the VFS
 }
 
 func newDir(vfs *VFS, f fs.Fs, parent *Dir, fsDir fs.Directory) *Dir {
@@ -33,11 +39,26 @@ func newDir(vfs *VFS, f fs.Fs, parent *Dir, fsDir fs.Directory) *Dir {
 		parent:  parent,
 		entry:   fsDir,
 		path:    fsDir.Remote(),
-		modTime: fsDir.ModTime(),
+		modTime: vfs.dirModTime(fsDir),
 		inode:   newInode(),
 	}
 }
 
+// dirModTime returns the modification time of the directory fsDir on
+// the remote, or when the VFS was started if the remote doesn't have
+// directory modification times.  Remotes without them list directories
+// with no time, or the unix epoch in the case of http.
+func (vfs *VFS) dirModTime(fsDir fs.Directory) time.Time {
+	if x, ok := fsDir.(*fs.Dir); ok && !x.HasModTime() {
+		return vfs.started
+	}
+	modTime := fsDir.ModTime()
+	if modTime.IsZero() || modTime.Equal(time.Unix(0, 0)) {
+		return vfs.started
+	}
+	return modTime
+}
+
 // String converts it to printablee
 func (d *Dir) String() string {
 	if d == nil {
@@ -58,6 +79,9 @@ func (d *Dir) IsDir() bool {
 
 // Mode bits of the directory - satisfies Node interface
 func (d *Dir) Mode() (mode os.FileMode) {
+	if d.vfs.permRules != nil {
+		return os.ModeDir | d.vfs.perms(d.path+"/", d.vfs.Opt.DirPerms)
+	}
 	return d.vfs.Opt.DirPerms
 }
 
@@ -65,9 +89,9 @@ func (d *Dir) Mode() (mode os.FileMode) {
 func (d *Dir) Name() (name string) {
 	name = path.Base(d.path)
 	if name == "." {
-		name = "/"
+		return "/"
 	}
-	return name
+	return d.vfs.displayName(name)
 }
 
 // Sys returns underlying data source (can be nil) - satisfies Node interface
@@ -103,12 +127,91 @@ func (d *Dir) ForgetPath(relativePath string) {
 	}
 
 	d.walk(absPath, func(dir *Dir) {
+		if dir.virtual {
+			return
+		}
 		fs.Debugf(dir.path, "forgetting directory cache")
 		dir.read = time.Time{}
 		dir.items = nil
+		dir.vfs.dirCache.remove(dir)
 	})
 }
 
+// evict forgets the cached listing of the directory to make room in
+// the directory cache.  Directories with files open or being
+// uploaded, or with subdirectories whose listings are cached, are
+// left alone.
+func (d *Dir) evict() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.items == nil {
+		d.vfs.dirCache.remove(d)
+		return
+	}
+	if len(d.pending) != 0 {
+		return
+	}
+	for _, node := range d.items {
+		switch x := node.(type) {
+		case *File:
+			if x.isOpen() {
+				return
+			}
+		case *Dir:
+			if x.virtual {
+				continue
+			}
+			x.mu.Lock()
+			cached := x.items != nil
+			x.mu.Unlock()
+			if cached {
+				return
+			}
+		}
+	}
+	fs.Debugf(d.path, "forgetting directory cache to make room")
+	d.read = time.Time{}
+	d.items = nil
+	d.vfs.dirCache.remove(d)
+}
+
+// cachedDir returns the directory at relativePath on the remote below
+// d if it and all the directories above it have cached listings, or
+// nil if not.  It never reads from the remote.
+func (d *Dir) cachedDir(relativePath string) *Dir {
+	dir := d
+	for _, leaf := range strings.Split(relativePath, "/") {
+		if leaf == "" {
+			continue
+		}
+		dir.mu.Lock()
+		node := dir.items[d.vfs.displayName(leaf)]
+		dir.mu.Unlock()
+		subDir, ok := node.(*Dir)
+		if !ok {
+			return nil
+		}
+		dir = subDir
+	}
+	dir.mu.Lock()
+	defer dir.mu.Unlock()
+	if dir.items == nil {
+		return nil
+	}
+	return dir
+}
+
+// cachedNodes returns the nodes in the cached listing of the directory
+func (d *Dir) cachedNodes() (nodes Nodes) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, node := range d.items {
+		nodes = append(nodes, node)
+	}
+	sort.Sort(nodes)
+	return nodes
+}
+
 // walk runs a function on all cached directories whose path matches
 // the given absolute one. It will be called on a directory's children
 // first. It will not apply the function to parent nodes, regardless
@@ -138,7 +241,7 @@ func (d *Dir) rename(newParent *Dir, fsDir fs.Directory) {
 	d.parent = newParent
 	d.entry = fsDir
 	d.path = fsDir.Remote()
-	d.modTime = fsDir.ModTime()
+	d.modTime = d.vfs.dirModTime(fsDir)
 	d.read = time.Time{}
 }
 
@@ -150,6 +253,7 @@ func (d *Dir) addObject(node Node) {
 	if d.items != nil {
 		d.items[node.Name()] = node
 	}
+	d.changed = time.Now()
 	d.mu.Unlock()
 }
 
@@ -159,27 +263,68 @@ func (d *Dir) delObject(leaf string) {
 	if d.items != nil {
 		delete(d.items, leaf)
 	}
+	d.changed = time.Now()
+	d.mu.Unlock()
+}
+
+// addPending adds a file which has been created but not yet uploaded
+// to the directory.  It will be shown in the listings until
+// delPending is called, even if the directory is re-read.
+func (d *Dir) addPending(file *File) {
+	d.mu.Lock()
+	if d.pending == nil {
+		d.pending = make(map[string]*File)
+	}
+	d.pending[file.Name()] = file
+	if d.items != nil {
+		d.items[file.Name()] = file
+	}
+	d.mu.Unlock()
+}
+
+// delPending removes a file added with addPending.  If remove is set
+// then it removes the file from the listing too.
+func (d *Dir) delPending(file *File, remove bool) {
+	d.mu.Lock()
+	leaf := file.Name()
+	if d.pending[leaf] == file {
+		delete(d.pending, leaf)
+		if remove && d.items != nil && d.items[leaf] == file {
+			delete(d.items, leaf)
+		}
+	}
 	d.mu.Unlock()
 }
 
 // read the directory and sets d.items - must be called with the lock held
 func (d *Dir) _readDir() error {
+	if d.virtual {
+		return nil
+	}
 	when := time.Now()
 	if d.read.IsZero() || d.items == nil {
 		// fs.Debugf(d.path, "Reading directory")
 	} else {
 		age := when.Sub(d.read)
-		if age < d.vfs.Opt.DirCacheTime {
+		// On eventually consistent remotes the listing may not
+		// show changes made through the VFS for a while yet
+		if age < d.vfs.dirCacheTime() || when.Sub(d.changed) < d.vfs.window {
+			d.vfs.dirCache.touch(d)
+			d.vfs.countDirCache(true)
 			return nil
 		}
 		fs.Debugf(d.path, "Re-reading directory (%v old)", age)
 	}
+	d.vfs.countDirCache(false)
 	entries, err := fs.ListDirSorted(d.f, false, d.path)
+	if err == nil || err == fs.ErrorDirNotFound {
+		d.vfs.markOK()
+	}
 	if err == fs.ErrorDirNotFound {
 		// We treat directory not found as empty because we
 		// create directories on the fly
 	} else if err != nil {
-		return err
+		return d.serveStale(when, err)
 	}
 	// NB when we re-read a directory after its cache has expired
 	// we drop the old files which should lead to correct
@@ -194,15 +339,28 @@ func (d *Dir) _readDir() error {
 		switch item := entry.(type) {
 		case fs.Object:
 			obj := item
-			name := path.Base(obj.Remote())
+			name := d.vfs.leafName(obj.Remote())
+			// "foo" sorts before "foo.rclonelink" so if both
+			// exist show the link under its raw name
+			if _, found := d.items[name]; found && d.vfs.isLinkRemote(obj.Remote()) {
+				rawName := d.vfs.displayName(path.Base(obj.Remote()))
+				fs.Logf(obj, "Symlink clashes with %q so showing it as %q", name, rawName)
+				name = rawName
+			}
 			d.items[name] = newFile(d, obj, name)
 		case fs.Directory:
 			dir := item
-			name := path.Base(dir.Remote())
+			name := d.vfs.displayName(path.Base(dir.Remote()))
 			// Use old dir value if it exists
 			if oldItems != nil {
 				if oldNode, ok := oldItems[name]; ok {
-					if oldNode.IsDir() {
+					if oldDir, ok := oldNode.(*Dir); ok {
+						// Keep the modification time up to date with the remote
+						if !oldDir.virtual {
+							oldDir.mu.Lock()
+							oldDir.modTime = d.vfs.dirModTime(dir)
+							oldDir.mu.Unlock()
+						}
 						d.items[name] = oldNode
 						continue
 					}
@@ -215,7 +373,94 @@ func (d *Dir) _readDir() error {
 			return err
 		}
 	}
+	// Add any files which are being created
+	for name, file := range d.pending {
+		d.items[name] = file
+	}
+	// Add the control directory to the root if required
+	if d.parent == nil && d.vfs.control != nil {
+		d.items[controlDirName] = d.vfs.control.dir
+	}
 	d.read = when
+	d.vfs.dirCache.touch(d)
+	return nil
+}
+
+// staleRetryInterval is how long to wait before re-reading a
+// directory in the background if it failed to read with
+// --vfs-serve-stale-on-error
+var staleRetryInterval = 10 * time.Second
+
+// serveStale is called when re-reading the directory at when failed
+// with err.
+//
+// If --vfs-serve-stale-on-error is set and the directory has been
+// read before then it carries on using the old listing, schedules a
+// re-read in the background and returns nil.  Otherwise it returns
+// err.
+//
+// Call with d.mu held
+func (d *Dir) serveStale(when time.Time, err error) error {
+	if !d.vfs.Opt.ServeStale || d.read.IsZero() || d.items == nil {
+		return err
+	}
+	fs.Errorf(d, "Serving stale directory listing as re-read failed: %v", err)
+	// Mark the listing as fresh until the retry so we don't keep
+	// trying the remote in the foreground
+	d.read = when.Add(staleRetryInterval - d.vfs.dirCacheTime())
+	if !d.reread {
+		d.reread = true
+		time.AfterFunc(staleRetryInterval, d.retryReadDir)
+	}
+	return nil
+}
+
+// retryReadDir re-reads the directory in the background after a
+// failure
+func (d *Dir) retryReadDir() {
+	defer d.vfs.dirCache.trim()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reread = false
+	if d.read.IsZero() || d.items == nil {
+		// directory was forgotten so will be read next time
+		return
+	}
+	// Expire the listing and re-read it
+	d.read = time.Now().Add(-d.vfs.dirCacheTime())
+	err := d._readDir()
+	if err != nil {
+		fs.Errorf(d, "Background re-read failed: %v", err)
+		return
+	}
+	if !d.reread {
+		fs.Infof(d, "Background re-read succeeded")
+	}
+}
+
+// warm reads the directory and its subdirectories down to depth levels
+// into the cache - see VFS.WarmCache
+func (d *Dir) warm(ctx context.Context, depth int) error {
+	if depth == 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	nodes, err := d.ReadDirAll()
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if dir, ok := node.(*Dir); ok && !dir.virtual {
+			err = dir.warm(ctx, depth-1)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -223,6 +468,7 @@ func (d *Dir) _readDir() error {
 //
 // returns ENOENT if not found.
 func (d *Dir) stat(leaf string) (Node, error) {
+	defer d.vfs.dirCache.trim()
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	err := d._readDir()
@@ -230,14 +476,40 @@ func (d *Dir) stat(leaf string) (Node, error) {
 		return nil, err
 	}
 	item, ok := d.items[leaf]
+	if !ok && d.vfs.Opt.CaseInsensitive {
+		item, ok = d._statCaseInsensitive(leaf)
+	}
 	if !ok {
 		return nil, ENOENT
 	}
 	return item, nil
 }
 
+// _statCaseInsensitive finds an item whose name matches leaf ignoring
+// case.  If more than one matches then it logs a warning and returns
+// the first in sorted order.
+//
+// Call with d.mu held
+func (d *Dir) _statCaseInsensitive(leaf string) (item Node, ok bool) {
+	var names []string
+	for name := range d.items {
+		if strings.EqualFold(name, leaf) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, false
+	}
+	sort.Strings(names)
+	if len(names) > 1 {
+		fs.Logf(d, "Case insensitive lookup of %q is ambiguous - using %q out of %q", leaf, names[0], names)
+	}
+	return d.items[names[0]], true
+}
+
 // Check to see if a directory is empty
 func (d *Dir) isEmpty() (bool, error) {
+	defer d.vfs.dirCache.trim()
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	err := d._readDir()
@@ -291,6 +563,7 @@ func (d *Dir) Stat(name string) (node Node, err error) {
 // ReadDirAll reads the contents of the directory sorted
 func (d *Dir) ReadDirAll() (items Nodes, err error) {
 	// fs.Debugf(d.path, "Dir.ReadDirAll")
+	defer d.vfs.dirCache.trim()
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	err = d._readDir()
@@ -321,26 +594,109 @@ func (d *Dir) Create(name string) (*File, *WriteFileHandle, error) {
 	if d.vfs.Opt.ReadOnly {
 		return nil, nil, EROFS
 	}
-	path := path.Join(d.path, name)
+	if d.virtual {
+		return nil, nil, EPERM
+	}
+	path := d.remotePath(name)
+	if !fs.Config.Filter.IncludeName(path) {
+		return nil, nil, EPERM
+	}
+	if err := d.checkNameLength(path); err != nil {
+		return nil, nil, err
+	}
 	// fs.Debugf(path, "Dir.Create")
 	src := newCreateInfo(d.f, path)
-	// This gets added to the directory when the file is written
 	file := newFile(d, nil, name)
 	fh, err := newWriteFileHandle(d, file, src)
 	if err != nil {
 		fs.Errorf(d, "Dir.Create error: %v", err)
 		return nil, nil, err
 	}
+	// Show the file in the directory until it has been uploaded
+	d.addPending(file)
 	// fs.Debugf(path, "Dir.Create OK")
 	return file, fh, nil
 }
 
+// CreateExclusive makes a new file called name like Create, but
+// returns EEXIST if it exists already, as for O_CREATE|O_EXCL.
+//
+// The remote is checked as well as the directory listing in case the
+// listing is out of date.  Exclusive creates through this VFS can't
+// race each other, but one by another rclone can still race this.
+func (d *Dir) CreateExclusive(name string) (*File, *WriteFileHandle, error) {
+	d.exclMu.Lock()
+	defer d.exclMu.Unlock()
+	_, err := d.stat(name)
+	if err == nil {
+		return nil, nil, EEXIST
+	} else if err != ENOENT {
+		return nil, nil, err
+	}
+	if !d.virtual {
+		_, err = d.f.NewObject(d.remotePath(name))
+		switch err {
+		case nil, fs.ErrorNotAFile:
+			return nil, nil, EEXIST
+		case fs.ErrorObjectNotFound, fs.ErrorDirNotFound:
+		default:
+			return nil, nil, err
+		}
+	}
+	return d.Create(name)
+}
+
+// Symlink makes a symlink called name pointing to target
+//
+// The symlink is stored on the remote as an object called name with
+// .rclonelink on the end containing target, so it returns ENOSYS
+// unless --vfs-links is set.
+func (d *Dir) Symlink(name, target string) (*File, error) {
+	if d.vfs.Opt.ReadOnly {
+		return nil, EROFS
+	}
+	if d.virtual {
+		return nil, EPERM
+	}
+	if !d.vfs.Opt.LinkFiles {
+		return nil, ENOSYS
+	}
+	if _, err := d.stat(name); err == nil {
+		return nil, EEXIST
+	}
+	remote := d.remotePath(name) + linkSuffix
+	if !fs.Config.Filter.IncludeName(remote) {
+		return nil, EPERM
+	}
+	if err := d.checkNameLength(remote); err != nil {
+		return nil, err
+	}
+	src := fs.NewStaticObjectInfo(remote, time.Now(), int64(len(target)), true, nil, d.f)
+	o, err := d.f.Put(strings.NewReader(target), src)
+	if err != nil {
+		fs.Errorf(d, "Dir.Symlink failed to create symlink: %v", err)
+		return nil, err
+	}
+	file := newFile(d, o, name)
+	d.addObject(file)
+	return file, nil
+}
+
 // Mkdir creates a new directory
 func (d *Dir) Mkdir(name string) (*Dir, error) {
 	if d.vfs.Opt.ReadOnly {
 		return nil, EROFS
 	}
-	path := path.Join(d.path, name)
+	if d.virtual {
+		return nil, EPERM
+	}
+	path := d.remotePath(name)
+	if !fs.Config.Filter.IncludeDirectory(path) {
+		return nil, EPERM
+	}
+	if err := d.checkNameLength(path); err != nil {
+		return nil, err
+	}
 	// fs.Debugf(path, "Dir.Mkdir")
 	err := d.f.Mkdir(path)
 	if err != nil {
@@ -359,6 +715,9 @@ func (d *Dir) Remove() error {
 	if d.vfs.Opt.ReadOnly {
 		return EROFS
 	}
+	if d.virtual {
+		return EPERM
+	}
 	// Check directory is empty first
 	empty, err := d.isEmpty()
 	if err != nil {
@@ -396,7 +755,7 @@ func (d *Dir) RemoveAll() error {
 	for _, node := range nodes {
 		err = node.RemoveAll()
 		if err != nil {
-			fs.Errorf(node.DirEntry(), "Dir.RemoveAll failed to remove: %v", err)
+			fs.Errorf(node, "Dir.RemoveAll failed to remove: %v", err)
 			return err
 		}
 	}
@@ -424,31 +783,269 @@ func (d *Dir) RemoveName(name string) error {
 	return node.Remove()
 }
 
+// rootPathLength returns the length of the path on the remote above
+// the root of f including the "/" after it.  The bucket of bucket
+// based remotes isn't counted as it isn't part of their paths.
+func rootPathLength(f fs.Fs) int {
+	root := strings.Trim(f.Root(), "/")
+	if f.Features().BucketBased {
+		i := strings.IndexByte(root, '/')
+		if i < 0 {
+			return 0
+		}
+		root = root[i+1:]
+	}
+	if root == "" {
+		return 0
+	}
+	return len(root) + 1
+}
+
+// checkNameLength returns ENAMETOOLONG if the name or path of remote
+// are longer than the remote allows
+func (d *Dir) checkNameLength(remote string) error {
+	features := d.f.Features()
+	if max := features.MaxNameLength; max > 0 && len(path.Base(remote)) > max {
+		fs.Errorf(remote, "File name is longer than the remote allows (%d bytes)", max)
+		return ENAMETOOLONG
+	}
+	if max := features.MaxPathLength; max > 0 && d.vfs.rootLen+len(remote) > max {
+		fs.Errorf(remote, "Path is longer than the remote allows (%d bytes)", max)
+		return ENAMETOOLONG
+	}
+	return nil
+}
+
+// checkDirPathLength returns ENAMETOOLONG if moving the directory
+// oldPath to newPath would make the path of anything in it longer
+// than the remote allows.
+//
+// This lists the directory recursively so it is only done if the
+// remote has a limit and the path is getting longer.
+func (d *Dir) checkDirPathLength(oldPath, newPath string) error {
+	max := d.f.Features().MaxPathLength
+	if max <= 0 || len(newPath) <= len(oldPath) {
+		return nil
+	}
+	longest := ""
+	err := fs.WalkCallback(d.f, oldPath, true, -1, func(obj fs.Object, dir fs.Directory) error {
+		remote := ""
+		if obj != nil {
+			remote = obj.Remote()
+		} else {
+			remote = dir.Remote()
+		}
+		if len(remote) > len(longest) {
+			longest = remote
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if longest == "" {
+		return nil
+	}
+	return d.checkNameLength(newPath + longest[len(oldPath):])
+}
+
+// existingObject returns the object at remote or nil if there isn't
+// one
+func (d *Dir) existingObject(remote string) (fs.Object, error) {
+	o, err := d.f.NewObject(remote)
+	switch err {
+	case nil:
+		return o, nil
+	case fs.ErrorObjectNotFound, fs.ErrorDirNotFound:
+		return nil, nil
+	}
+	return nil, err
+}
+
+// moveObject moves oldObject to newPath returning the new object.
+//
+// This uses the server side Move if the remote has one.  If it
+// doesn't (or the Move can't be done) then it falls back to copying
+// the object then deleting the original.  This isn't atomic, so the
+// copy is checked before the original is deleted.  If the original
+// can't be deleted the copy is removed again, unless it replaced an
+// existing object in which case it is left in place as the data it
+// overwrote is gone.
+func (d *Dir) moveObject(oldObject fs.Object, newPath string) (fs.Object, error) {
+	if doMove := d.f.Features().Move; doMove != nil {
+		newObject, err := doMove(oldObject, newPath)
+		if err != fs.ErrorCantMove {
+			return newObject, err
+		}
+	}
+	if strings.EqualFold(oldObject.Remote(), newPath) {
+		// On a case insensitive remote deleting the original
+		// would delete the copy
+		return nil, errors.Errorf("Fs %q can't rename files to a name differing only in case (no Move)", d.f)
+	}
+	fs.Logf(oldObject, "Renaming to %q by copy and delete as the remote can't move files so it isn't atomic", newPath)
+	dst, err := d.existingObject(newPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "rename failed to find destination")
+	}
+	err = fs.Copy(d.f, dst, newPath, oldObject)
+	if err != nil {
+		return nil, errors.Wrap(err, "rename failed to copy")
+	}
+	newObject, err := d.f.NewObject(newPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "rename failed to find copy")
+	}
+	err = oldObject.Remove()
+	if err != nil {
+		if dst != nil {
+			fs.Errorf(newObject, "Leaving copy as the original couldn't be deleted and it replaced an existing file: %v", err)
+		} else {
+			fs.Errorf(newObject, "Removing copy as the original couldn't be deleted: %v", err)
+			removeErr := newObject.Remove()
+			if removeErr != nil {
+				fs.Errorf(newObject, "Failed to remove copy: %v", removeErr)
+			}
+		}
+		return nil, errors.Wrap(err, "rename failed to delete original")
+	}
+	return newObject, nil
+}
+
+// Copy the file oldName in d to newName in destDir with a server side
+// copy, returning the new file.
+//
+// It returns ENOSYS if the remote can't do server side copies or the
+// file hasn't been uploaded yet, and EINVAL if oldName isn't a file.
+func (d *Dir) Copy(oldName, newName string, destDir *Dir) (*File, error) {
+	return d.copy(oldName, newName, destDir)
+}
+
+// Link makes newName in destDir a hard link to the file oldName in d,
+// returning the new file.
+//
+// Remotes can't have two paths referring to the same object, so the
+// link is made with a server side copy.  The new file is a separate
+// file with its own inode number so tools like cp and rsync don't
+// treat the two as the same file, and changes to one of them aren't
+// seen in the other.
+//
+// It returns EPERM if oldName is a directory, EEXIST if newName
+// exists and otherwise the same errors as Copy.
+func (d *Dir) Link(oldName, newName string, destDir *Dir) (*File, error) {
+	if oldNode, err := d.stat(oldName); err == nil && oldNode.IsDir() {
+		return nil, EPERM
+	}
+	if _, err := destDir.stat(newName); err == nil {
+		return nil, EEXIST
+	}
+	return d.copy(oldName, newName, destDir)
+}
+
+// copy the file oldName in d to newName in destDir with a server side
+// copy
+func (d *Dir) copy(oldName, newName string, destDir *Dir) (*File, error) {
+	if d.vfs.Opt.ReadOnly {
+		return nil, EROFS
+	}
+	if d.virtual || destDir.virtual {
+		return nil, EPERM
+	}
+	oldPath := d.remotePath(oldName)
+	newPath := destDir.remotePath(newName)
+	oldNode, err := d.stat(oldName)
+	if err != nil {
+		return nil, err
+	}
+	oldFile, ok := oldNode.(*File)
+	if !ok {
+		return nil, EINVAL
+	}
+	oldObject := oldFile.uploaded()
+	if oldObject == nil {
+		return nil, ENOSYS
+	}
+	if d.f.Features().Copy == nil {
+		return nil, ENOSYS
+	}
+	if d.vfs.isLinkRemote(oldObject.Remote()) {
+		newPath += linkSuffix
+	}
+	if !fs.Config.Filter.IncludeName(newPath) {
+		return nil, EPERM
+	}
+	if err := d.checkNameLength(newPath); err != nil {
+		return nil, err
+	}
+	dst, err := destDir.existingObject(newPath)
+	if err != nil {
+		fs.Errorf(oldPath, "Dir.Copy error: %v", err)
+		return nil, err
+	}
+	err = fs.Copy(d.f, dst, newPath, oldObject)
+	if err != nil {
+		fs.Errorf(oldPath, "Dir.Copy error: %v", err)
+		return nil, err
+	}
+	newObject, err := d.f.NewObject(newPath)
+	if err != nil {
+		fs.Errorf(oldPath, "Dir.Copy error: %v", err)
+		return nil, err
+	}
+	newFile := newFile(destDir, newObject, newName)
+	destDir.addObject(newFile)
+	return newFile, nil
+}
+
 // Rename the file
 func (d *Dir) Rename(oldName, newName string, destDir *Dir) error {
 	if d.vfs.Opt.ReadOnly {
 		return EROFS
 	}
-	oldPath := path.Join(d.path, oldName)
-	newPath := path.Join(destDir.path, newName)
+	if d.virtual || destDir.virtual {
+		return EPERM
+	}
+	oldPath := d.remotePath(oldName)
+	newPath := destDir.remotePath(newName)
 	// fs.Debugf(oldPath, "Dir.Rename to %q", newPath)
 	oldNode, err := d.stat(oldName)
 	if err != nil {
 		fs.Errorf(oldPath, "Dir.Rename error: %v", err)
 		return err
 	}
+	if oldDir, ok := oldNode.(*Dir); ok && oldDir.virtual {
+		return EPERM
+	}
+	// Don't rename to names the filters would hide
+	if oldFile, ok := oldNode.(*File); ok {
+		newRemote := newPath
+		if oldFile.isLinkFile() {
+			newRemote += linkSuffix
+		}
+		if !fs.Config.Filter.IncludeName(newRemote) {
+			return EPERM
+		}
+		if err := d.checkNameLength(newRemote); err != nil {
+			return err
+		}
+	} else if !fs.Config.Filter.IncludeDirectory(newPath) {
+		return EPERM
+	} else if err := d.checkNameLength(newPath); err != nil {
+		return err
+	} else if err := d.checkDirPathLength(oldPath, newPath); err != nil {
+		return err
+	}
+	if oldFile, ok := oldNode.(*File); ok && oldFile.renameWriting(destDir, newName) {
+		fs.Debugf(oldPath, "Dir.Rename to %q will be done when the upload finishes", newPath)
+		return nil
+	}
 	switch x := oldNode.DirEntry().(type) {
 	case fs.Object:
 		oldObject := x
-		// FIXME: could Copy then Delete if Move not available
-		// - though care needed if case insensitive...
-		doMove := d.f.Features().Move
-		if doMove == nil {
-			err := errors.Errorf("Fs %q can't rename files (no Move)", d.f)
-			fs.Errorf(oldPath, "Dir.Rename error: %v", err)
-			return err
+		if d.vfs.isLinkRemote(oldObject.Remote()) {
+			newPath += linkSuffix
 		}
-		newObject, err := doMove(oldObject, newPath)
+		newObject, err := d.moveObject(oldObject, newPath)
 		if err != nil {
 			fs.Errorf(oldPath, "Dir.Rename error: %v", err)
 			return err
@@ -0,0 +1,107 @@
+// Limit the number of directory listings cached
+
+package vfs
+
+import (
+	"container/list"
+	"sync"
+)
+
+// dirCache tracks the directories with cached listings in least
+// recently used order so the oldest can be forgotten when there are
+// more than --dir-cache-max-entries of them.
+//
+// Directories call touch and remove with their own lock held, so
+// dirCache never takes a directory's lock while holding its own.
+type dirCache struct {
+	max   int
+	mu    sync.Mutex             // protects the following
+	lru   *list.List             // of *Dir, most recently used at the front
+	items map[*Dir]*list.Element // elements of lru by *Dir
+}
+
+// newDirCache makes a dirCache allowing max listings, returning nil
+// if max is 0 or less meaning there is no limit
+func newDirCache(max int) *dirCache {
+	if max <= 0 {
+		return nil
+	}
+	return &dirCache{
+		max:   max,
+		lru:   list.New(),
+		items: make(map[*Dir]*list.Element),
+	}
+}
+
+// touch records the listing of d as cached and recently used
+//
+// It is safe to call on a nil dirCache.
+func (c *dirCache) touch(d *Dir) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[d]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.items[d] = c.lru.PushFront(d)
+}
+
+// remove records the listing of d as no longer cached
+//
+// It is safe to call on a nil dirCache.
+func (c *dirCache) remove(d *Dir) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[d]; ok {
+		c.lru.Remove(e)
+		delete(c.items, d)
+	}
+}
+
+// trim forgets the least recently used listings until there are no
+// more than max cached.  Listings which can't be forgotten are
+// skipped, so there may still be more than max afterwards.
+//
+// It must be called without any directory locks held.  It is safe to
+// call on a nil dirCache.
+func (c *dirCache) trim() {
+	if c == nil {
+		return
+	}
+	tried := make(map[*Dir]struct{})
+	for {
+		var victim *Dir
+		c.mu.Lock()
+		if c.lru.Len() > c.max {
+			for e := c.lru.Back(); e != nil; e = e.Prev() {
+				candidate := e.Value.(*Dir)
+				if _, ok := tried[candidate]; !ok {
+					victim = candidate
+					break
+				}
+			}
+		}
+		c.mu.Unlock()
+		if victim == nil {
+			return
+		}
+		tried[victim] = struct{}{}
+		victim.evict()
+	}
+}
+
+// count returns the number of listings cached
+func (c *dirCache) count() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
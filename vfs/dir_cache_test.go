@@ -0,0 +1,61 @@
+package vfs
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirCacheMax(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	const n = 20
+	for i := 0; i < n; i++ {
+		r.WriteObject(fmt.Sprintf("dir%d/file", i), "contents", t1)
+	}
+	opt := DefaultOpt
+	opt.DirCacheMax = 5
+	vfs := New(r.Fremote, &opt)
+
+	// Keep a file open in the first directory
+	fd, err := vfs.OpenFile("dir0/file", os.O_RDONLY, 0)
+	require.NoError(t, err)
+
+	// Read all the directories
+	for i := 0; i < n; i++ {
+		_, err := vfs.Stat(fmt.Sprintf("dir%d/file", i))
+		require.NoError(t, err)
+		assert.True(t, vfs.dirCache.count() <= opt.DirCacheMax, "too many cached after dir%d: %d", i, vfs.dirCache.count())
+	}
+
+	// The root, the directory with the open file and the most
+	// recently used directories should still be cached
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	assert.NotNil(t, root.cachedDir(""))
+	assert.NotNil(t, root.cachedDir("dir0"))
+	assert.NotNil(t, root.cachedDir(fmt.Sprintf("dir%d", n-1)))
+	assert.Nil(t, root.cachedDir("dir1"))
+
+	// Once the file is closed its directory can be forgotten
+	require.NoError(t, fd.Close())
+	for i := 1; i < n; i++ {
+		_, err := vfs.Stat(fmt.Sprintf("dir%d/file", i))
+		require.NoError(t, err)
+	}
+	assert.Nil(t, root.cachedDir("dir0"))
+	assert.True(t, vfs.dirCache.count() <= opt.DirCacheMax)
+
+	// Forgotten directories are read again when needed
+	node, err := vfs.Stat("dir0/file")
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), node.Size())
+
+	// Forgetting everything empties the cache
+	root.ForgetAll()
+	assert.Equal(t, 0, vfs.dirCache.count())
+}
@@ -2,14 +2,21 @@ package vfs
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ncw/rclone/fs"
 	"github.com/ncw/rclone/fstest"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
 )
 
 func dirCreate(t *testing.T, r *fstest.Run) (*VFS, *Dir, fstest.Item) {
@@ -184,6 +191,41 @@ func TestDirWalk(t *testing.T) {
 	assert.Equal(t, []string{"fil/a/b"}, result)
 }
 
+func TestDirStatCaseInsensitive(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs := New(r.Fremote, nil)
+
+	file1 := r.WriteObject("dir/File1", "file1 contents", t1)
+	file2 := r.WriteObject("dir/FILE2", "file2 contents!", t2)
+	file3 := r.WriteObject("dir/file2", "file3", t3)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3)
+
+	_, err := vfs.Stat("DIR/file1")
+	assert.Equal(t, ENOENT, err)
+
+	vfs.Opt.CaseInsensitive = true
+	node, err := vfs.Stat("DIR/file1")
+	require.NoError(t, err)
+	assert.Equal(t, "File1", node.Name())
+
+	// Exact matches are preferred
+	node, err = vfs.Stat("dir/file2")
+	require.NoError(t, err)
+	assert.Equal(t, "file2", node.Name())
+	node, err = vfs.Stat("dir/FILE2")
+	require.NoError(t, err)
+	assert.Equal(t, "FILE2", node.Name())
+
+	// Ambiguous matches pick the first sorted name
+	node, err = vfs.Stat("dir/File2")
+	require.NoError(t, err)
+	assert.Equal(t, "FILE2", node.Name())
+
+	_, err = vfs.Stat("dir/potato")
+	assert.Equal(t, ENOENT, err)
+}
+
 func TestDirSetModTime(t *testing.T) {
 	r := fstest.NewRun(t)
 	defer r.Finalise()
@@ -412,6 +454,67 @@ func TestDirRemoveName(t *testing.T) {
 	assert.Equal(t, EROFS, err)
 }
 
+func TestDirSymlink(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs, dir, file1 := dirCreate(t, r)
+	link1 := r.WriteObject("dir/link1.rclonelink", "file1", t1)
+
+	// Without --vfs-links symlinks can't be made and link files
+	// are shown as they are
+	_, err := dir.Symlink("link2", "file1")
+	assert.Equal(t, ENOSYS, err)
+	checkListing(t, dir, []string{"file1,14,false", "link1.rclonelink,5,false"})
+
+	opt := DefaultOpt
+	opt.LinkFiles = true
+	vfs = New(r.Fremote, &opt)
+	node, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	dir = node.(*Dir)
+	checkListing(t, dir, []string{"file1,14,false", "link1,5,false"})
+
+	// Read an existing symlink
+	node, err = vfs.Stat("dir/link1")
+	require.NoError(t, err)
+	link := node.(*File)
+	assert.Equal(t, os.ModeSymlink, link.Mode()&os.ModeSymlink)
+	target, err := link.Readlink()
+	require.NoError(t, err)
+	assert.Equal(t, "file1", target)
+
+	// Make a new one
+	_, err = dir.Symlink("link1", "potato")
+	assert.Equal(t, EEXIST, err)
+	link, err = dir.Symlink("link2", "../sub/file3")
+	require.NoError(t, err)
+	assert.Equal(t, "link2", link.Name())
+	assert.Equal(t, os.ModeSymlink, link.Mode()&os.ModeSymlink)
+	target, err = link.Readlink()
+	require.NoError(t, err)
+	assert.Equal(t, "../sub/file3", target)
+	checkListing(t, dir, []string{"file1,14,false", "link1,5,false", "link2,12,false"})
+
+	// Rename it
+	require.NoError(t, vfs.Rename("dir/link2", "dir/link3"))
+	node, err = vfs.Stat("dir/link3")
+	require.NoError(t, err)
+	target, err = node.(*File).Readlink()
+	require.NoError(t, err)
+	assert.Equal(t, "../sub/file3", target)
+
+	link3 := fstest.NewItem("dir/link3.rclonelink", "../sub/file3", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, link1, link3}, []string{"dir"}, fs.ModTimeNotSupported)
+
+	// A link clashing with a file is shown under its raw name
+	r.WriteObject("dir/file1.rclonelink", "link1", t1)
+	dir.ForgetAll()
+	checkListing(t, dir, []string{"file1,14,false", "file1.rclonelink,5,false", "link1,5,false", "link3,12,false"})
+	node, err = vfs.Stat("dir/file1")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0), node.Mode()&os.ModeSymlink)
+}
+
 func TestDirRename(t *testing.T) {
 	r := fstest.NewRun(t)
 	defer r.Finalise()
@@ -453,3 +556,484 @@ func TestDirRename(t *testing.T) {
 	err = dir.Rename("potato", "tuba", dir)
 	assert.Equal(t, EROFS, err)
 }
+
+// noMoveFs is an fs.Fs which can't Move files
+type noMoveFs struct {
+	fs.Fs
+}
+
+// Features returns the optional features without Move
+func (f *noMoveFs) Features() *fs.Features {
+	features := *f.Fs.Features()
+	features.Move = nil
+	return &features
+}
+
+func TestDirRenameCopyDelete(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	r.WriteObject("dir2/file2", "file2 contents", t2)
+	vfs := New(&noMoveFs{Fs: r.Fremote}, nil)
+
+	node, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	dir := node.(*Dir)
+	node, err = vfs.Stat("dir2")
+	require.NoError(t, err)
+	dir2 := node.(*Dir)
+
+	// Rename within the directory
+	err = dir.Rename("file1", "file3", dir)
+	require.NoError(t, err)
+	checkListing(t, dir, []string{"file3,14,false"})
+	file1.Path = "dir/file3"
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, fstest.NewItem("dir2/file2", "file2 contents", t2)}, []string{"dir", "dir2"}, r.Fremote.Precision())
+
+	// Rename to a different directory
+	err = dir.Rename("file3", "file1", dir2)
+	require.NoError(t, err)
+	checkListing(t, dir, []string(nil))
+	checkListing(t, dir2, []string{"file1,14,false", "file2,14,false"})
+	file1.Path = "dir2/file1"
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, fstest.NewItem("dir2/file2", "file2 contents", t2)}, []string{"dir", "dir2"}, r.Fremote.Precision())
+
+	// Check the node was updated
+	node, err = vfs.Stat("dir2/file1")
+	require.NoError(t, err)
+	assert.Equal(t, "dir2/file1", node.DirEntry().Remote())
+	fd, err := node.Open(os.O_RDONLY)
+	require.NoError(t, err)
+	buf := make([]byte, 14)
+	_, err = fd.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "file1 contents", string(buf))
+	require.NoError(t, fd.Close())
+
+	// Renaming to a name only differing in case isn't allowed
+	err = dir2.Rename("file1", "FILE1", dir2)
+	assert.Error(t, err)
+
+	// Rename over an existing file replaces it
+	err = dir2.Rename("file1", "file2", dir2)
+	require.NoError(t, err)
+	checkListing(t, dir2, []string{"file2,14,false"})
+	file1.Path = "dir2/file2"
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1}, []string{"dir", "dir2"}, r.Fremote.Precision())
+}
+
+// failFs is an fs.Fs whose listings can be made to fail
+type failFs struct {
+	fs.Fs
+	mu   sync.Mutex
+	fail bool
+}
+
+func (f *failFs) setFail(fail bool) {
+	f.mu.Lock()
+	f.fail = fail
+	f.mu.Unlock()
+}
+
+func (f *failFs) List(dir string) (entries fs.DirEntries, err error) {
+	f.mu.Lock()
+	fail := f.fail
+	f.mu.Unlock()
+	if fail {
+		return nil, errors.New("remote unavailable")
+	}
+	return f.Fs.List(dir)
+}
+
+func TestDirServeStale(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	oldStaleRetryInterval := staleRetryInterval
+	staleRetryInterval = 100 * time.Millisecond
+	defer func() { staleRetryInterval = oldStaleRetryInterval }()
+
+	f := &failFs{Fs: r.Fremote}
+	opt := DefaultOpt
+	opt.DirCacheTime = 0
+	opt.ServeStale = true
+	vfs := New(f, &opt)
+
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+	node, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	dir := node.(*Dir)
+	checkListing(t, dir, []string{"file1,14,false"})
+
+	// Make the remote fail and check the old listing is served
+	f.setFail(true)
+	checkListing(t, dir, []string{"file1,14,false"})
+	node, err = vfs.Stat("dir/file1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), node.Size())
+
+	// Fix the remote and check the listing is re-read in the background
+	file2 := r.WriteObject("dir/file2", "file2 contents!", t2)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+	f.setFail(false)
+	time.Sleep(3 * staleRetryInterval)
+	dir.mu.Lock()
+	_, found := dir.items["file2"]
+	dir.mu.Unlock()
+	assert.True(t, found)
+	checkListing(t, dir, []string{"file1,14,false", "file2,15,false"})
+
+	// Check that without the option the error is returned
+	vfs.Opt.ServeStale = false
+	f.setFail(true)
+	_, err = dir.ReadDirAll()
+	assert.EqualError(t, err, "remote unavailable")
+
+	// Check a directory that was never read returns the error
+	vfs.Opt.ServeStale = true
+	newVFS := New(f, &opt)
+	root, err := newVFS.Root()
+	require.NoError(t, err)
+	_, err = root.ReadDirAll()
+	assert.EqualError(t, err, "remote unavailable")
+}
+
+func TestDirWarmCache(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	f := &failFs{Fs: r.Fremote}
+	vfs := New(f, nil)
+
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	file2 := r.WriteObject("dir/sub/file2", "file2 contents!", t2)
+	file3 := r.WriteObject("dir/sub/deeper/file3", "file3", t3)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3)
+
+	require.NoError(t, vfs.WarmCache(context.Background(), "dir", 2))
+
+	// Check the warmed directories can be read without the remote
+	f.setFail(true)
+	node, err := vfs.Stat("dir/sub")
+	require.NoError(t, err)
+	checkListing(t, node.(*Dir), []string{"deeper,0,true", "file2,15,false"})
+	node, err = vfs.Stat("dir/sub/deeper")
+	require.NoError(t, err)
+	_, err = node.(*Dir).ReadDirAll()
+	assert.EqualError(t, err, "remote unavailable")
+
+	// Check a negative depth reads everything
+	f.setFail(false)
+	require.NoError(t, vfs.WarmCache(context.Background(), "", -1))
+	f.setFail(true)
+	checkListing(t, node.(*Dir), []string{"file3,5,false"})
+
+	// Check errors
+	err = vfs.WarmCache(context.Background(), "dir/file1", 1)
+	assert.EqualError(t, err, `"dir/file1" is not a directory`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, vfs.WarmCache(ctx, "", -1))
+}
+
+// countFs is an fs.Fs which counts the calls made to the remote
+type countFs struct {
+	fs.Fs
+	lists      int32
+	newObjects int32
+}
+
+func (f *countFs) List(dir string) (entries fs.DirEntries, err error) {
+	atomic.AddInt32(&f.lists, 1)
+	return f.Fs.List(dir)
+}
+
+func (f *countFs) NewObject(remote string) (fs.Object, error) {
+	atomic.AddInt32(&f.newObjects, 1)
+	return f.Fs.NewObject(remote)
+}
+
+func TestDirReadDirPlus(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	file2 := r.WriteObject("dir/file2", "file2 contents!", t2)
+	file3 := r.WriteObject("dir/sub/file3", "file3", t3)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3)
+
+	f := &countFs{Fs: r.Fremote}
+	vfs := New(f, nil)
+	node, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	dir := node.(*Dir)
+	items, err := dir.ReadDirAll()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(items))
+	lists := atomic.LoadInt32(&f.lists)
+
+	// Stat each entry and read its attributes - these should all
+	// come from the listing without calling the remote
+	want := map[string]fstest.Item{"file1": file1, "file2": file2}
+	for _, item := range items {
+		node, err := vfs.Stat("dir/" + item.Name())
+		require.NoError(t, err)
+		assert.Equal(t, item, node)
+		if node.IsDir() {
+			assert.Equal(t, "sub", node.Name())
+			continue
+		}
+		file := want[node.Name()]
+		assert.Equal(t, file.Size, node.Size(), node.Name())
+		assert.WithinDuration(t, file.ModTime, node.ModTime(), r.Fremote.Precision(), node.Name())
+		assert.Equal(t, vfs.Opt.FilePerms, node.Mode(), node.Name())
+	}
+	assert.Equal(t, lists, atomic.LoadInt32(&f.lists))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&f.newObjects))
+}
+
+// copyFs is an fs.Fs which can do server side copies, counting them
+// and the uploads
+type copyFs struct {
+	fs.Fs
+	copies int32
+	puts   int32
+}
+
+// Features returns the optional features with Copy
+func (f *copyFs) Features() *fs.Features {
+	features := *f.Fs.Features()
+	features.Copy = f.copy
+	return &features
+}
+
+// copy copies src to remote on the underlying remote
+func (f *copyFs) copy(src fs.Object, remote string) (fs.Object, error) {
+	atomic.AddInt32(&f.copies, 1)
+	err := fs.Copy(f.Fs, nil, remote, src)
+	if err != nil {
+		return nil, err
+	}
+	return f.Fs.NewObject(remote)
+}
+
+// Put counts the uploads
+func (f *copyFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	atomic.AddInt32(&f.puts, 1)
+	return f.Fs.Put(in, src, options...)
+}
+
+func TestDirCopy(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	f := &copyFs{Fs: r.Fremote}
+	vfs := New(f, nil)
+	node, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	dir := node.(*Dir)
+	root, err := vfs.Root()
+	require.NoError(t, err)
+
+	// Copy the file to another directory with a server side copy
+	file, err := dir.Copy("file1", "file2", root)
+	require.NoError(t, err)
+	assert.Equal(t, "file2", file.Name())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&f.copies))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&f.puts))
+	checkListing(t, root, []string{"dir,0,true", "file2,14,false"})
+	file2 := fstest.NewItem("file2", "file1 contents", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2}, []string{"dir"}, fs.ModTimeNotSupported)
+
+	// Copying over an existing file replaces it
+	r.WriteObject("dir/file3", "file3 contents longer", t2)
+	_, err = root.Copy("file2", "file3", dir)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&f.copies))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&f.puts))
+	file3 := fstest.NewItem("dir/file3", "file1 contents", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2, file3}, []string{"dir"}, fs.ModTimeNotSupported)
+
+	// Copying directories isn't allowed
+	_, err = root.Copy("dir", "dir2", root)
+	assert.Equal(t, EINVAL, err)
+
+	// Files being written can't be copied
+	_, fd, err := dir.Create("file4")
+	require.NoError(t, err)
+	_, err = dir.Copy("file4", "file5", dir)
+	assert.Equal(t, ENOSYS, err)
+	require.NoError(t, fd.Close())
+
+	// Remotes which can't copy return ENOSYS
+	vfs = New(r.Fremote, nil)
+	err = vfs.Copy("dir/file1", "file5")
+	assert.Equal(t, ENOSYS, err)
+
+	vfs.Opt.ReadOnly = true
+	err = vfs.Copy("dir/file1", "file5")
+	assert.Equal(t, EROFS, err)
+}
+
+func TestDirLink(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	f := &copyFs{Fs: r.Fremote}
+	vfs := New(f, nil)
+	node, err := vfs.Stat("dir/file1")
+	require.NoError(t, err)
+	oldFile := node.(*File)
+
+	// Link the file into another directory making a separate copy
+	err = vfs.Link("dir/file1", "file2")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&f.copies))
+	node, err = vfs.Stat("file2")
+	require.NoError(t, err)
+	newFile := node.(*File)
+	assert.NotEqual(t, oldFile.Inode(), newFile.Inode())
+
+	// Check both paths have the same contents
+	for _, name := range []string{"dir/file1", "file2"} {
+		fd, err := vfs.OpenFile(name, os.O_RDONLY, 0)
+		require.NoError(t, err)
+		contents, err := ioutil.ReadAll(fd)
+		require.NoError(t, err)
+		require.NoError(t, fd.Close())
+		assert.Equal(t, "file1 contents", string(contents), name)
+	}
+	file2 := fstest.NewItem("file2", "file1 contents", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2}, []string{"dir"}, fs.ModTimeNotSupported)
+
+	// Linking to an existing name isn't allowed
+	err = vfs.Link("dir/file1", "file2")
+	assert.Equal(t, EEXIST, err)
+
+	// Neither is linking directories
+	err = vfs.Link("dir", "dir2")
+	assert.Equal(t, EPERM, err)
+
+	// Remotes which can't copy return ENOSYS
+	vfs = New(r.Fremote, nil)
+	err = vfs.Link("dir/file1", "file3")
+	assert.Equal(t, ENOSYS, err)
+}
+
+// laggyFs is an fs.Fs whose listings leave out the objects in hide,
+// as an eventually consistent remote might straight after they are
+// written
+type laggyFs struct {
+	fs.Fs
+	consistent bool
+	hide       map[string]bool
+}
+
+func (f *laggyFs) List(dir string) (entries fs.DirEntries, err error) {
+	entries, err = f.Fs.List(dir)
+	var out fs.DirEntries
+	for _, entry := range entries {
+		if !f.hide[entry.Remote()] {
+			out = append(out, entry)
+		}
+	}
+	return out, err
+}
+
+func (f *laggyFs) Features() *fs.Features {
+	features := *f.Fs.Features()
+	features.EventuallyConsistent = !f.consistent
+	return &features
+}
+
+func TestDirConsistencyWindow(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	opt := DefaultOpt
+	opt.DirCacheTime = 0
+	opt.Consistency = 100 * time.Millisecond
+
+	// write writes file1 through a new VFS on f and checks whether
+	// it can be found straight away
+	write := func(f *laggyFs) (*VFS, error) {
+		vfs := New(f, &opt)
+		h, err := vfs.OpenFile("file1", os.O_WRONLY|os.O_CREATE, 0777)
+		require.NoError(t, err)
+		_, err = h.Write([]byte("hello"))
+		require.NoError(t, err)
+		require.NoError(t, h.Close())
+		f.hide["file1"] = true
+		_, err = vfs.Stat("file1")
+		return vfs, err
+	}
+
+	// On an eventually consistent remote the file is found and read
+	// until the window has passed
+	vfs, err := write(&laggyFs{Fs: r.Fremote, hide: map[string]bool{}})
+	require.NoError(t, err)
+	fd, err := vfs.OpenFile("file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = fd.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+	require.NoError(t, fd.Close())
+	time.Sleep(2 * opt.Consistency)
+	_, err = vfs.Stat("file1")
+	assert.Equal(t, ENOENT, err)
+
+	// Other remotes are re-listed straight away
+	_, err = write(&laggyFs{Fs: r.Fremote, consistent: true, hide: map[string]bool{}})
+	assert.Equal(t, ENOENT, err)
+}
+
+// dirTimeFs lists directories with modTime as their modification time
+type dirTimeFs struct {
+	fs.Fs
+	modTime time.Time
+}
+
+// List the directory setting the time of the directories in it
+func (f *dirTimeFs) List(dir string) (entries fs.DirEntries, err error) {
+	entries, err = f.Fs.List(dir)
+	for i, entry := range entries {
+		if d, ok := entry.(fs.Directory); ok {
+			entries[i] = fs.NewDir(d.Remote(), f.modTime)
+		}
+	}
+	return entries, err
+}
+
+func TestDirModTimeFromRemote(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	f := &dirTimeFs{Fs: r.Fremote, modTime: t2}
+	vfs := New(f, nil)
+
+	modTime := func() time.Time {
+		node, err := vfs.Stat("dir")
+		require.NoError(t, err)
+		return node.ModTime()
+	}
+
+	// The time comes from the remote
+	assert.Equal(t, t2, modTime())
+
+	// and is updated when the directory is read again
+	f.modTime = t3
+	vfs.root.ForgetAll()
+	assert.Equal(t, t3, modTime())
+
+	// Remotes without directory times use when the VFS started
+	f.modTime = time.Time{}
+	vfs.root.ForgetAll()
+	assert.Equal(t, vfs.started, modTime())
+}
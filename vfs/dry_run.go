@@ -0,0 +1,183 @@
+// Dry run wrapper for the remote
+
+package vfs
+
+import (
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// errDryRunObject is returned when reading a file which was only
+// written in the dry run
+var errDryRunObject = errors.New("can't read file not uploaded as --vfs-dry-run")
+
+// dryRunFs wraps an Fs for --vfs-dry-run.  Anything which would
+// change the remote is logged and not done, but returns success so
+// the changes show up in the VFS until its directory cache expires.
+type dryRunFs struct {
+	fs.Fs
+	features *fs.Features
+}
+
+// newDryRunFs wraps f so it doesn't change the remote
+func newDryRunFs(f fs.Fs) *dryRunFs {
+	d := &dryRunFs{Fs: f}
+	features := *f.Features()
+	// These are provided even if the remote can't do them as
+	// nothing is actually done
+	features.Copy = d.copy
+	features.Move = d.move
+	features.DirMove = d.dirMove
+	features.PutStream = d.Put
+	if features.PutUnchecked != nil {
+		features.PutUnchecked = d.Put
+	}
+	// Disable anything else which changes the remote
+	features.Purge = nil
+	features.MergeDirs = nil
+	features.CleanUp = nil
+	features.AbortMultipartUpload = nil
+	features.RemoveDirMarker = nil
+	features.DirSetModTime = nil
+	features.ListR = nil
+	d.features = &features
+	return d
+}
+
+// Features returns the optional features of the wrapped Fs with the
+// ones which change the remote replaced
+func (d *dryRunFs) Features() *fs.Features {
+	return d.features
+}
+
+// newObject makes a dryRunObject in d from info.  o is the object
+// with the data if there is one.
+func (d *dryRunFs) newObject(info fs.ObjectInfo, o fs.Object) *dryRunObject {
+	return &dryRunObject{
+		ObjectInfo: info,
+		f:          d,
+		o:          o,
+	}
+}
+
+// wrap wraps the objects in entries so they can't be changed
+func (d *dryRunFs) wrap(entries fs.DirEntries) fs.DirEntries {
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = d.newObject(o, o)
+		}
+	}
+	return entries
+}
+
+// List the objects and directories in dir
+func (d *dryRunFs) List(dir string) (entries fs.DirEntries, err error) {
+	entries, err = d.Fs.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	return d.wrap(entries), nil
+}
+
+// NewObject finds the Object at remote
+func (d *dryRunFs) NewObject(remote string) (fs.Object, error) {
+	o, err := d.Fs.NewObject(remote)
+	if err != nil {
+		return nil, err
+	}
+	return d.newObject(o, o), nil
+}
+
+// Put reads in and returns an Object for src without uploading it
+func (d *dryRunFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	fs.Logf(src, "Not uploading as --vfs-dry-run")
+	n, err := io.Copy(ioutil.Discard, in)
+	if err != nil {
+		return nil, err
+	}
+	info := fs.NewStaticObjectInfo(src.Remote(), src.ModTime(), n, true, nil, d)
+	return d.newObject(info, nil), nil
+}
+
+// Mkdir logs the directory which would be made
+func (d *dryRunFs) Mkdir(dir string) error {
+	fs.Logf(dir, "Not making directory as --vfs-dry-run")
+	return nil
+}
+
+// Rmdir logs the directory which would be removed
+func (d *dryRunFs) Rmdir(dir string) error {
+	fs.Logf(dir, "Not removing directory as --vfs-dry-run")
+	return nil
+}
+
+// copy returns an Object for the copy of src without making it
+func (d *dryRunFs) copy(src fs.Object, remote string) (fs.Object, error) {
+	fs.Logf(src, "Not copying to %q as --vfs-dry-run", remote)
+	info := fs.NewStaticObjectInfo(remote, src.ModTime(), src.Size(), true, nil, d)
+	return d.newObject(info, src), nil
+}
+
+// move returns an Object for src moved to remote without moving it
+func (d *dryRunFs) move(src fs.Object, remote string) (fs.Object, error) {
+	fs.Logf(src, "Not moving to %q as --vfs-dry-run", remote)
+	info := fs.NewStaticObjectInfo(remote, src.ModTime(), src.Size(), true, nil, d)
+	return d.newObject(info, src), nil
+}
+
+// dirMove logs the directory which would be moved
+func (d *dryRunFs) dirMove(src fs.Fs, srcRemote, dstRemote string) error {
+	fs.Logf(srcRemote, "Not moving directory to %q as --vfs-dry-run", dstRemote)
+	return nil
+}
+
+// dryRunObject is an Object for --vfs-dry-run which can't be
+// changed.  It is either an object on the remote or one which was
+// only made in the dry run.
+type dryRunObject struct {
+	fs.ObjectInfo
+	f *dryRunFs
+	o fs.Object // object with the data or nil if there isn't one
+}
+
+// Fs returns the dryRunFs the object is in
+func (o *dryRunObject) Fs() fs.Info {
+	return o.f
+}
+
+// Open opens the object for read if it has any data
+func (o *dryRunObject) Open(options ...fs.OpenOption) (io.ReadCloser, error) {
+	if o.o == nil {
+		return nil, errDryRunObject
+	}
+	return o.o.Open(options...)
+}
+
+// SetModTime logs the modification time which would be set
+func (o *dryRunObject) SetModTime(modTime time.Time) error {
+	fs.Logf(o, "Not setting modification time as --vfs-dry-run")
+	return nil
+}
+
+// Update reads in without uploading it
+func (o *dryRunObject) Update(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	fs.Logf(o, "Not updating as --vfs-dry-run")
+	_, err := io.Copy(ioutil.Discard, in)
+	return err
+}
+
+// Remove logs the object which would be removed
+func (o *dryRunObject) Remove() error {
+	fs.Logf(o, "Not deleting as --vfs-dry-run")
+	return nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs     = (*dryRunFs)(nil)
+	_ fs.Object = (*dryRunObject)(nil)
+)
@@ -0,0 +1,77 @@
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSDryRun(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	file2 := r.WriteObject("dir2/file2", "file2 contents", t2)
+	file3 := r.WriteObject("file3", "file3 contents", t3)
+
+	opt := DefaultOpt
+	opt.DryRun = true
+	vfs := New(r.Fremote, &opt)
+
+	// Write a new file
+	fd, err := vfs.OpenFile("dir/new", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = fd.Write([]byte("new contents"))
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+
+	// Overwrite an existing one
+	fd, err = vfs.OpenFile("file3", os.O_WRONLY|os.O_TRUNC, 0777)
+	require.NoError(t, err)
+	_, err = fd.Write([]byte("potato"))
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	_, err = root.Mkdir("newdir")
+	require.NoError(t, err)
+	require.NoError(t, vfs.Rename("dir/file1", "dir/renamed"))
+	require.NoError(t, vfs.Rename("dir2", "dir3"))
+	require.NoError(t, root.RemoveName("file3"))
+	node, err := vfs.Stat("dir/renamed")
+	require.NoError(t, err)
+	require.NoError(t, node.SetModTime(t3))
+
+	// The changes should be visible in the VFS
+	node, err = vfs.Stat("dir/new")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("new contents")), node.Size())
+	_, err = vfs.Stat("newdir")
+	assert.NoError(t, err)
+	_, err = vfs.Stat("dir3")
+	assert.NoError(t, err)
+	_, err = vfs.Stat("dir/file1")
+	assert.Equal(t, os.ErrNotExist, err)
+	_, err = vfs.Stat("file3")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// Renamed files can still be read but new ones can't
+	fd, err = vfs.OpenFile("dir/renamed", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	contents, err := ioutil.ReadAll(fd)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	assert.Equal(t, "file1 contents", string(contents))
+	fd, err = vfs.OpenFile("dir/new", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(fd)
+	assert.Error(t, err)
+	require.NoError(t, fd.Close())
+
+	// Check the remote hasn't changed
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2, file3}, []string{"dir", "dir2"}, r.Fremote.Precision())
+}
@@ -20,6 +20,11 @@ const (
 	EBADF
 	EROFS
 	ENOSYS
+	EINVAL
+	EMFILE
+	ENOTSUP
+	ENOATTR
+	ENAMETOOLONG
 )
 
 // Errors which have exact counterparts in os
@@ -31,12 +36,17 @@ var (
 )
 
 var errorNames = []string{
-	OK:        "Success",
-	ENOTEMPTY: "Directory not empty",
-	ESPIPE:    "Illegal seek",
-	EBADF:     "Bad file descriptor",
-	EROFS:     "Read only file system",
-	ENOSYS:    "Function not implemented",
+	OK:           "Success",
+	ENOTEMPTY:    "Directory not empty",
+	ESPIPE:       "Illegal seek",
+	EBADF:        "Bad file descriptor",
+	EROFS:        "Read only file system",
+	ENOSYS:       "Function not implemented",
+	EINVAL:       "Invalid argument",
+	EMFILE:       "Too many open files",
+	ENOTSUP:      "Operation not supported",
+	ENOATTR:      "No such attribute",
+	ENAMETOOLONG: "File name too long",
 }
 
 // Error renders the error as a string
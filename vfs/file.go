@@ -1,8 +1,10 @@
 package vfs
 
 import (
+	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,14 +15,15 @@ import (
 
 // File represents a file
 type File struct {
-	inode          uint64       // inode number
-	size           int64        // size of file - read and written with atomic int64 - must be 64 bit aligned
-	d              *Dir         // parent directory - read only
-	mu             sync.RWMutex // protects the following
-	o              fs.Object    // NB o may be nil if file is being written
-	leaf           string       // leaf name of the object
-	writers        int          // number of writers for this file
-	pendingModTime time.Time    // will be applied once o becomes available, i.e. after file was written
+	inode          uint64             // inode number
+	size           int64              // size of file - read and written with atomic int64 - must be 64 bit aligned
+	opens          int32              // number of open handles - read and written with atomic
+	d              *Dir               // parent directory - read only
+	mu             sync.RWMutex       // protects the following
+	o              fs.Object          // NB o may be nil if file is being written
+	leaf           string             // leaf name of the object
+	writers        []*WriteFileHandle // open write handles for this file
+	pendingModTime time.Time          // will be applied once o becomes available, i.e. after file was written
 }
 
 // newFile creates a new File
@@ -34,11 +37,14 @@ func newFile(d *Dir, o fs.Object, leaf string) *File {
 }
 
 // String converts it to printable
+//
+// This is the path on the remote, like Dir.String, rather than the
+// name shown with --vfs-name-transform
 func (f *File) String() string {
 	if f == nil {
 		return "<nil *File>"
 	}
-	return path.Join(f.d.path, f.leaf)
+	return f.d.remotePath(f.leaf)
 }
 
 // IsFile returns true for File - satisfies Node interface
@@ -53,7 +59,60 @@ func (f *File) IsDir() bool {
 
 // Mode bits of the file or directory - satisfies Node interface
 func (f *File) Mode() (mode os.FileMode) {
-	return f.d.vfs.Opt.FilePerms
+	mode = f.d.vfs.Opt.FilePerms
+	if f.d.vfs.permRules != nil {
+		mode = f.d.vfs.perms(f.String(), mode)
+	}
+	if f.isLinkFile() {
+		return os.ModeSymlink | mode
+	}
+	return mode
+}
+
+// linkSuffix is the suffix of the objects symlinks are stored in if
+// --vfs-links is set
+const linkSuffix = ".rclonelink"
+
+// isLinkRemote returns true if --vfs-links is set and remote is the
+// name of an object storing a symlink
+func (vfs *VFS) isLinkRemote(remote string) bool {
+	return vfs.Opt.LinkFiles && strings.HasSuffix(remote, linkSuffix) && len(path.Base(remote)) > len(linkSuffix)
+}
+
+// leafName returns the name the object at remote is shown with
+func (vfs *VFS) leafName(remote string) string {
+	leaf := path.Base(remote)
+	if vfs.isLinkRemote(remote) {
+		leaf = leaf[:len(leaf)-len(linkSuffix)]
+	}
+	return vfs.displayName(leaf)
+}
+
+// isLinkFile returns true if the file is a symlink stored in a
+// .rclonelink object
+func (f *File) isLinkFile() bool {
+	f.mu.RLock()
+	o := f.o
+	f.mu.RUnlock()
+	return o != nil && f.d.vfs.isLinkRemote(o.Remote())
+}
+
+// Readlink returns the target of the file if it is a symlink stored
+// with --vfs-links, or EINVAL otherwise
+func (f *File) Readlink() (string, error) {
+	if !f.isLinkFile() {
+		return "", EINVAL
+	}
+	o, err := f.waitForValidObject()
+	if err != nil {
+		return "", err
+	}
+	target, err := readObject(o)
+	if err != nil {
+		fs.Errorf(f, "Failed to read symlink: %v", err)
+		return "", err
+	}
+	return string(target), nil
 }
 
 // Name (base) of the directory - satisfies Node interface
@@ -81,15 +140,74 @@ func (f *File) rename(d *Dir, o fs.Object) {
 	f.mu.Lock()
 	f.o = o
 	f.d = d
-	f.leaf = path.Base(o.Remote())
+	f.leaf = d.vfs.leafName(o.Remote())
 	f.mu.Unlock()
 }
 
-// addWriters increments or decrements the writers
-func (f *File) addWriters(n int) {
+// renameWriting renames the file to newName in destDir if it is being
+// written, returning true if it did.  The object is uploaded under the
+// old name then moved to the new name once the upload has finished by
+// setObject.
+//
+// This means the common pattern of writing to a temporary file then
+// renaming it over the target only uploads the data once.
+func (f *File) renameWriting(destDir *Dir, newName string) bool {
 	f.mu.Lock()
-	f.writers += n
+	defer f.mu.Unlock()
+	if f.o != nil && len(f.writers) == 0 {
+		return false
+	}
+	f.d.delPending(f, true)
+	f.d.delObject(f.leaf)
+	f.d = destDir
+	f.leaf = newName
+	destDir.addPending(f)
+	return true
+}
+
+// uploaded returns the object for the file or nil if it is still
+// being written
+func (f *File) uploaded() fs.Object {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.writers) != 0 {
+		return nil
+	}
+	return f.o
+}
+
+// addWriter records fh as open for write on the file
+func (f *File) addWriter(fh *WriteFileHandle) {
+	f.mu.Lock()
+	f.writers = append(f.writers, fh)
 	f.mu.Unlock()
+	atomic.AddInt32(&f.opens, 1)
+}
+
+// delWriter records fh as no longer open for write on the file
+func (f *File) delWriter(fh *WriteFileHandle) {
+	f.mu.Lock()
+	for i, writer := range f.writers {
+		if writer == fh {
+			f.writers = append(f.writers[:i], f.writers[i+1:]...)
+			break
+		}
+	}
+	f.mu.Unlock()
+	atomic.AddInt32(&f.opens, -1)
+}
+
+// addReaders increments or decrements the number of open read handles
+func (f *File) addReaders(n int) {
+	atomic.AddInt32(&f.opens, int32(n))
+}
+
+// isOpen returns true if the file has any open handles
+//
+// This doesn't take the lock so can be called with the directory
+// lock held
+func (f *File) isOpen() bool {
+	return atomic.LoadInt32(&f.opens) != 0
 }
 
 // ModTime returns the modified time of the file
@@ -101,7 +219,7 @@ func (f *File) ModTime() (modTime time.Time) {
 
 	if !f.d.vfs.Opt.NoModTime {
 		// if o is nil it isn't valid yet or there are writers, so return the size so far
-		if f.o == nil || f.writers != 0 {
+		if f.o == nil || len(f.writers) != 0 {
 			if !f.pendingModTime.IsZero() {
 				return f.pendingModTime
 			}
@@ -119,7 +237,7 @@ func (f *File) Size() int64 {
 	defer f.mu.Unlock()
 
 	// if o is nil it isn't valid yet or there are writers, so return the size so far
-	if f.o == nil || f.writers != 0 {
+	if f.o == nil || len(f.writers) != 0 {
 		return atomic.LoadInt64(&f.size)
 	}
 	return f.o.Size()
@@ -135,7 +253,9 @@ func (f *File) SetModTime(modTime time.Time) error {
 
 	f.pendingModTime = modTime
 
-	if f.o != nil {
+	// Only set the object now if it isn't being written, otherwise
+	// the upload would replace the modtime when it finished
+	if f.o != nil && len(f.writers) == 0 {
 		return f.applyPendingModTime()
 	}
 
@@ -161,6 +281,7 @@ func (f *File) applyPendingModTime() error {
 		fs.Debugf(f.o, "File.applyPendingModTime OK")
 	case fs.ErrorCantSetModTime, fs.ErrorCantSetModTimeWithoutDelete:
 		// do nothing, in order to not break "touch somefile" if it exists already
+		fs.Debugf(f.o, "File.applyPendingModTime skipped: %v", err)
 	default:
 		fs.Errorf(f, "File.applyPendingModTime error: %v", err)
 		return err
@@ -178,9 +299,41 @@ func (f *File) setSize(n int64) {
 func (f *File) setObject(o fs.Object) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	// If the file was renamed while it was being written then move
+	// it to its new name now it has been uploaded
+	if remote := f.d.remotePath(f.leaf); o.Remote() != remote {
+		newObject, err := f.moveUploaded(o, remote)
+		if err != nil {
+			fs.Errorf(o, "Failed to rename to %q after upload: %v", remote, err)
+		} else {
+			o = newObject
+		}
+	}
 	f.o = o
 	_ = f.applyPendingModTime()
 	f.d.addObject(f)
+	f.d.delPending(f, false)
+}
+
+// moveUploaded moves the uploaded object o to remote using a server
+// side move
+func (f *File) moveUploaded(o fs.Object, remote string) (fs.Object, error) {
+	doMove := f.d.f.Features().Move
+	if doMove == nil {
+		return nil, errors.Errorf("Fs %q can't rename files (no Move)", f.d.f)
+	}
+	fs.Debugf(o, "Moving to %q after upload", remote)
+	return doMove(o, remote)
+}
+
+// writeFailed should be called if the upload of the file failed
+//
+// If the file was never successfully written it is removed from the
+// directory listing.
+func (f *File) writeFailed() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.d.delPending(f, f.o == nil)
 }
 
 // Wait for f.o to become non nil for a short time returning it or an
@@ -191,7 +344,7 @@ func (f *File) waitForValidObject() (o fs.Object, err error) {
 	for i := 0; i < 50; i++ {
 		f.mu.Lock()
 		o = f.o
-		writers := f.writers
+		writers := len(f.writers)
 		f.mu.Unlock()
 		if o != nil {
 			return o, nil
@@ -246,6 +399,84 @@ func (f *File) OpenWrite() (fh *WriteFileHandle, err error) {
 	return fh, nil
 }
 
+// OpenAppend opens the file for write with the write position at the
+// end of the existing contents
+//
+// Objects can't be appended to, so the existing contents are read and
+// written to the start of the new upload.  They are read before the
+// upload starts which means this only works for files no larger than
+// --streaming-upload-cutoff - EPERM is returned for larger files
+// rather than truncating them.
+func (f *File) OpenAppend() (fh *WriteFileHandle, err error) {
+	if f.d.vfs.Opt.ReadOnly {
+		return nil, EROFS
+	}
+	// if o is nil it isn't valid yet
+	o, err := f.waitForValidObject()
+	if err != nil {
+		return nil, err
+	}
+	if cutoff := int64(fs.Config.StreamingUploadCutoff); o.Size() > cutoff {
+		fs.Errorf(f, "Can't open for append as file is larger than --streaming-upload-cutoff %v", fs.Config.StreamingUploadCutoff)
+		return nil, EPERM
+	}
+	contents, err := readObject(o)
+	if err != nil {
+		err = errors.Wrap(err, "open for append")
+		fs.Errorf(f, "File.OpenAppend failed: %v", err)
+		return nil, err
+	}
+	fh, err = f.OpenWrite()
+	if err != nil {
+		return nil, err
+	}
+	f.d.vfs.handles.setFlags(fh, os.O_WRONLY|os.O_APPEND)
+	_, err = fh.Write(contents)
+	if err != nil {
+		_ = fh.Close()
+		fs.Errorf(f, "File.OpenAppend failed to write existing contents: %v", err)
+		return nil, err
+	}
+	return fh, nil
+}
+
+// readObject reads all the contents of o
+func readObject(o fs.Object) (contents []byte, err error) {
+	in, err := o.Open()
+	if err != nil {
+		return nil, err
+	}
+	contents, err = ioutil.ReadAll(in)
+	closeErr := in.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return contents, err
+}
+
+// Truncate changes the size of the file to size.
+//
+// Objects can't be changed in place, so this only works while the file
+// is open for write when the write handle is truncated - see
+// WriteFileHandle.Truncate.  Otherwise EPERM is returned unless size is
+// the current size.
+func (f *File) Truncate(size int64) error {
+	f.mu.RLock()
+	var fh *WriteFileHandle
+	if len(f.writers) != 0 {
+		fh = f.writers[len(f.writers)-1]
+	}
+	f.mu.RUnlock()
+	if fh != nil {
+		return fh.Truncate(size)
+	}
+	if size != f.Size() {
+		fs.Errorf(f, "Can't truncate files which aren't open for write")
+		return EPERM
+	}
+	return nil
+}
+
 // Fsync the file
 //
 // Note that we don't do anything except return OK
@@ -259,17 +490,27 @@ func (f *File) Remove() error {
 		return EROFS
 	}
 	if f.o != nil {
-		err := f.o.Remove()
+		err := f.d.vfs.removeObject(f.o)
 		if err != nil {
 			fs.Errorf(f, "File.Remove file error: %v", err)
 			return err
 		}
 	}
 	// Remove the item from the directory listing
+	f.d.delPending(f, false)
 	f.d.delObject(f.Name())
 	return nil
 }
 
+// removeObject removes o, sending it to the trash instead if
+// --vfs-use-trash is set and the remote has one
+func (vfs *VFS) removeObject(o fs.Object) error {
+	if do, ok := o.(fs.Trasher); ok && vfs.Opt.UseTrash {
+		return do.Trash()
+	}
+	return o.Remove()
+}
+
 // RemoveAll the file - same as remove for files
 func (f *File) RemoveAll() error {
 	return f.Remove()
@@ -292,6 +533,12 @@ func (f *File) VFS() *VFS {
 
 // Open a file according to the flags provided
 func (f *File) Open(flags int) (fd Handle, err error) {
+	f.mu.Lock()
+	c, isControl := f.o.(*control)
+	f.mu.Unlock()
+	if isControl {
+		return newControlHandle(c), nil
+	}
 	rdwrMode := flags & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
 	var read bool
 	switch {
@@ -306,10 +553,19 @@ func (f *File) Open(flags int) (fd Handle, err error) {
 		fs.Errorf(f, "Can't figure out how to open with flags: 0x%X", flags)
 		return nil, EPERM
 	}
-	if read {
+	switch {
+	case read:
 		fd, err = f.OpenRead()
-	} else {
+	case flags&os.O_APPEND != 0 && flags&os.O_TRUNC == 0:
+		fd, err = f.OpenAppend()
+	default:
 		fd, err = f.OpenWrite()
 	}
-	return fd, err
+	if err != nil {
+		return nil, err
+	}
+	if h, ok := fd.(infoHandle); ok {
+		f.d.vfs.handles.setFlags(h, flags)
+	}
+	return fd, nil
 }
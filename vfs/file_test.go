@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/ncw/rclone/fs"
 	"github.com/ncw/rclone/fstest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -88,6 +89,26 @@ func TestFileSetModTime(t *testing.T) {
 	assert.Equal(t, EROFS, err)
 }
 
+func TestFileSetModTimeWhileWriting(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	_, file, _ := fileCreate(t, r)
+
+	// Overwrite the file setting the modtime before it is closed
+	fh, err := file.OpenWrite()
+	require.NoError(t, err)
+	_, err = fh.Write([]byte("new contents"))
+	require.NoError(t, err)
+	require.NoError(t, file.SetModTime(t3))
+	assert.Equal(t, t3, file.ModTime())
+	require.NoError(t, fh.Close())
+
+	// The modtime set should win over the time of the upload
+	file1 := fstest.NewItem("dir/file1", "new contents", t3)
+	fstest.CheckItems(t, r.Fremote, file1)
+	assert.WithinDuration(t, t3, file.ModTime(), r.Fremote.Precision())
+}
+
 func TestFileOpenRead(t *testing.T) {
 	r := fstest.NewRun(t)
 	defer r.Finalise()
@@ -124,6 +145,59 @@ func TestFileOpenWrite(t *testing.T) {
 	assert.Equal(t, EROFS, err)
 }
 
+func TestFileTruncate(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	_, file, _ := fileCreate(t, r)
+
+	// Not open for write so only the current size is allowed
+	require.NoError(t, file.Truncate(14))
+	assert.Equal(t, EPERM, file.Truncate(0))
+
+	// Open for write the handle is truncated
+	fd, err := file.OpenWrite()
+	require.NoError(t, err)
+	require.NoError(t, file.Truncate(0))
+	require.NoError(t, file.Truncate(5))
+	assert.Equal(t, int64(5), fd.Offset())
+	assert.Equal(t, EPERM, file.Truncate(2))
+	require.NoError(t, fd.Close())
+
+	assert.Equal(t, int64(5), file.Size())
+}
+
+func TestFileOpenAppend(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs, file, _ := fileCreate(t, r)
+
+	fd, err := vfs.OpenFile("dir/file1", os.O_WRONLY|os.O_APPEND, 0777)
+	require.NoError(t, err)
+	fh, ok := fd.(*WriteFileHandle)
+	require.True(t, ok)
+	assert.Equal(t, int64(14), fh.Offset())
+
+	n, err := fh.Write([]byte(" and more"))
+	require.NoError(t, err)
+	assert.Equal(t, 9, n)
+	require.NoError(t, fh.Close())
+
+	assert.Equal(t, int64(23), file.Size())
+	file1 := fstest.NewItem("dir/file1", "file1 contents and more", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1}, []string{"dir"}, fs.ModTimeNotSupported)
+
+	// Files too big to buffer can't be appended to
+	oldCutoff := fs.Config.StreamingUploadCutoff
+	fs.Config.StreamingUploadCutoff = 10
+	defer func() { fs.Config.StreamingUploadCutoff = oldCutoff }()
+	_, err = file.Open(os.O_WRONLY | os.O_APPEND)
+	assert.Equal(t, EPERM, err)
+
+	vfs.Opt.ReadOnly = true
+	_, err = file.OpenAppend()
+	assert.Equal(t, EROFS, err)
+}
+
 func TestFileRemove(t *testing.T) {
 	r := fstest.NewRun(t)
 	defer r.Finalise()
@@ -154,6 +228,70 @@ func TestFileRemoveAll(t *testing.T) {
 	assert.Equal(t, EROFS, err)
 }
 
+// trashFs is an Fs whose objects can be sent to the trash, recording
+// which were
+type trashFs struct {
+	fs.Fs
+	trashed []string
+}
+
+// trashObject is an object on a trashFs
+type trashObject struct {
+	fs.Object
+	f *trashFs
+}
+
+// Trash records the object as trashed and removes it
+func (o *trashObject) Trash() error {
+	o.f.trashed = append(o.f.trashed, o.Remote())
+	return o.Remove()
+}
+
+// List the directory wrapping the objects
+func (f *trashFs) List(dir string) (entries fs.DirEntries, err error) {
+	entries, err = f.Fs.List(dir)
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = &trashObject{Object: o, f: f}
+		}
+	}
+	return entries, err
+}
+
+func TestFileRemoveTrash(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("file1", "file1 contents", t1)
+	file2 := r.WriteObject("file2", "file2 contents", t1)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	f := &trashFs{Fs: r.Fremote}
+	remove := func(vfs *VFS, name string) {
+		node, err := vfs.Stat(name)
+		require.NoError(t, err)
+		require.NoError(t, node.Remove())
+	}
+
+	// Without --vfs-use-trash objects are removed
+	remove(New(f, nil), "file1")
+	fstest.CheckItems(t, r.Fremote, file2)
+	assert.Equal(t, []string(nil), f.trashed)
+
+	// With it they are sent to the trash
+	opt := DefaultOpt
+	opt.UseTrash = true
+	remove(New(f, &opt), "file2")
+	fstest.CheckItems(t, r.Fremote)
+	assert.Equal(t, []string{"file2"}, f.trashed)
+
+	// Remotes without a trash just remove
+	file3 := r.WriteObject("file3", "file3 contents", t1)
+	fstest.CheckItems(t, r.Fremote, file3)
+	remove(New(r.Fremote, &opt), "file3")
+	fstest.CheckItems(t, r.Fremote)
+	assert.Equal(t, []string{"file2"}, f.trashed)
+}
+
 func TestFileOpen(t *testing.T) {
 	r := fstest.NewRun(t)
 	defer r.Finalise()
@@ -0,0 +1,149 @@
+// Track the open file handles for debugging
+
+package vfs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// HandleInfo describes an open file handle
+type HandleInfo struct {
+	Path   string    // path of the file
+	Flags  int       // flags the file was opened with, eg os.O_RDONLY
+	Bytes  int64     // bytes read from or written to the handle
+	Dirty  bool      // set if data has been written which isn't uploaded yet
+	Opened time.Time // when the handle was opened
+	Used   time.Time // when the handle was last read from or written to
+}
+
+// infoHandle is a file handle which can describe itself
+type infoHandle interface {
+	// info returns the Path, Bytes, Dirty and Used fields of the
+	// HandleInfo for the handle
+	info() HandleInfo
+
+	// closeIdle closes the handle, uploading anything written, if
+	// it hasn't been used for timeout
+	closeIdle(timeout time.Duration)
+}
+
+// openHandle is the details of an open handle which the handle
+// doesn't know itself
+type openHandle struct {
+	flags  int
+	opened time.Time
+}
+
+// openHandles tracks the file handles which are open, closing ones
+// which have been idle for --vfs-handle-idle-timeout if it is set.
+//
+// Handles call the methods with their own lock held, so openHandles
+// never takes a handle's lock while holding its own.
+type openHandles struct {
+	idleTimeout time.Duration // close handles idle for this long if set
+	mu          sync.Mutex    // protects the following
+	items       map[infoHandle]openHandle
+	reaping     bool // set if closeIdle is scheduled
+}
+
+// newOpenHandles makes an empty openHandles which closes handles idle
+// for idleTimeout if it is greater than 0
+func newOpenHandles(idleTimeout time.Duration) *openHandles {
+	return &openHandles{
+		idleTimeout: idleTimeout,
+		items:       make(map[infoHandle]openHandle),
+	}
+}
+
+// add records h as opened now with flags
+func (o *openHandles) add(h infoHandle, flags int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.items[h] = openHandle{
+		flags:  flags,
+		opened: time.Now(),
+	}
+	if o.idleTimeout > 0 && !o.reaping {
+		o.reaping = true
+		time.AfterFunc(o.idleTimeout/2, o.closeIdle)
+	}
+}
+
+// setFlags records that h was opened with flags
+func (o *openHandles) setFlags(h infoHandle, flags int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if item, ok := o.items[h]; ok {
+		item.flags = flags
+		o.items[h] = item
+	}
+}
+
+// remove records h as closed
+func (o *openHandles) remove(h infoHandle) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.items, h)
+}
+
+// closeIdle closes the handles which have been idle for longer than
+// idleTimeout, rescheduling itself while any handles are open.
+func (o *openHandles) closeIdle() {
+	o.mu.Lock()
+	handles := make([]infoHandle, 0, len(o.items))
+	for h := range o.items {
+		handles = append(handles, h)
+	}
+	o.mu.Unlock()
+
+	// The handles take their own locks and call remove when closed
+	for _, h := range handles {
+		h.closeIdle(o.idleTimeout)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.items) > 0 {
+		time.AfterFunc(o.idleTimeout/2, o.closeIdle)
+	} else {
+		o.reaping = false
+	}
+}
+
+// OpenHandles returns a snapshot of the open file handles, oldest
+// first.  This is useful for finding handles which have been left
+// open.
+func (vfs *VFS) OpenHandles() []HandleInfo {
+	// Copy the handles so their locks aren't taken with ours held
+	o := vfs.handles
+	o.mu.Lock()
+	handles := make(map[infoHandle]openHandle, len(o.items))
+	for h, item := range o.items {
+		handles[h] = item
+	}
+	o.mu.Unlock()
+
+	infos := make([]HandleInfo, 0, len(handles))
+	for h, item := range handles {
+		info := h.info()
+		info.Flags = item.flags
+		info.Opened = item.opened
+		infos = append(infos, info)
+	}
+	sort.Sort(handleInfos(infos))
+	return infos
+}
+
+// handleInfos sorts HandleInfo oldest first then by path
+type handleInfos []HandleInfo
+
+func (x handleInfos) Len() int      { return len(x) }
+func (x handleInfos) Swap(i, j int) { x[i], x[j] = x[j], x[i] }
+func (x handleInfos) Less(i, j int) bool {
+	if !x[i].Opened.Equal(x[j].Opened) {
+		return x[i].Opened.Before(x[j].Opened)
+	}
+	return x[i].Path < x[j].Path
+}
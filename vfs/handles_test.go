@@ -0,0 +1,96 @@
+package vfs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSOpenHandles(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteObject("file1", "file1 contents", t1)
+	vfs := New(r.Fremote, nil)
+	assert.Equal(t, []HandleInfo{}, vfs.OpenHandles())
+
+	// Open one file for read and create another
+	rfd, err := vfs.OpenFile("file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	wfd, err := vfs.OpenFile("file2", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+
+	buf := make([]byte, 5)
+	_, err = rfd.Read(buf)
+	require.NoError(t, err)
+	_, err = wfd.Write([]byte("hello world"))
+	require.NoError(t, err)
+
+	handles := vfs.OpenHandles()
+	require.Equal(t, 2, len(handles))
+	byPath := map[string]HandleInfo{}
+	for _, h := range handles {
+		byPath[h.Path] = h
+		assert.False(t, h.Opened.IsZero(), h.Path)
+	}
+	assert.False(t, handles[1].Opened.Before(handles[0].Opened))
+
+	h := byPath["file1"]
+	assert.Equal(t, os.O_RDONLY, h.Flags)
+	assert.Equal(t, int64(5), h.Bytes)
+	assert.False(t, h.Dirty)
+
+	h = byPath["file2"]
+	assert.Equal(t, os.O_WRONLY|os.O_CREATE, h.Flags)
+	assert.Equal(t, int64(11), h.Bytes)
+	assert.True(t, h.Dirty)
+
+	// Closing them should remove them
+	require.NoError(t, rfd.Close())
+	require.NoError(t, wfd.Close())
+	assert.Equal(t, []HandleInfo{}, vfs.OpenHandles())
+}
+
+func TestVFSHandleIdleTimeout(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("file1", "file1 contents", t1)
+	opt := DefaultOpt
+	opt.IdleTimeout = 100 * time.Millisecond
+	vfs := New(r.Fremote, &opt)
+
+	// Open a file for read and one for write and leave them idle
+	rfd, err := vfs.OpenFile("file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	wfd, err := vfs.OpenFile("file2", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = wfd.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	// Keep writing to another so it isn't idle
+	active, err := vfs.OpenFile("file3", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		_, err = active.Write([]byte("x"))
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// The idle handles should have been closed and uploaded
+	handles := vfs.OpenHandles()
+	require.Equal(t, 1, len(handles))
+	assert.Equal(t, "file3", handles[0].Path)
+	_, err = wfd.Write([]byte(" world"))
+	assert.Equal(t, ECLOSED, err)
+	assert.Equal(t, ECLOSED, wfd.Close())
+	assert.Equal(t, ECLOSED, rfd.Close())
+	file2 := fstest.NewItem("file2", "hello", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2}, nil, fs.ModTimeNotSupported)
+
+	require.NoError(t, active.Close())
+	assert.Equal(t, []HandleInfo{}, vfs.OpenHandles())
+}
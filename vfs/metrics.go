@@ -0,0 +1,62 @@
+// Counters of the operations done through a single VFS
+
+package vfs
+
+import "sync/atomic"
+
+// Metrics are counts of the operations done through the VFS since it
+// was created.  These are as well as the global transfer stats, which
+// count all the transfers rclone does.
+type Metrics struct {
+	Opens        int64 // number of file handles opened
+	Reads        int64 // number of reads from file handles
+	Writes       int64 // number of writes to file handles
+	BytesRead    int64 // bytes read from file handles
+	BytesWritten int64 // bytes written to file handles
+	CacheHits    int64 // directory reads served from the directory cache
+	CacheMisses  int64 // directory reads which listed the remote
+}
+
+// Metrics returns a snapshot of the counters.
+//
+// There is no file cache so the cache hits and misses count the
+// reads of the directory cache.
+func (vfs *VFS) Metrics() Metrics {
+	m := vfs.metrics
+	return Metrics{
+		Opens:        atomic.LoadInt64(&m.Opens),
+		Reads:        atomic.LoadInt64(&m.Reads),
+		Writes:       atomic.LoadInt64(&m.Writes),
+		BytesRead:    atomic.LoadInt64(&m.BytesRead),
+		BytesWritten: atomic.LoadInt64(&m.BytesWritten),
+		CacheHits:    atomic.LoadInt64(&m.CacheHits),
+		CacheMisses:  atomic.LoadInt64(&m.CacheMisses),
+	}
+}
+
+// countOpen counts a file handle being opened
+func (vfs *VFS) countOpen() {
+	atomic.AddInt64(&vfs.metrics.Opens, 1)
+}
+
+// countRead counts a read of n bytes
+func (vfs *VFS) countRead(n int) {
+	atomic.AddInt64(&vfs.metrics.Reads, 1)
+	atomic.AddInt64(&vfs.metrics.BytesRead, int64(n))
+}
+
+// countWrite counts a write of n bytes
+func (vfs *VFS) countWrite(n int) {
+	atomic.AddInt64(&vfs.metrics.Writes, 1)
+	atomic.AddInt64(&vfs.metrics.BytesWritten, int64(n))
+}
+
+// countDirCache counts a directory read which was served from the
+// cache if hit is set or from the remote otherwise
+func (vfs *VFS) countDirCache(hit bool) {
+	if hit {
+		atomic.AddInt64(&vfs.metrics.CacheHits, 1)
+	} else {
+		atomic.AddInt64(&vfs.metrics.CacheMisses, 1)
+	}
+}
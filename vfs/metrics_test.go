@@ -0,0 +1,57 @@
+package vfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSMetrics(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteObject("file1", "file1 contents", t1)
+	vfs := New(r.Fremote, nil)
+	assert.Equal(t, Metrics{}, vfs.Metrics())
+
+	// The first stat lists the root and the second uses the cache
+	_, err := vfs.Stat("file1")
+	require.NoError(t, err)
+	assert.Equal(t, Metrics{CacheMisses: 1}, vfs.Metrics())
+	_, err = vfs.Stat("file1")
+	require.NoError(t, err)
+	assert.Equal(t, Metrics{CacheHits: 1, CacheMisses: 1}, vfs.Metrics())
+
+	// Read from one file and write another
+	rfd, err := vfs.OpenFile("file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = rfd.Read(buf)
+	require.NoError(t, err)
+	_, err = rfd.Read(buf)
+	require.NoError(t, err)
+	require.NoError(t, rfd.Close())
+
+	wfd, err := vfs.OpenFile("file2", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = wfd.Write([]byte("hello world"))
+	require.NoError(t, err)
+	require.NoError(t, wfd.Close())
+
+	m := vfs.Metrics()
+	assert.Equal(t, int64(2), m.Opens)
+	assert.Equal(t, int64(2), m.Reads)
+	assert.Equal(t, int64(10), m.BytesRead)
+	assert.Equal(t, int64(1), m.Writes)
+	assert.Equal(t, int64(11), m.BytesWritten)
+	assert.True(t, m.CacheHits > 1)
+	assert.Equal(t, int64(1), m.CacheMisses)
+
+	// Forgetting the cache should make the next stat a miss
+	vfs.root.ForgetAll()
+	_, err = vfs.Stat("file2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), vfs.Metrics().CacheMisses)
+}
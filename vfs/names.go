@@ -0,0 +1,177 @@
+// Transforming names between the remote and the VFS
+
+package vfs
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NameTransformer maps the leaf names of files and directories on the
+// remote to the names shown in the VFS and back again.
+//
+// Remote must undo Display, so Remote(Display(leaf)) == leaf for any
+// leaf on the remote, otherwise files can't be found again after they
+// have been listed.
+type NameTransformer interface {
+	// Display returns the name the remote leaf is shown with
+	Display(leaf string) string
+	// Remote returns the leaf on the remote for the name shown
+	Remote(name string) string
+}
+
+// nameTransforms are the transforms which can be selected by name
+var nameTransforms = map[string]NameTransformer{}
+
+// RegisterNameTransform makes t selectable as name with
+// --vfs-name-transform
+func RegisterNameTransform(name string, t NameTransformer) {
+	nameTransforms[name] = t
+}
+
+func init() {
+	RegisterNameTransform("windows", windowsNames{})
+}
+
+// NameTransform is the name of a registered NameTransformer, or ""
+// for none.
+//
+// It satisfies the pflag.Value interface so can be used as a command
+// line flag.
+type NameTransform string
+
+// String returns the name
+func (name *NameTransform) String() string {
+	return string(*name)
+}
+
+// Set checks the transform called s exists and selects it
+func (name *NameTransform) Set(s string) error {
+	if _, ok := nameTransforms[s]; !ok && s != "" {
+		var known []string
+		for k := range nameTransforms {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return errors.Errorf("unknown name transform %q - should be one of %s", s, strings.Join(known, ", "))
+	}
+	*name = NameTransform(s)
+	return nil
+}
+
+// Type of the value
+func (name *NameTransform) Type() string {
+	return "string"
+}
+
+// displayName returns the name the remote leaf is shown with
+func (vfs *VFS) displayName(leaf string) string {
+	if vfs.names == nil {
+		return leaf
+	}
+	return vfs.names.Display(leaf)
+}
+
+// remoteName returns the leaf on the remote for the name shown
+func (vfs *VFS) remoteName(name string) string {
+	if vfs.names == nil {
+		return name
+	}
+	return vfs.names.Remote(name)
+}
+
+// remotePathOf returns the path on the remote, relative to the root,
+// of the path p in the VFS
+func (vfs *VFS) remotePathOf(p string) string {
+	if vfs.names == nil {
+		return p
+	}
+	elements := strings.Split(p, "/")
+	for i, element := range elements {
+		elements[i] = vfs.names.Remote(element)
+	}
+	return strings.Join(elements, "/")
+}
+
+// remotePath returns the path on the remote of the entry shown as
+// name in d
+func (d *Dir) remotePath(name string) string {
+	return path.Join(d.path, d.vfs.remoteName(name))
+}
+
+// windowsCharMap holds replacements for the characters Windows doesn't
+// allow in file names.  These are mapped to their FULLWIDTH unicode
+// equivalents as the onedrive backend does.
+var (
+	windowsCharMap = map[rune]rune{
+		'"':  '＂', // FULLWIDTH QUOTATION MARK
+		'*':  '＊', // FULLWIDTH ASTERISK
+		':':  '：', // FULLWIDTH COLON
+		'<':  '＜', // FULLWIDTH LESS-THAN SIGN
+		'>':  '＞', // FULLWIDTH GREATER-THAN SIGN
+		'?':  '？', // FULLWIDTH QUESTION MARK
+		'\\': '＼', // FULLWIDTH REVERSE SOLIDUS
+		'|':  '｜', // FULLWIDTH VERTICAL LINE
+	}
+	windowsInvCharMap map[rune]rune
+)
+
+// windowsQuote is put in front of characters on the remote which
+// would otherwise be read back as a replacement
+const windowsQuote = '‛' // SINGLE HIGH-REVERSED-9 QUOTATION MARK
+
+func init() {
+	windowsInvCharMap = make(map[rune]rune, len(windowsCharMap))
+	for k, v := range windowsCharMap {
+		windowsInvCharMap[v] = k
+	}
+}
+
+// windowsNames is a NameTransformer which replaces the characters
+// Windows doesn't allow in file names.
+//
+// Names on the remote which already contain the replacements or the
+// quote character have them quoted so they are restored unchanged.
+type windowsNames struct{}
+
+// Display replaces the characters Windows doesn't allow in leaf
+func (windowsNames) Display(leaf string) string {
+	var out bytes.Buffer
+	for _, c := range leaf {
+		if replacement, ok := windowsCharMap[c]; ok {
+			c = replacement
+		} else if _, ok := windowsInvCharMap[c]; ok || c == windowsQuote {
+			out.WriteRune(windowsQuote)
+		}
+		out.WriteRune(c)
+	}
+	return out.String()
+}
+
+// Remote undoes the replacements made by Display
+func (windowsNames) Remote(name string) string {
+	var out bytes.Buffer
+	quoted := false
+	for _, c := range name {
+		switch {
+		case quoted:
+			quoted = false
+		case c == windowsQuote:
+			quoted = true
+			continue
+		default:
+			if original, ok := windowsInvCharMap[c]; ok {
+				c = original
+			}
+		}
+		out.WriteRune(c)
+	}
+	if quoted {
+		out.WriteRune(windowsQuote)
+	}
+	return out.String()
+}
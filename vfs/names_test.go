@@ -0,0 +1,92 @@
+package vfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameTransformSet(t *testing.T) {
+	var name NameTransform
+	require.NoError(t, name.Set("windows"))
+	assert.Equal(t, "windows", name.String())
+	assert.Equal(t, "string", name.Type())
+	require.NoError(t, name.Set(""))
+	assert.Equal(t, "", name.String())
+	assert.EqualError(t, name.Set("potato"), `unknown name transform "potato" - should be one of windows`)
+}
+
+func TestWindowsNames(t *testing.T) {
+	var names windowsNames
+	for _, test := range []struct {
+		in  string
+		out string
+	}{
+		{"", ""},
+		{"abc 123", "abc 123"},
+		{`a"*:<>?\|b`, `a＂＊：＜＞？＼｜b`},
+		{"already：full？width", "already‛：full‛？width"},
+		{"quote‛", "quote‛‛"},
+		{"‛?‛？", "‛‛？‛‛‛？"},
+	} {
+		got := names.Display(test.in)
+		assert.Equal(t, test.out, got, test.in)
+		assert.Equal(t, test.in, names.Remote(got), got)
+	}
+	// Names not made by Display are left alone where possible
+	assert.Equal(t, "trailing quote‛", names.Remote("trailing quote‛"))
+}
+
+func TestVFSNameTransform(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir:1/file?1", "file1 contents", t1)
+
+	opt := DefaultOpt
+	opt.NameTransform = "windows"
+	vfs := New(r.Fremote, &opt)
+
+	// Listings show the remote names transformed
+	nodes, err := vfs.root.ReadDirAll()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(nodes))
+	assert.Equal(t, "dir：1", nodes[0].Name())
+	node, err := vfs.Stat("dir：1/file？1")
+	require.NoError(t, err)
+	assert.Equal(t, "file？1", node.Name())
+	assert.Equal(t, "dir:1/file?1", node.(*File).String())
+	_, err = vfs.Stat("dir:1")
+	assert.Equal(t, ENOENT, err)
+
+	// Reading finds the object on the remote
+	fd, err := vfs.OpenFile("dir：1/file？1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	buf := make([]byte, 14)
+	_, err = fd.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "file1 contents", string(buf))
+	require.NoError(t, fd.Close())
+
+	// Creating, making directories and renaming use the remote names
+	fd, err = vfs.OpenFile("dir：1/new＊file", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = fd.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	_, err = vfs.root.Mkdir("sub｜dir")
+	require.NoError(t, err)
+	require.NoError(t, vfs.Rename("dir：1/new＊file", "sub｜dir/renamed＜＞"))
+
+	file2 := fstest.NewItem("sub|dir/renamed<>", "hello", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2}, []string{"dir:1", "sub|dir"}, fs.ModTimeNotSupported)
+
+	// And the names round trip back through a fresh listing
+	vfs.root.ForgetAll()
+	node, err = vfs.Stat("sub｜dir/renamed＜＞")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), node.Size())
+}
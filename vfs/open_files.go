@@ -0,0 +1,120 @@
+// Limit the number of files open on the remote
+
+package vfs
+
+import (
+	"container/list"
+	"sync"
+)
+
+// openFile is a handle with a connection open to the remote
+type openFile interface {
+	// evict closes the connection to the remote so it can be
+	// reopened later, returning false if that isn't possible
+	evict() bool
+}
+
+// openFiles tracks the handles with connections open to the remote
+// in least recently used order so that idle ones can be closed when
+// there are more than --vfs-max-open-files of them.
+//
+// Handles call the methods with their own lock held, so openFiles
+// never takes a handle's lock while holding its own.
+type openFiles struct {
+	max   int
+	mu    sync.Mutex                 // protects the following
+	lru   *list.List                 // of openFile, most recently used at the front
+	items map[openFile]*list.Element // elements of lru by openFile
+}
+
+// newOpenFiles makes an openFiles allowing max files open, returning
+// nil if max is 0 or less meaning there is no limit
+func newOpenFiles(max int) *openFiles {
+	if max <= 0 {
+		return nil
+	}
+	return &openFiles{
+		max:   max,
+		lru:   list.New(),
+		items: make(map[openFile]*list.Element),
+	}
+}
+
+// add records f as open, evicting the least recently used files if
+// there are too many open.  It returns EMFILE if there are too many
+// open and none of them can be evicted.
+//
+// It is safe to call on a nil openFiles.
+func (o *openFiles) add(f openFile) error {
+	if o == nil {
+		return nil
+	}
+	tried := make(map[openFile]struct{})
+	for {
+		o.mu.Lock()
+		if e, ok := o.items[f]; ok {
+			o.lru.MoveToFront(e)
+			o.mu.Unlock()
+			return nil
+		}
+		if o.lru.Len() < o.max {
+			o.items[f] = o.lru.PushFront(f)
+			o.mu.Unlock()
+			return nil
+		}
+		var victim openFile
+		for e := o.lru.Back(); e != nil; e = e.Prev() {
+			candidate := e.Value.(openFile)
+			if _, ok := tried[candidate]; !ok {
+				victim = candidate
+				break
+			}
+		}
+		o.mu.Unlock()
+		if victim == nil {
+			return EMFILE
+		}
+		tried[victim] = struct{}{}
+		// evict calls remove if it succeeds
+		_ = victim.evict()
+	}
+}
+
+// touch marks f as recently used
+//
+// It is safe to call on a nil openFiles.
+func (o *openFiles) touch(f openFile) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if e, ok := o.items[f]; ok {
+		o.lru.MoveToFront(e)
+	}
+}
+
+// remove records f as closed
+//
+// It is safe to call on a nil openFiles.
+func (o *openFiles) remove(f openFile) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if e, ok := o.items[f]; ok {
+		o.lru.Remove(e)
+		delete(o.items, f)
+	}
+}
+
+// count returns the number of files open
+func (o *openFiles) count() int {
+	if o == nil {
+		return 0
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.lru.Len()
+}
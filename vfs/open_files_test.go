@@ -0,0 +1,79 @@
+package vfs
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenFilesEvict(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	const n = 5
+	for i := 0; i < n; i++ {
+		r.WriteObject(fmt.Sprintf("file%d", i), fmt.Sprintf("contents of file%d", i), t1)
+	}
+	opt := DefaultOpt
+	opt.MaxOpenFiles = 2
+	vfs := New(r.Fremote, &opt)
+
+	// Open all the files and read the start of them
+	var handles []Handle
+	for i := 0; i < n; i++ {
+		fd, err := vfs.OpenFile(fmt.Sprintf("file%d", i), os.O_RDONLY, 0)
+		require.NoError(t, err)
+		handles = append(handles, fd)
+		buf := make([]byte, 9)
+		_, err = fd.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "contents ", string(buf))
+		assert.True(t, vfs.openFiles.count() <= opt.MaxOpenFiles)
+	}
+	assert.Equal(t, opt.MaxOpenFiles, vfs.openFiles.count())
+
+	// The first ones should have been closed and reopen
+	// transparently at the same offset
+	for i, fd := range handles {
+		buf := make([]byte, 8)
+		_, err := fd.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("of file%d", i), string(buf))
+		assert.True(t, vfs.openFiles.count() <= opt.MaxOpenFiles)
+	}
+
+	for _, fd := range handles {
+		require.NoError(t, fd.Close())
+	}
+	assert.Equal(t, 0, vfs.openFiles.count())
+}
+
+func TestOpenFilesDirty(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteObject("file1", "file1 contents", t1)
+	opt := DefaultOpt
+	opt.MaxOpenFiles = 1
+	vfs := New(r.Fremote, &opt)
+
+	// A file being written can't be closed to make room
+	fh, err := vfs.OpenFile("file2", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = vfs.OpenFile("file3", os.O_WRONLY|os.O_CREATE, 0777)
+	assert.Equal(t, EMFILE, err)
+	fd, err := vfs.OpenFile("file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	_, err = fd.Read(make([]byte, 5))
+	assert.Equal(t, EMFILE, err)
+
+	// Once it is closed there is room
+	require.NoError(t, fh.Close())
+	buf := make([]byte, 5)
+	_, err = fd.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "file1", string(buf))
+	require.NoError(t, fd.Close())
+}
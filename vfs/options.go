@@ -0,0 +1,74 @@
+// Changing the options of a running VFS
+
+package vfs
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ncw/rclone/crypt"
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// Check returns an error if the options can't be used so commands can
+// fail when they start rather than when the files are used.
+func (opt *Options) Check() error {
+	if opt.CryptPath != "" {
+		if _, err := opt.newCipher(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newCipher makes the cipher for CryptPath from the passwords of the
+// crypt remote CryptRemote
+func (opt *Options) newCipher() (crypt.Cipher, error) {
+	cipher, err := crypt.NewCipher(strings.TrimSuffix(opt.CryptRemote, ":"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read --vfs-crypt-remote %q", opt.CryptRemote)
+	}
+	return cipher, nil
+}
+
+// dirCacheTime returns how long directory listings are cached for
+func (vfs *VFS) dirCacheTime() time.Duration {
+	vfs.optMu.Lock()
+	defer vfs.optMu.Unlock()
+	return vfs.Opt.DirCacheTime
+}
+
+// SetOptions changes the options of the running VFS to those in opt.
+//
+// Only DirCacheTime and BwLimit can be changed while the VFS is in
+// use.  The other options are read when the VFS is made, so if any
+// of them differ from the current ones they are ignored with a
+// warning.
+func (vfs *VFS) SetOptions(opt *Options) {
+	newOpt := *opt
+	newOpt.maskPerms()
+
+	vfs.optMu.Lock()
+	defer vfs.optMu.Unlock()
+
+	if newOpt.DirCacheTime != vfs.Opt.DirCacheTime {
+		fs.Infof(nil, "VFS: changing dir cache time from %v to %v", vfs.Opt.DirCacheTime, newOpt.DirCacheTime)
+		vfs.Opt.DirCacheTime = newOpt.DirCacheTime
+	}
+	if newOpt.BwLimit != vfs.Opt.BwLimit {
+		fs.Infof(nil, "VFS: changing bandwidth limit from %v to %v", vfs.Opt.BwLimit, newOpt.BwLimit)
+		vfs.Opt.BwLimit = newOpt.BwLimit
+		vfs.bwLimiter = newBwLimiter(newOpt.BwLimit)
+	}
+
+	// Warn about any others which have changed
+	oldValue := reflect.ValueOf(vfs.Opt)
+	newValue := reflect.ValueOf(newOpt)
+	for i := 0; i < oldValue.NumField(); i++ {
+		if !reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			fs.Errorf(nil, "VFS: ignoring change to %s as it can't be changed without a remount", oldValue.Type().Field(i).Name)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package vfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSSetOptions(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteObject("file1", "file1 contents", t1)
+
+	opt := DefaultOpt
+	opt.DirCacheTime = time.Hour
+	vfs := New(r.Fremote, &opt)
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	items, err := root.ReadDirAll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(items))
+
+	// The new file isn't seen while the listing is cached
+	r.WriteObject("file2", "file2 contents", t2)
+	items, err = root.ReadDirAll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(items))
+
+	// Change the options
+	newOpt := opt
+	newOpt.DirCacheTime = 0
+	newOpt.BwLimit = 1024 * 1024
+	newOpt.PollInterval = opt.PollInterval + time.Minute
+	vfs.SetOptions(&newOpt)
+	assert.Equal(t, time.Duration(0), vfs.Opt.DirCacheTime)
+	assert.Equal(t, newOpt.BwLimit, vfs.Opt.BwLimit)
+	require.NotNil(t, vfs.bwLimiter)
+	assert.Equal(t, 1024*1024, vfs.bwLimiter.Burst())
+
+	// The poll interval can't be changed so is ignored
+	assert.Equal(t, opt.PollInterval, vfs.Opt.PollInterval)
+
+	// The listing should now be re-read
+	items, err = root.ReadDirAll()
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(items))
+
+	// Check the bandwidth limit can be removed again
+	newOpt.BwLimit = 0
+	vfs.SetOptions(&newOpt)
+	assert.Equal(t, newOpt.BwLimit, vfs.Opt.BwLimit)
+	assert.Nil(t, vfs.bwLimiter)
+}
@@ -0,0 +1,99 @@
+// Permissions for files and directories matching globs
+
+package vfs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// PermRule sets the permissions of the files and directories whose
+// paths match Glob instead of FilePerms and DirPerms.
+//
+// Glob uses the same syntax as the filter rules.  Directories are
+// matched with a "/" on the end of their path.
+type PermRule struct {
+	Glob  string
+	Perms os.FileMode
+}
+
+// String turns the rule into glob=perms
+func (rule PermRule) String() string {
+	return fmt.Sprintf("%s=%04o", rule.Glob, rule.Perms)
+}
+
+// PermRules is a list of PermRule.  The first matching rule is used.
+//
+// It satisfies the pflag.Value interface so can be used as a command
+// line flag with values like "bin/**=0755".
+type PermRules []PermRule
+
+// String turns the rules into a comma separated list
+func (rules *PermRules) String() string {
+	var out []string
+	for _, rule := range *rules {
+		out = append(out, rule.String())
+	}
+	return strings.Join(out, ",")
+}
+
+// Set parses a glob=perms rule and adds it
+func (rules *PermRules) Set(s string) error {
+	i := strings.LastIndex(s, "=")
+	if i < 0 {
+		return errors.Errorf("permission rule %q should be glob=perms", s)
+	}
+	glob, perms := s[:i], s[i+1:]
+	mode, err := strconv.ParseUint(perms, 8, 32)
+	if err != nil || mode > 0777 {
+		return errors.Errorf("bad permissions %q in %q - should be octal like 0755", perms, s)
+	}
+	_, err = fs.GlobToRegexp(glob)
+	if err != nil {
+		return errors.Wrapf(err, "bad glob in %q", s)
+	}
+	*rules = append(*rules, PermRule{Glob: glob, Perms: os.FileMode(mode)})
+	return nil
+}
+
+// Type of the value
+func (rules *PermRules) Type() string {
+	return "glob=perms"
+}
+
+// permRule is a compiled PermRule
+type permRule struct {
+	re    *regexp.Regexp
+	perms os.FileMode
+}
+
+// compilePermRules compiles rules, logging and skipping any which are
+// invalid
+func compilePermRules(rules PermRules) (out []permRule) {
+	for _, rule := range rules {
+		re, err := fs.GlobToRegexp(rule.Glob)
+		if err != nil {
+			fs.Errorf(nil, "Ignoring permission rule %v: %v", rule, err)
+			continue
+		}
+		out = append(out, permRule{re: re, perms: rule.Perms & os.ModePerm})
+	}
+	return out
+}
+
+// perms returns the permissions for remote from the first rule which
+// matches, or defaultPerms if none do
+func (vfs *VFS) perms(remote string, defaultPerms os.FileMode) os.FileMode {
+	for _, rule := range vfs.permRules {
+		if rule.re.MatchString(remote) {
+			return rule.perms
+		}
+	}
+	return defaultPerms
+}
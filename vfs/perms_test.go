@@ -0,0 +1,61 @@
+package vfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermRulesSet(t *testing.T) {
+	var rules PermRules
+	require.NoError(t, rules.Set("bin/**=0755"))
+	require.NoError(t, rules.Set("a=b=600"))
+	assert.Equal(t, PermRules{
+		{Glob: "bin/**", Perms: 0755},
+		{Glob: "a=b", Perms: 0600},
+	}, rules)
+	assert.Equal(t, "bin/**=0755,a=b=0600", rules.String())
+	assert.Equal(t, "glob=perms", rules.Type())
+
+	for _, bad := range []string{"potato", "x=", "x=0999", "x=01000", "x=rwx", "***=0644"} {
+		assert.Error(t, rules.Set(bad), bad)
+	}
+	assert.Equal(t, 2, len(rules))
+}
+
+func TestPermRules(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	opt := DefaultOpt
+	opt.Umask = 0
+	require.NoError(t, opt.PermRules.Set("/bin/=0750"))
+	require.NoError(t, opt.PermRules.Set("/bin/*=0755"))
+	require.NoError(t, opt.PermRules.Set("*.txt=0444"))
+	vfs := New(r.Fremote, &opt)
+
+	r.WriteObject("bin/prog", "program", t1)
+	r.WriteObject("bin/readme.txt", "readme", t1)
+	r.WriteObject("doc/readme.txt", "readme", t1)
+	r.WriteObject("doc/other", "other", t1)
+
+	for _, test := range []struct {
+		path string
+		want os.FileMode
+	}{
+		{"", os.ModeDir | 0777},
+		{"bin", os.ModeDir | 0750},
+		{"bin/prog", 0755},
+		{"bin/readme.txt", 0755},
+		{"doc", os.ModeDir | 0777},
+		{"doc/readme.txt", 0444},
+		{"doc/other", 0666},
+	} {
+		node, err := vfs.Stat(test.path)
+		require.NoError(t, err, test.path)
+		assert.Equal(t, test.want, node.Mode(), test.path)
+	}
+}
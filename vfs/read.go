@@ -4,6 +4,7 @@ import (
 	"io"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/ncw/rclone/fs"
 	"github.com/pkg/errors"
@@ -23,8 +24,19 @@ type ReadFileHandle struct {
 	file       *File
 	hash       *fs.MultiHasher
 	opened     bool
+	retries    int       // number of read retries since the last successful read
+	released   bool      // set once the file has been told the handle is finished with
+	bytes      int64     // number of bytes read
+	used       time.Time // when the handle was last read from
 }
 
+// readRetrySleep is the time to wait before the first read retry.  It
+// doubles on each subsequent retry up to maxReadRetrySleep.
+var (
+	readRetrySleep    = 100 * time.Millisecond
+	maxReadRetrySleep = 10 * time.Second
+)
+
 // Check interfaces
 var (
 	_ io.Reader   = (*ReadFileHandle)(nil)
@@ -47,27 +59,95 @@ func newReadFileHandle(f *File, o fs.Object) (*ReadFileHandle, error) {
 		o:      o,
 		noSeek: f.d.vfs.Opt.NoSeek,
 		file:   f,
+		used:   time.Now(),
 		hash:   hash,
 	}
+	f.addReaders(1)
+	f.d.vfs.handles.add(fh, os.O_RDONLY)
+	f.d.vfs.countOpen()
 	return fh, nil
 }
 
+// info describes the handle - satisfies the infoHandle interface
+func (fh *ReadFileHandle) info() HandleInfo {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	return HandleInfo{
+		Path:  fh.file.String(),
+		Bytes: fh.bytes,
+		Used:  fh.used,
+	}
+}
+
+// closeIdle closes the handle if it hasn't been read from for
+// timeout - satisfies the infoHandle interface
+func (fh *ReadFileHandle) closeIdle(timeout time.Duration) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.closed || time.Since(fh.used) < timeout {
+		return
+	}
+	fs.Infof(fh.file, "Closing read handle idle for more than %v", timeout)
+	err := fh.close()
+	if err != nil {
+		fs.Errorf(fh.file, "Failed to close idle read handle: %v", err)
+	}
+}
+
+// release tells the file and the VFS the handle is finished with if
+// they haven't been told already
+//
+// Must be called with fh.mu held
+func (fh *ReadFileHandle) release() {
+	if !fh.released {
+		fh.released = true
+		fh.file.addReaders(-1)
+		fh.file.d.vfs.handles.remove(fh)
+	}
+}
+
 // openPending opens the file if there is a pending open
 // call with the lock held
 func (fh *ReadFileHandle) openPending() (err error) {
+	openFiles := fh.file.d.vfs.openFiles
 	if fh.opened {
+		openFiles.touch(fh)
 		return nil
 	}
-	r, err := fh.o.Open()
+	err = openFiles.add(fh)
+	if err != nil {
+		return err
+	}
+	// offset is non zero if reopening after being evicted
+	r, err := fh.openAt(fh.offset)
 	if err != nil {
+		openFiles.remove(fh)
 		return err
 	}
-	fh.r = fs.NewAccount(r, fh.o).WithBuffer() // account the transfer
+	fh.file.d.vfs.markOK()
+	fh.r = fs.NewAccount(r, fh.o) // account the transfer
+	if readAhead := fh.file.d.vfs.Opt.ReadAhead; readAhead > 0 {
+		fh.r = fh.r.WithBufferSize(int64(readAhead))
+	} else {
+		fh.r = fh.r.WithBuffer()
+	}
 	fh.opened = true
 	fs.Stats.Transferring(fh.o.Remote())
 	return nil
 }
 
+// openAt opens the object for reading from offset, using RangeOpen
+// if the object supports it
+func (fh *ReadFileHandle) openAt(offset int64) (io.ReadCloser, error) {
+	if offset == 0 {
+		return fh.o.Open()
+	}
+	if _, ok := fh.o.(fs.RangeOpener); ok {
+		return fs.RangeOpen(fh.o, offset, -1)
+	}
+	return fh.o.Open(&fs.SeekOption{Offset: offset})
+}
+
 // String converts it to printable
 func (fh *ReadFileHandle) String() string {
 	if fh == nil {
@@ -117,7 +197,7 @@ func (fh *ReadFileHandle) seek(offset int64, reopen bool) (err error) {
 			fs.Debugf(fh.o, "ReadFileHandle.Read seek close old failed: %v", err)
 		}
 		// re-open with a seek
-		r, err = fh.o.Open(&fs.SeekOption{Offset: offset})
+		r, err = fh.openAt(offset)
 		if err != nil {
 			fs.Debugf(fh.o, "ReadFileHandle.Read seek failed: %v", err)
 			return err
@@ -177,7 +257,20 @@ func (fh *ReadFileHandle) ReadAt(p []byte, off int64) (n int, err error) {
 	return fh.readAt(p, off)
 }
 
+// retryableReadError returns false if err is one which retrying the
+// read can't fix, such as the object having been deleted
+func retryableReadError(err error) bool {
+	if fs.IsNoRetryError(err) || fs.IsFatalError(err) {
+		return false
+	}
+	_, cause := fs.Cause(err)
+	return cause != fs.ErrorObjectNotFound && !os.IsNotExist(cause)
+}
+
 // Implementation of ReadAt - call with lock held
+//
+// The lock is released while waiting to retry a failed read so the
+// handle can be closed in the meantime.
 func (fh *ReadFileHandle) readAt(p []byte, off int64) (n int, err error) {
 	err = fh.openPending() // FIXME pending open could be more efficient in the presense of seek (and retries)
 	if err != nil {
@@ -193,7 +286,10 @@ func (fh *ReadFileHandle) readAt(p []byte, off int64) (n int, err error) {
 		return 0, ESPIPE
 	}
 	var newOffset int64
-	retries := 0
+	maxRetries := fh.file.d.vfs.Opt.ReadRetries
+	if maxRetries <= 0 {
+		maxRetries = fs.Config.LowLevelRetries
+	}
 	reqSize := len(p)
 	doReopen := false
 	for {
@@ -230,18 +326,42 @@ func (fh *ReadFileHandle) readAt(p []byte, off int64) (n int, err error) {
 				break
 			}
 		}
-		if retries >= fs.Config.LowLevelRetries {
+		if fh.retries >= maxRetries || !retryableReadError(err) {
 			break
 		}
-		retries++
-		fs.Errorf(fh.o, "ReadFileHandle.Read error: low level retry %d/%d: %v", retries, fs.Config.LowLevelRetries, err)
+		sleep := readRetrySleep << uint(fh.retries)
+		if sleep > maxReadRetrySleep || sleep <= 0 {
+			sleep = maxReadRetrySleep
+		}
+		fh.retries++
+		fs.Errorf(fh.o, "ReadFileHandle.Read error: retry %d/%d in %v: %v", fh.retries, maxRetries, sleep, err)
+		fh.mu.Unlock()
+		time.Sleep(sleep)
+		fh.mu.Lock()
+		if fh.closed {
+			return 0, ECLOSED
+		}
 		doSeek = true
 		doReopen = true
+		if !fh.opened {
+			// evicted while sleeping so open it again at the last
+			// good offset
+			err = fh.openPending()
+			if err != nil {
+				break
+			}
+			doSeek = off != fh.offset
+		}
 	}
+	fh.file.d.vfs.limitBandwidth(n)
+	fh.file.d.vfs.countRead(n)
+	fh.bytes += int64(n)
+	fh.used = time.Now()
 	if err != nil {
 		fs.Errorf(fh.o, "ReadFileHandle.Read error: %v", err)
 	} else {
 		fh.offset = newOffset
+		fh.retries = 0
 		// fs.Debugf(fh.o, "ReadFileHandle.Read OK")
 
 		if fh.hash != nil {
@@ -321,8 +441,10 @@ func (fh *ReadFileHandle) close() error {
 		return ECLOSED
 	}
 	fh.closed = true
+	fh.release()
 
 	if fh.opened {
+		fh.file.d.vfs.openFiles.remove(fh)
 		fs.Stats.DoneTransferring(fh.o.Remote(), true)
 		// Close first so that we have hashes
 		err := fh.r.Close()
@@ -338,6 +460,26 @@ func (fh *ReadFileHandle) close() error {
 	return nil
 }
 
+// evict closes the connection to the remote if the handle is open so
+// it will be reopened at the same offset by the next read - satisfies
+// the openFile interface
+func (fh *ReadFileHandle) evict() bool {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	fh.file.d.vfs.openFiles.remove(fh)
+	if !fh.opened || fh.closed {
+		return true
+	}
+	fs.Debugf(fh.o, "ReadFileHandle.evict closing idle file at offset %d", fh.offset)
+	fs.Stats.DoneTransferring(fh.o.Remote(), true)
+	err := fh.r.Close()
+	if err != nil {
+		fs.Debugf(fh.o, "ReadFileHandle.evict close failed: %v", err)
+	}
+	fh.opened = false
+	return true
+}
+
 // Close closes the file
 func (fh *ReadFileHandle) Close() error {
 	fh.mu.Lock()
@@ -373,6 +515,7 @@ func (fh *ReadFileHandle) Release() error {
 	fh.mu.Lock()
 	defer fh.mu.Unlock()
 	if !fh.opened {
+		fh.release()
 		return nil
 	}
 	if fh.closed {
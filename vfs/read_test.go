@@ -3,9 +3,13 @@ package vfs
 import (
 	"io"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ncw/rclone/fs"
 	"github.com/ncw/rclone/fstest"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -236,3 +240,223 @@ func TestReadFileHandleRelease(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, fh.closed)
 }
+
+func TestReadFileHandleReleaseUnread(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs, fh := readHandleCreate(t, r)
+	assert.Equal(t, 1, len(vfs.OpenHandles()))
+
+	// Releasing a handle which was never read from forgets it
+	require.NoError(t, fh.Release())
+	assert.Equal(t, 0, len(vfs.OpenHandles()))
+}
+
+// flakyObject is an Object whose first few opens return a reader
+// which fails part way through
+type flakyObject struct {
+	fs.Object
+	failures int // number of opens which should fail
+	opens    int // number of times Open has been called
+}
+
+// Open the object, returning a failing reader for the first failures
+// opens
+func (o *flakyObject) Open(options ...fs.OpenOption) (io.ReadCloser, error) {
+	o.opens++
+	in, err := o.Object.Open(options...)
+	if err != nil || o.opens > o.failures {
+		return in, err
+	}
+	return &flakyReader{ReadCloser: in, n: 4}, nil
+}
+
+// flakyReader returns an error after reading n bytes
+type flakyReader struct {
+	io.ReadCloser
+	n int
+}
+
+func (r *flakyReader) Read(p []byte) (n int, err error) {
+	if r.n <= 0 {
+		return 0, errors.New("flaky read error")
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	n, err = r.ReadCloser.Read(p)
+	r.n -= n
+	return n, err
+}
+
+func TestReadFileHandleReadRetries(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	oldSleep := readRetrySleep
+	readRetrySleep = time.Millisecond
+	defer func() { readRetrySleep = oldSleep }()
+
+	opt := DefaultOpt
+	opt.ReadRetries = 3
+	vfs := New(r.Fremote, &opt)
+
+	file1 := r.WriteObject("dir/file1", "0123456789abcdef", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	open := func(failures int) (*ReadFileHandle, *flakyObject) {
+		h, err := vfs.OpenFile("dir/file1", os.O_RDONLY, 0777)
+		require.NoError(t, err)
+		fh, ok := h.(*ReadFileHandle)
+		require.True(t, ok)
+		o := &flakyObject{Object: fh.o, failures: failures}
+		fh.o = o
+		return fh, o
+	}
+
+	// Recovers within the budget
+	fh, o := open(3)
+	assert.Equal(t, "01234567", readString(t, fh, 8))
+	assert.Equal(t, 4, o.opens)
+	assert.Equal(t, 0, fh.retries)
+	assert.Equal(t, "89abcdef", readString(t, fh, 8))
+	require.NoError(t, fh.Close())
+
+	// Fails when the budget is exceeded
+	fh, o = open(5)
+	buf := make([]byte, 8)
+	_, err := fh.Read(buf)
+	assert.EqualError(t, err, "flaky read error")
+	assert.Equal(t, 4, o.opens)
+	assert.Equal(t, 3, fh.retries)
+	require.NoError(t, fh.Close())
+
+	// Fails fast if the object has been deleted
+	fh, o = open(5)
+	assert.Equal(t, "01", readString(t, fh, 2))
+	require.NoError(t, o.Object.Remove())
+	_, err = fh.Read(buf)
+	require.Error(t, err)
+	assert.True(t, os.IsNotExist(errors.Cause(err)), err.Error())
+	assert.Equal(t, 2, o.opens)
+	assert.Equal(t, 1, fh.retries)
+	_ = fh.Close() // the failed reopen has already closed the reader
+}
+
+func TestReadFileHandleCloseWhileRetrying(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	oldSleep := readRetrySleep
+	readRetrySleep = time.Second
+	defer func() { readRetrySleep = oldSleep }()
+
+	opt := DefaultOpt
+	opt.ReadRetries = 3
+	vfs := New(r.Fremote, &opt)
+
+	file1 := r.WriteObject("dir/file1", "0123456789abcdef", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	h, err := vfs.OpenFile("dir/file1", os.O_RDONLY, 0777)
+	require.NoError(t, err)
+	fh, ok := h.(*ReadFileHandle)
+	require.True(t, ok)
+	fh.o = &flakyObject{Object: fh.o, failures: 5}
+
+	errs := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 8)
+		_, err := fh.Read(buf)
+		errs <- err
+	}()
+
+	// Wait for the read to fail and start its backoff
+	time.Sleep(100 * time.Millisecond)
+
+	// The handle can be closed without waiting for the retry
+	start := time.Now()
+	require.NoError(t, fh.Close())
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+
+	// The read gives up once it wakes up
+	assert.Equal(t, ECLOSED, <-errs)
+}
+
+func TestReadFileHandleRetryableReadError(t *testing.T) {
+	assert.True(t, retryableReadError(errors.New("500 internal server error")))
+	assert.True(t, retryableReadError(io.ErrUnexpectedEOF))
+	assert.False(t, retryableReadError(fs.ErrorObjectNotFound))
+	assert.False(t, retryableReadError(errors.Wrap(os.ErrNotExist, "open failed")))
+	assert.False(t, retryableReadError(fs.NoRetryError(errors.New("bad request"))))
+	assert.False(t, retryableReadError(fs.FatalError(errors.New("bad auth"))))
+}
+
+func TestReadFileHandleReadAhead(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	opt := DefaultOpt
+	opt.ReadAhead = 1024 * 1024
+	vfs := New(r.Fremote, &opt)
+
+	contents := strings.Repeat("0123456789abcdef", 3*1024*1024/16)
+	file1 := r.WriteObject("dir/file1", contents, t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	h, err := vfs.OpenFile("dir/file1", os.O_RDONLY, 0777)
+	require.NoError(t, err)
+	fh, ok := h.(*ReadFileHandle)
+	require.True(t, ok)
+
+	assert.Equal(t, "0123456789", readString(t, fh, 10))
+
+	// Seek past the read ahead and check reading carries on there
+	_, err = fh.Seek(2*1024*1024+3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "3456789abc", readString(t, fh, 10))
+
+	require.NoError(t, fh.Close())
+}
+
+func TestReadFileHandleSmallReads(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs := New(r.Fremote, nil)
+
+	contents := strings.Repeat("0123456789abcdef", 64*1024/16)
+	file1 := r.WriteObject("dir/file1", contents, t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	h, err := vfs.OpenFile("dir/file1", os.O_RDONLY, 0777)
+	require.NoError(t, err)
+	fh, ok := h.(*ReadFileHandle)
+	require.True(t, ok)
+	o := &flakyObject{Object: fh.o}
+	fh.o = o
+
+	// Sequential reads are served from the one open stream and its
+	// read ahead however small they are
+	var got []byte
+	buf := make([]byte, 16)
+	reads := 0
+	for len(got) < len(contents)/2 {
+		n, err := fh.Read(buf)
+		require.NoError(t, err)
+		got = append(got, buf[:n]...)
+		reads++
+	}
+	assert.Equal(t, contents[:len(got)], string(got))
+	assert.Equal(t, 2048, reads)
+	assert.Equal(t, 1, o.opens)
+
+	// Seeking re-opens the object once at the new offset
+	_, err = fh.Seek(3, 0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, contents[3+16*i:3+16*(i+1)], readString(t, fh, 16))
+	}
+	assert.Equal(t, 2, o.opens)
+
+	require.NoError(t, fh.Close())
+}
@@ -0,0 +1,65 @@
+// Sizes of the file system for statfs
+
+package vfs
+
+import (
+	"time"
+
+	"github.com/ncw/rclone/fs"
+)
+
+// Statfs returns the total, used and free space on the remote in
+// bytes, or -1 for any which aren't known.
+//
+// The quota from the remote's About is used if it has one, otherwise
+// the total is --vfs-disk-space-total-size if set.  About is only
+// called once every --dir-cache-time.
+func (vfs *VFS) Statfs() (total, used, free int64) {
+	total, used, free = -1, -1, -1
+	if do := vfs.f.Features().About; do != nil {
+		vfs.usageMu.Lock()
+		if vfs.usageTime.IsZero() || time.Since(vfs.usageTime) >= vfs.dirCacheTime() {
+			usage, err := do()
+			if err != nil {
+				fs.Errorf(vfs.f, "Statfs failed to read quota: %v", err)
+			} else {
+				vfs.usage = usage
+			}
+			vfs.usageTime = time.Now()
+		}
+		if u := vfs.usage; u != nil {
+			if u.Total != nil {
+				total = *u.Total
+			}
+			if u.Used != nil {
+				used = *u.Used
+			}
+			if u.Free != nil {
+				free = *u.Free
+			}
+		}
+		vfs.usageMu.Unlock()
+	}
+	if total < 0 && used >= 0 && free >= 0 {
+		total = used + free
+	}
+	if total < 0 && vfs.Opt.DiskSpaceTotal > 0 {
+		total = int64(vfs.Opt.DiskSpaceTotal)
+	}
+	// Work out whichever of used and free is missing from the total
+	if total >= 0 {
+		if used < 0 && free >= 0 {
+			used = total - free
+		}
+		if used < 0 {
+			used = 0
+		}
+		if free < 0 {
+			free = total - used
+		}
+		if free < 0 {
+			free = 0
+		}
+	}
+	return total, used, free
+}
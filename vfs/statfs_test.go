@@ -0,0 +1,81 @@
+package vfs
+
+import (
+	"testing"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/fstest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// aboutFs is an fs.Fs whose About returns usage, or err if set, or
+// which has no About if noAbout is set
+type aboutFs struct {
+	fs.Fs
+	noAbout bool
+	usage   fs.Usage
+	err     error
+	calls   int
+}
+
+func (f *aboutFs) Features() *fs.Features {
+	features := *f.Fs.Features()
+	features.About = f.about
+	if f.noAbout {
+		features.About = nil
+	}
+	return &features
+}
+
+func (f *aboutFs) about() (*fs.Usage, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	usage := f.usage
+	return &usage, nil
+}
+
+func TestVFSStatfs(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	i64 := func(i int64) *int64 { return &i }
+
+	// statfs returns the sizes from a new VFS on f
+	statfs := func(f *aboutFs, diskSpaceTotal fs.SizeSuffix) []int64 {
+		opt := DefaultOpt
+		opt.DiskSpaceTotal = diskSpaceTotal
+		total, used, free := New(f, &opt).Statfs()
+		return []int64{total, used, free}
+	}
+
+	// Nothing known
+	assert.Equal(t, []int64{-1, -1, -1}, statfs(&aboutFs{Fs: r.Fremote, noAbout: true}, 0))
+
+	// Size from the flag
+	assert.Equal(t, []int64{1 << 30, 0, 1 << 30}, statfs(&aboutFs{Fs: r.Fremote, noAbout: true}, 1<<30))
+
+	// Size from About is preferred to the flag
+	f := &aboutFs{Fs: r.Fremote, usage: fs.Usage{Total: i64(1000), Used: i64(400)}}
+	assert.Equal(t, []int64{1000, 400, 600}, statfs(f, 1<<30))
+	f = &aboutFs{Fs: r.Fremote, usage: fs.Usage{Used: i64(100), Free: i64(900)}}
+	assert.Equal(t, []int64{1000, 100, 900}, statfs(f, 1<<30))
+
+	// But used from About with the total from the flag
+	f = &aboutFs{Fs: r.Fremote, usage: fs.Usage{Used: i64(100)}}
+	assert.Equal(t, []int64{5000, 100, 4900}, statfs(f, 5000))
+
+	// About failing falls back to the flag
+	f = &aboutFs{Fs: r.Fremote, err: errors.New("no quota")}
+	assert.Equal(t, []int64{5000, 0, 5000}, statfs(f, 5000))
+
+	// About is cached for --dir-cache-time
+	f = &aboutFs{Fs: r.Fremote, usage: fs.Usage{Total: i64(1000), Free: i64(1000)}}
+	vfs := New(f, nil)
+	for i := 0; i < 3; i++ {
+		total, used, free := vfs.Statfs()
+		assert.Equal(t, []int64{1000, 0, 1000}, []int64{total, used, free})
+	}
+	assert.Equal(t, 1, f.calls)
+}
@@ -23,10 +23,14 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
 )
 
 // DefaultOpt is the default values uses for Opt
@@ -42,6 +46,7 @@ var DefaultOpt = Options{
 	GID:          ^uint32(0), // overriden for non windows in mount_unix.go
 	DirPerms:     os.FileMode(0777) | os.ModeDir,
 	FilePerms:    os.FileMode(0666),
+	Consistency:  10 * time.Second,
 }
 
 // Node represents either a directory (*Dir) or a file (*File)
@@ -70,7 +75,7 @@ type Nodes []Node
 // Sort functions
 func (ns Nodes) Len() int           { return len(ns) }
 func (ns Nodes) Swap(i, j int)      { ns[i], ns[j] = ns[j], ns[i] }
-func (ns Nodes) Less(i, j int) bool { return ns[i].DirEntry().Remote() < ns[j].DirEntry().Remote() }
+func (ns Nodes) Less(i, j int) bool { return ns[i].Name() < ns[j].Name() }
 
 // Noder represents something which can return a node
 type Noder interface {
@@ -143,28 +148,77 @@ var (
 	_ Handle = (*ReadFileHandle)(nil)
 	_ Handle = (*WriteFileHandle)(nil)
 	_ Handle = (*DirHandle)(nil)
+	_ Handle = (*ControlHandle)(nil)
 )
 
 // VFS represents the top level filing system
 type VFS struct {
-	f    fs.Fs
-	root *Dir
-	Opt  Options
+	f         fs.Fs
+	root      *Dir
+	control   *control   // the control file if Opt.ControlFile is set
+	permRules []permRule // compiled Opt.PermRules
+	notifyMu  sync.Mutex
+	notify    []ChangeNotifyFunc // called when cached directories change
+	openFiles *openFiles         // files open on the remote if Opt.MaxOpenFiles is set
+	handles   *openHandles       // open file handles
+	metrics   *Metrics           // counters of operations - use atomic
+	dirCache  *dirCache          // cached directory listings if Opt.DirCacheMax is set
+	rootLen   int                // length of the path above the root on the remote, as counted in MaxPathLength
+	optMu     sync.Mutex         // protects the options which SetOptions changes and the following
+	bwLimiter *rate.Limiter      // bandwidth limit if Opt.BwLimit is set
+	names     NameTransformer    // transform of names if Opt.NameTransform is set
+	window    time.Duration      // Opt.Consistency if the remote is eventually consistent, or 0
+	usageMu   sync.Mutex         // protects the following
+	usage     *fs.Usage          // last result of About for Statfs
+	usageTime time.Time          // when usage was read
+	started   time.Time          // when the VFS was created
+	statusMu  sync.Mutex         // protects the following
+	lastOK    time.Time          // time of the last successful operation on the remote
+	Opt       Options
 }
 
 // Options is options for creating the vfs
 type Options struct {
-	NoSeek       bool          // don't allow seeking if set
-	NoChecksum   bool          // don't check checksums if set
-	ReadOnly     bool          // if set VFS is read only
-	NoModTime    bool          // don't read mod times for files
-	DirCacheTime time.Duration // how long to consider directory listing cache valid
-	PollInterval time.Duration
-	Umask        int
-	UID          uint32
-	GID          uint32
-	DirPerms     os.FileMode
-	FilePerms    os.FileMode
+	NoSeek          bool          // don't allow seeking if set
+	NoChecksum      bool          // don't check checksums if set
+	ReadOnly        bool          // if set VFS is read only
+	NoModTime       bool          // don't read mod times for files
+	DirCacheTime    time.Duration // how long to consider directory listing cache valid
+	PollInterval    time.Duration
+	Umask           int
+	UID             uint32
+	GID             uint32
+	DirPerms        os.FileMode
+	FilePerms       os.FileMode
+	ControlFile     bool          // if set expose a control file at .rclone/command
+	ServeStale      bool          // if set serve stale directory listings if the remote fails
+	ReadRetries     int           // number of times to retry a failed read on an open file, or 0 to use --low-level-retries
+	ReadAhead       fs.SizeSuffix // bytes to read ahead of reads, or 0 to use --buffer-size
+	CaseInsensitive bool          // if set look up names ignoring case if there is no exact match
+	PermRules       PermRules     // permissions for paths matching globs instead of DirPerms/FilePerms
+	WriteBackSync   bool          // if set check the hash of uploads when files are closed
+	MaxOpenFiles    int           // max number of files open on the remote, or 0 for no limit
+	DirCacheMax     int           // max number of directory listings to cache, or 0 for no limit
+	BwLimit         fs.SizeSuffix // bandwidth limit in bytes/s for reads and writes, or 0 for no limit
+	DryRun          bool          // if set log changes to the remote instead of making them
+	LinkFiles       bool          // if set store symlinks as .rclonelink files on the remote
+	ChunkSize       fs.SizeSuffix // chunk size for uploads to remotes which upload in chunks, or 0 for their default
+	IdleTimeout     time.Duration // close handles which haven't been read or written for this long, or 0 to leave them open
+	NameTransform   NameTransform // transform of names between the remote and the VFS, or "" for none
+	Consistency     time.Duration // don't re-list directories changed this recently on eventually consistent remotes
+	DiskSpaceTotal  fs.SizeSuffix // total size to report in statfs if the remote has no quota, or 0 for none
+	UseTrash        bool          // if set send removed files to the remote's trash if it has one
+	NoMimeSniff     bool          // don't detect the mime type of uploads from their contents
+	CryptPath       string        // path on the remote whose files are encrypted, or "" for none
+	CryptRemote     string        // crypt remote in the config file with the passwords for CryptPath
+}
+
+// maskPerms masks the permissions with the umask and makes sure
+// directories are returned as directories
+func (opt *Options) maskPerms() {
+	opt.DirPerms &= ^os.FileMode(opt.Umask)
+	opt.FilePerms &= ^os.FileMode(opt.Umask)
+	opt.DirPerms |= os.ModeDir
 }
 
 // New creates a new VFS and root directory.  If opt is nil, then
@@ -172,7 +226,7 @@ type Options struct {
 func New(f fs.Fs, opt *Options) *VFS {
 	fsDir := fs.NewDir("", time.Now())
 	vfs := &VFS{
-		f: f,
+		started: time.Now(),
 	}
 
 	// Make a copy of the options
@@ -182,20 +236,51 @@ func New(f fs.Fs, opt *Options) *VFS {
 		vfs.Opt = DefaultOpt
 	}
 
-	// Mask the permissions with the umask
-	vfs.Opt.DirPerms &= ^os.FileMode(vfs.Opt.Umask)
-	vfs.Opt.FilePerms &= ^os.FileMode(vfs.Opt.Umask)
+	// Stop any changes to the remote if required
+	if vfs.Opt.DryRun {
+		f = newDryRunFs(f)
+	}
+
+	// Encrypt the files in --vfs-crypt-path if required
+	if vfs.Opt.CryptPath != "" {
+		cipher, err := vfs.Opt.newCipher()
+		if err != nil {
+			fs.Errorf(nil, "Files in %q can't be used: %v", vfs.Opt.CryptPath, err)
+		}
+		f = newCryptFs(f, vfs.Opt.CryptPath, cipher, err)
+	}
+	vfs.f = f
+	vfs.rootLen = rootPathLength(f)
 
-	// Make sure directories are returned as directories
-	vfs.Opt.DirPerms |= os.ModeDir
+	vfs.Opt.maskPerms()
+	vfs.permRules = compilePermRules(vfs.Opt.PermRules)
+	vfs.openFiles = newOpenFiles(vfs.Opt.MaxOpenFiles)
+	vfs.handles = newOpenHandles(vfs.Opt.IdleTimeout)
+	vfs.metrics = new(Metrics)
+	vfs.dirCache = newDirCache(vfs.Opt.DirCacheMax)
+	vfs.bwLimiter = newBwLimiter(vfs.Opt.BwLimit)
+	if f.Features().EventuallyConsistent {
+		vfs.window = vfs.Opt.Consistency
+	}
+	if vfs.Opt.NameTransform != "" {
+		vfs.names = nameTransforms[string(vfs.Opt.NameTransform)]
+		if vfs.names == nil {
+			fs.Errorf(nil, "Ignoring unknown name transform %q", vfs.Opt.NameTransform)
+		}
+	}
 
 	// Create root directory
 	vfs.root = newDir(vfs, f, nil, fsDir)
 
+	// Create the control file if required
+	if vfs.Opt.ControlFile {
+		vfs.control = newControl(vfs)
+	}
+
 	// Start polling if required
 	if vfs.Opt.PollInterval > 0 {
 		if do := vfs.f.Features().DirChangeNotify; do != nil {
-			do(vfs.root.ForgetPath, vfs.Opt.PollInterval)
+			do(vfs.changeNotify, vfs.Opt.PollInterval)
 		}
 	}
 	return vfs
@@ -261,7 +346,11 @@ func (vfs *VFS) StatParent(name string) (dir *Dir, leaf string, err error) {
 }
 
 // OpenFile a file according to the flags and perm provided
+//
+// If flags has O_CREATE and O_EXCL then it returns EEXIST if the file
+// exists - see Dir.CreateExclusive for the limits of this.
 func (vfs *VFS) OpenFile(name string, flags int, perm os.FileMode) (fd Handle, err error) {
+	exclusive := flags&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL
 	node, err := vfs.Stat(name)
 	if err != nil {
 		if err == ENOENT && flags&os.O_CREATE != 0 {
@@ -269,14 +358,63 @@ func (vfs *VFS) OpenFile(name string, flags int, perm os.FileMode) (fd Handle, e
 			if err != nil {
 				return nil, err
 			}
-			_, fd, err = dir.Create(leaf)
-			return fd, err
+			var fh *WriteFileHandle
+			if exclusive {
+				_, fh, err = dir.CreateExclusive(leaf)
+			} else {
+				_, fh, err = dir.Create(leaf)
+			}
+			if err != nil {
+				return nil, err
+			}
+			vfs.handles.setFlags(fh, flags)
+			return fh, nil
 		}
 		return nil, err
 	}
+	if exclusive {
+		return nil, EEXIST
+	}
 	return node.Open(flags)
 }
 
+// Copy the file oldName to newName with a server side copy
+//
+// This is for duplicating whole files, eg for copy_file_range.  It
+// returns ENOSYS if the remote can't do server side copies, so the
+// caller can fall back to reading and writing the data.
+func (vfs *VFS) Copy(oldName, newName string) error {
+	oldDir, oldLeaf, err := vfs.StatParent(oldName)
+	if err != nil {
+		return err
+	}
+	newDir, newLeaf, err := vfs.StatParent(newName)
+	if err != nil {
+		return err
+	}
+	_, err = oldDir.Copy(oldLeaf, newLeaf, newDir)
+	return err
+}
+
+// Link makes newName a hard link to the file oldName
+//
+// As the remote can't share objects between paths the link is a
+// server side copy with its own inode number, so changes to one
+// aren't seen in the other.  It returns ENOSYS if the remote can't do
+// server side copies.
+func (vfs *VFS) Link(oldName, newName string) error {
+	oldDir, oldLeaf, err := vfs.StatParent(oldName)
+	if err != nil {
+		return err
+	}
+	newDir, newLeaf, err := vfs.StatParent(newName)
+	if err != nil {
+		return err
+	}
+	_, err = oldDir.Link(oldLeaf, newLeaf, newDir)
+	return err
+}
+
 // Rename oldName to newName
 func (vfs *VFS) Rename(oldName, newName string) error {
 	// find the parent directories
@@ -294,3 +432,82 @@ func (vfs *VFS) Rename(oldName, newName string) error {
 	}
 	return nil
 }
+
+// WarmCache reads the directory dir and the directories below it into
+// the directory cache so they can be listed without waiting for the
+// remote.  depth is the number of levels to read - 1 reads just dir
+// and a negative depth reads everything below it.
+//
+// Directories read less than --dir-cache-time ago aren't read again.
+// Cancel ctx to stop early.
+func (vfs *VFS) WarmCache(ctx context.Context, dir string, depth int) error {
+	node, err := vfs.Stat(dir)
+	if err != nil {
+		return err
+	}
+	d, ok := node.(*Dir)
+	if !ok {
+		return errors.Errorf("%q is not a directory", dir)
+	}
+	return d.warm(ctx, depth)
+}
+
+// CacheStats describes the contents of the directory cache
+type CacheStats struct {
+	Dirs      int           // number of directories with a cached listing
+	Entries   int           // number of entries in the cached listings
+	Uploading int           // number of files written but not yet uploaded
+	OldestAge time.Duration // age of the oldest cached listing
+}
+
+// CacheStats returns statistics about the directory cache.
+//
+// There is no file cache so these describe the cached directory
+// listings and the files still being uploaded.
+func (vfs *VFS) CacheStats() (stats CacheStats) {
+	now := time.Now()
+	vfs.root.walk("", func(d *Dir) {
+		if d.virtual {
+			return
+		}
+		stats.Uploading += len(d.pending)
+		if d.items == nil || d.read.IsZero() {
+			return
+		}
+		stats.Dirs++
+		stats.Entries += len(d.items)
+		if age := now.Sub(d.read); age > stats.OldestAge {
+			stats.OldestAge = age
+		}
+	})
+	return stats
+}
+
+// Health describes whether the VFS is working
+type Health struct {
+	Reachable bool          // set if the remote could be listed
+	Err       error         // the error listing the remote if not Reachable
+	Uptime    time.Duration // time since the VFS was created
+	LastOK    time.Time     // time of the last successful operation on the remote, zero if none
+}
+
+// Health checks the remote is reachable by listing the root, which
+// only contacts the remote if the cached listing has expired.  It is
+// intended for supervisors checking that a mount is live.
+func (vfs *VFS) Health() (health Health) {
+	_, err := vfs.root.ReadDirAll()
+	health.Reachable = err == nil
+	health.Err = err
+	health.Uptime = time.Since(vfs.started)
+	vfs.statusMu.Lock()
+	health.LastOK = vfs.lastOK
+	vfs.statusMu.Unlock()
+	return health
+}
+
+// markOK records that an operation on the remote succeeded
+func (vfs *VFS) markOK() {
+	vfs.statusMu.Lock()
+	vfs.lastOK = time.Now()
+	vfs.statusMu.Unlock()
+}
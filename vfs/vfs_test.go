@@ -4,8 +4,11 @@ package vfs
 
 import (
 	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/ncw/rclone/fs"
 	_ "github.com/ncw/rclone/fs/all" // import all the file systems
 	"github.com/ncw/rclone/fstest"
 	"github.com/stretchr/testify/assert"
@@ -226,6 +229,60 @@ func TestVFSOpenFile(t *testing.T) {
 	assert.Nil(t, fd)
 }
 
+func TestVFSOpenFileExclusive(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs := New(r.Fremote, nil)
+	const flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+
+	// The second create should fail while the first is open and
+	// once it has been uploaded
+	fd, err := vfs.OpenFile("lock", flags, 0777)
+	require.NoError(t, err)
+	_, err = vfs.OpenFile("lock", flags, 0777)
+	assert.Equal(t, EEXIST, err)
+	require.NoError(t, fd.Close())
+	_, err = vfs.OpenFile("lock", flags, 0777)
+	assert.Equal(t, EEXIST, err)
+
+	// Files created on the remote after the directory was read
+	// should be found
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	_, err = root.ReadDirAll()
+	require.NoError(t, err)
+	r.WriteObject("lock2", "contents", t1)
+	_, err = vfs.OpenFile("lock2", flags, 0777)
+	assert.Equal(t, EEXIST, err)
+
+	// Only one of many exclusive creates should succeed
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fd, err := vfs.OpenFile("lock3", flags, 0777)
+			if err == nil {
+				err = fd.Close()
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.Equal(t, EEXIST, err)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+}
+
 func TestVFSRename(t *testing.T) {
 	r := fstest.NewRun(t)
 	defer r.Finalise()
@@ -250,3 +307,211 @@ func TestVFSRename(t *testing.T) {
 	err = vfs.Rename("file0", "not found/file0")
 	assert.Equal(t, os.ErrNotExist, err)
 }
+
+func TestVFSCacheStats(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("file1", "file1 contents", t1)
+	file2 := r.WriteObject("dir/file2", "file2 contents", t2)
+	fstest.CheckItems(t, r.Fremote, file1, file2)
+
+	vfs := New(r.Fremote, nil)
+	assert.Equal(t, CacheStats{}, vfs.CacheStats())
+
+	_, err := vfs.Stat("dir/file2")
+	require.NoError(t, err)
+
+	fd, err := vfs.OpenFile("dir/file3", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+
+	stats := vfs.CacheStats()
+	assert.Equal(t, 2, stats.Dirs)
+	assert.Equal(t, 4, stats.Entries)
+	assert.Equal(t, 1, stats.Uploading)
+	assert.True(t, stats.OldestAge > 0)
+
+	require.NoError(t, fd.Close())
+	stats = vfs.CacheStats()
+	assert.Equal(t, 0, stats.Uploading)
+	assert.Equal(t, 4, stats.Entries)
+
+	vfs.root.ForgetAll()
+	assert.Equal(t, CacheStats{}, vfs.CacheStats())
+}
+
+func TestVFSReadOnly(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir/file1", "file1 contents", t1)
+	r.WriteObject("dir2/file2", "file2 contents", t2)
+
+	opt := DefaultOpt
+	opt.ReadOnly = true
+	vfs := New(r.Fremote, &opt)
+
+	node, err := vfs.Stat("dir/file1")
+	require.NoError(t, err)
+	file := node.(*File)
+	node, err = vfs.Stat("dir")
+	require.NoError(t, err)
+	dir := node.(*Dir)
+
+	for _, test := range []struct {
+		what string
+		fn   func() error
+	}{
+		{"open for write", func() error { _, err := vfs.OpenFile("dir/file1", os.O_WRONLY, 0); return err }},
+		{"open for truncate", func() error { _, err := vfs.OpenFile("dir/file1", os.O_RDWR|os.O_TRUNC, 0); return err }},
+		{"open for append", func() error { _, err := vfs.OpenFile("dir/file1", os.O_WRONLY|os.O_APPEND, 0); return err }},
+		{"create", func() error { _, err := vfs.OpenFile("dir/new", os.O_WRONLY|os.O_CREATE, 0); return err }},
+		{"dir create", func() error { _, _, err := dir.Create("new"); return err }},
+		{"mkdir", func() error { _, err := dir.Mkdir("new"); return err }},
+		{"file remove", file.Remove},
+		{"file remove all", file.RemoveAll},
+		{"dir remove", dir.Remove},
+		{"dir remove all", dir.RemoveAll},
+		{"remove name", func() error { return dir.RemoveName("file1") }},
+		{"rename file", func() error { return vfs.Rename("dir/file1", "dir/file3") }},
+		{"rename dir", func() error { return vfs.Rename("dir", "dir3") }},
+		{"file set modtime", func() error { return file.SetModTime(t3) }},
+		{"dir set modtime", func() error { return dir.SetModTime(t3) }},
+	} {
+		assert.Equal(t, EROFS, test.fn(), test.what)
+	}
+
+	// Check reading still works and nothing changed
+	fd, err := vfs.OpenFile("dir/file1", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, fstest.NewItem("dir2/file2", "file2 contents", t2)}, []string{"dir", "dir2"}, r.Fremote.Precision())
+}
+
+func TestVFSHealth(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteObject("file1", "file1 contents", t1)
+
+	f := &failFs{Fs: r.Fremote}
+	opt := DefaultOpt
+	opt.DirCacheTime = 0
+	vfs := New(f, &opt)
+	assert.True(t, vfs.lastOK.IsZero())
+
+	health := vfs.Health()
+	assert.True(t, health.Reachable)
+	assert.NoError(t, health.Err)
+	assert.True(t, health.Uptime > 0)
+	assert.WithinDuration(t, time.Now(), health.LastOK, 10*time.Second)
+
+	// Check it notices the remote failing
+	f.setFail(true)
+	lastOK := health.LastOK
+	health = vfs.Health()
+	assert.False(t, health.Reachable)
+	assert.Error(t, health.Err)
+	assert.Equal(t, lastOK, health.LastOK)
+
+	// and recovering
+	f.setFail(false)
+	health = vfs.Health()
+	assert.True(t, health.Reachable)
+	assert.True(t, !health.LastOK.Before(lastOK))
+}
+
+func TestVFSFilters(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	film := r.WriteObject("dir/film.mp4", "film", t1)
+	photo := r.WriteObject("dir/photo.jpg", "photo", t2)
+
+	oldFilter := fs.Config.Filter
+	defer func() { fs.Config.Filter = oldFilter }()
+	var err error
+	fs.Config.Filter, err = fs.NewFilter()
+	require.NoError(t, err)
+	require.NoError(t, fs.Config.Filter.AddRule("+ *.mp4"))
+	require.NoError(t, fs.Config.Filter.AddRule("- *"))
+	vfs := New(r.Fremote, nil)
+
+	// Only the matching files are visible
+	node, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	nodes, err := node.(*Dir).ReadDirAll()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(nodes))
+	assert.Equal(t, "film.mp4", nodes[0].Name())
+	_, err = vfs.OpenFile("dir/photo.jpg", os.O_RDONLY, 0)
+	assert.Equal(t, ENOENT, err)
+
+	// Excluded names can't be created or renamed to
+	_, err = vfs.OpenFile("dir/new.jpg", os.O_WRONLY|os.O_CREATE, 0777)
+	assert.Equal(t, EPERM, err)
+	_, err = vfs.OpenFile("dir/photo.jpg", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0777)
+	assert.Equal(t, EEXIST, err)
+	assert.Equal(t, EPERM, vfs.Rename("dir/film.mp4", "dir/film.jpg"))
+
+	// But matching ones can
+	fd, err := vfs.OpenFile("dir/new.mp4", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = fd.Write([]byte("new"))
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+
+	fs.Config.Filter = oldFilter
+	newFilm := fstest.NewItem("dir/new.mp4", "new", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{film, photo, newFilm}, nil, fs.ModTimeNotSupported)
+}
+
+// limitFs is an Fs with limits on the length of names and paths
+type limitFs struct {
+	fs.Fs
+	features *fs.Features
+	root     string
+}
+
+// Root returns the root set in the limitFs
+func (f *limitFs) Root() string {
+	return f.root
+}
+
+// Features returns the features of the Fs with the limits set
+func (f *limitFs) Features() *fs.Features {
+	return f.features
+}
+
+func TestVFSNameLength(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteObject("dir/file1", "file1", t1)
+
+	features := *r.Fremote.Features()
+	features.MaxNameLength = 10
+	features.MaxPathLength = 19
+	features.BucketBased = true
+	vfs := New(&limitFs{Fs: r.Fremote, features: &features, root: "bucket/prefix"}, nil)
+	root, err := vfs.Root()
+	require.NoError(t, err)
+
+	// Names which are too long fail straight away
+	_, err = vfs.OpenFile("dir/file-name-too-long", os.O_WRONLY|os.O_CREATE, 0777)
+	assert.Equal(t, ENAMETOOLONG, err)
+	_, err = vfs.OpenFile("dir/file23456", os.O_WRONLY|os.O_CREATE, 0777)
+	assert.Equal(t, ENAMETOOLONG, err)
+	_, err = root.Mkdir("directory-name")
+	assert.Equal(t, ENAMETOOLONG, err)
+	assert.Equal(t, ENAMETOOLONG, vfs.Rename("dir/file1", "dir/file-name-too-long"))
+	assert.Equal(t, ENAMETOOLONG, vfs.Rename("dir", "directory-name"))
+	assert.Equal(t, ENAMETOOLONG, vfs.Rename("dir", "dir5678"))
+
+	// Ones which fit are fine
+	fd, err := vfs.OpenFile("dir/file2", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = fd.Write([]byte("file2"))
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	_, err = root.Mkdir("dir2")
+	require.NoError(t, err)
+
+	file2 := fstest.NewItem("dir/file2", "file2", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2}, []string{"dir", "dir2"}, fs.ModTimeNotSupported)
+}
@@ -2,22 +2,99 @@
 package vfsflags
 
 import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/ncw/rclone/fs"
 	"github.com/ncw/rclone/vfs"
+	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 )
 
 // Options set by command line flags
 var (
-	Opt = vfs.DefaultOpt
+	Opt         = vfs.DefaultOpt
+	OptionsFile = ""
 )
 
 // AddFlags adds the non filing system specific flags to the command
 func AddFlags(flags *pflag.FlagSet) {
-	flags.BoolVarP(&Opt.NoModTime, "no-modtime", "", Opt.NoModTime, "Don't read/write the modification time (can speed things up).")
-	flags.BoolVarP(&Opt.NoChecksum, "no-checksum", "", Opt.NoChecksum, "Don't compare checksums on up/download.")
-	flags.BoolVarP(&Opt.NoSeek, "no-seek", "", Opt.NoSeek, "Don't allow seeking in files.")
-	flags.DurationVarP(&Opt.DirCacheTime, "dir-cache-time", "", Opt.DirCacheTime, "Time to cache directory entries for.")
-	flags.DurationVarP(&Opt.PollInterval, "poll-interval", "", Opt.PollInterval, "Time to wait between polling for changes. Must be smaller than dir-cache-time. Only on supported remotes. Set to 0 to disable.")
-	flags.BoolVarP(&Opt.ReadOnly, "read-only", "", Opt.ReadOnly, "Mount read-only.")
-	platformFlags(flags)
+	platformDefaults()
+	addFlags(flags, &Opt)
+	flags.StringVarP(&OptionsFile, "vfs-options-file", "", OptionsFile, "File of VFS flags to read on top of the command line ones when sent SIGHUP.")
+}
+
+// addFlags adds the flags which set opt
+func addFlags(flags *pflag.FlagSet, opt *vfs.Options) {
+	flags.BoolVarP(&opt.NoModTime, "no-modtime", "", opt.NoModTime, "Don't read/write the modification time (can speed things up).")
+	flags.BoolVarP(&opt.NoChecksum, "no-checksum", "", opt.NoChecksum, "Don't compare checksums on up/download.")
+	flags.BoolVarP(&opt.NoSeek, "no-seek", "", opt.NoSeek, "Don't allow seeking in files.")
+	flags.DurationVarP(&opt.DirCacheTime, "dir-cache-time", "", opt.DirCacheTime, "Time to cache directory entries for.")
+	flags.IntVarP(&opt.DirCacheMax, "dir-cache-max-entries", "", opt.DirCacheMax, "Max number of directory listings to cache, forgetting the least recently used. 0 is unlimited.")
+	flags.DurationVarP(&opt.PollInterval, "poll-interval", "", opt.PollInterval, "Time to wait between polling for changes. Must be smaller than dir-cache-time. Only on supported remotes. Set to 0 to disable.")
+	flags.BoolVarP(&opt.ReadOnly, "read-only", "", opt.ReadOnly, "Only allow read-only access.")
+	flags.BoolVarP(&opt.ControlFile, "control-file", "", opt.ControlFile, "Expose a control file at .rclone/command for runtime commands.")
+	flags.BoolVarP(&opt.ServeStale, "vfs-serve-stale-on-error", "", opt.ServeStale, "Serve cached directory listings if the remote fails to list.")
+	flags.IntVarP(&opt.ReadRetries, "vfs-read-retries", "", opt.ReadRetries, "Number of times to retry a failed read on an open file with backoff, if not set uses --low-level-retries.")
+	flags.VarP(&opt.ReadAhead, "vfs-read-ahead", "", "Bytes to read ahead of reads on open files, if not set uses --buffer-size.")
+	flags.BoolVarP(&opt.CaseInsensitive, "vfs-case-insensitive", "", opt.CaseInsensitive, "If a file name isn't found look it up ignoring case.")
+	flags.VarP(&opt.PermRules, "vfs-perms", "", "Permissions for files and directories matching a glob, eg bin/**=0755 - can be repeated.")
+	flags.BoolVarP(&opt.WriteBackSync, "vfs-write-back-sync", "", opt.WriteBackSync, "Check the size and hash of the upload when a file is closed, returning an error if they don't match.")
+	flags.VarP(&opt.BwLimit, "vfs-bwlimit", "", "Bandwidth limit in bytes/s for this VFS as well as any --bwlimit.")
+	flags.VarP(&opt.ChunkSize, "vfs-upload-chunk-size", "", "Chunk size for uploads to remotes which upload in chunks (currently S3). 0 uses the remote's default.")
+	flags.BoolVarP(&opt.NoMimeSniff, "vfs-no-mime-sniff", "", opt.NoMimeSniff, "Don't detect the mime type of uploaded files from their contents if their extension doesn't give one.")
+	flags.StringVarP(&opt.CryptPath, "vfs-crypt-path", "", opt.CryptPath, "Encrypt the files in this path on the remote with the passwords of --vfs-crypt-remote.")
+	flags.StringVarP(&opt.CryptRemote, "vfs-crypt-remote", "", opt.CryptRemote, "Crypt remote in the config file whose passwords are used for --vfs-crypt-path.")
+	flags.BoolVarP(&opt.UseTrash, "vfs-use-trash", "", opt.UseTrash, "Send deleted files to the remote's trash or recycle bin if it has one.")
+	flags.BoolVarP(&opt.DryRun, "vfs-dry-run", "", opt.DryRun, "Log changes to the remote instead of making them.")
+	flags.BoolVarP(&opt.LinkFiles, "vfs-links", "", opt.LinkFiles, "Store symlinks as files with the target in ending .rclonelink on the remote.")
+	flags.DurationVarP(&opt.Consistency, "vfs-consistency-window", "", opt.Consistency, "Don't re-list directories changed through the mount this recently on eventually consistent remotes (eg S3).")
+	flags.VarP(&opt.DiskSpaceTotal, "vfs-disk-space-total-size", "", "Total size of the file system to report if the remote doesn't have a quota.")
+	flags.VarP(&opt.NameTransform, "vfs-name-transform", "", "Transform names between the remote and the mount, eg windows to replace characters Windows doesn't allow.")
+	flags.DurationVarP(&opt.IdleTimeout, "vfs-handle-idle-timeout", "", opt.IdleTimeout, "Close open files which haven't been read or written for this long, uploading any written. 0 leaves them open.")
+	flags.IntVarP(&opt.MaxOpenFiles, "vfs-max-open-files", "", opt.MaxOpenFiles, "Max number of files open on the remote at once, closing idle ones if exceeded. 0 is unlimited.")
+	platformFlags(flags, opt)
+}
+
+// Reload reads OptionsFile if set and changes the options of v
+// which can be changed while it is running to the ones in it.
+func Reload(v *vfs.VFS) {
+	if OptionsFile == "" {
+		return
+	}
+	opt, err := readOptionsFile(OptionsFile)
+	if err != nil {
+		fs.Errorf(nil, "Failed to reload VFS options: %v", err)
+		return
+	}
+	v.SetOptions(&opt)
+}
+
+// readOptionsFile reads the flags in path, one or more to a line, and
+// returns the options they set on top of the command line ones.
+// Blank lines and lines starting with # are ignored.
+func readOptionsFile(path string) (opt vfs.Options, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return opt, err
+	}
+	var args []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args = append(args, strings.Fields(line)...)
+	}
+	opt = Opt
+	flags := pflag.NewFlagSet(path, pflag.ContinueOnError)
+	addFlags(flags, &opt)
+	err = flags.Parse(args)
+	if err != nil {
+		return opt, errors.Wrapf(err, "failed to parse %q", path)
+	}
+	if flags.NArg() != 0 {
+		return opt, errors.Errorf("unexpected arguments %q in %q", flags.Args(), path)
+	}
+	return opt, nil
 }
@@ -3,9 +3,14 @@
 package vfsflags
 
 import (
+	"github.com/ncw/rclone/vfs"
 	"github.com/spf13/pflag"
 )
 
+// set the defaults of any platform specific options
+func platformDefaults() {
+}
+
 // add any extra platform specific flags
-func platformFlags(flags *pflag.FlagSet) {
+func platformFlags(flags *pflag.FlagSet, opt *vfs.Options) {
 }
@@ -0,0 +1,83 @@
+package vfsflags
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ncw/rclone/fs"
+	_ "github.com/ncw/rclone/fs/all" // import all the file systems
+	"github.com/ncw/rclone/fstest"
+	"github.com/ncw/rclone/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain drives the tests
+func TestMain(m *testing.M) {
+	fstest.TestMain(m)
+}
+
+// writeOptionsFile writes contents to an options file in a temporary
+// directory, setting OptionsFile to it, and returns a function to
+// tidy up
+func writeOptionsFile(t *testing.T, contents string) func() {
+	dir, err := ioutil.TempDir("", "rclone-vfsflags-test")
+	require.NoError(t, err)
+	OptionsFile = filepath.Join(dir, "options")
+	require.NoError(t, ioutil.WriteFile(OptionsFile, []byte(contents), 0600))
+	return func() {
+		OptionsFile = ""
+		require.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestReadOptionsFile(t *testing.T) {
+	tidy := writeOptionsFile(t, `
+# Comment
+--dir-cache-time 1m
+--vfs-bwlimit=1M --read-only
+`)
+	defer tidy()
+
+	opt, err := readOptionsFile(OptionsFile)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, opt.DirCacheTime)
+	assert.Equal(t, fs.SizeSuffix(1024*1024), opt.BwLimit)
+	assert.True(t, opt.ReadOnly)
+
+	// Check the ones which aren't in the file are unchanged
+	assert.Equal(t, Opt.PollInterval, opt.PollInterval)
+	assert.Equal(t, Opt.Umask, opt.Umask)
+
+	// Check errors
+	require.NoError(t, ioutil.WriteFile(OptionsFile, []byte("--potato"), 0600))
+	_, err = readOptionsFile(OptionsFile)
+	assert.Error(t, err)
+	require.NoError(t, ioutil.WriteFile(OptionsFile, []byte("--read-only potato"), 0600))
+	_, err = readOptionsFile(OptionsFile)
+	assert.Error(t, err)
+	_, err = readOptionsFile(OptionsFile + "-notfound")
+	assert.Error(t, err)
+}
+
+func TestReload(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	v := vfs.New(r.Fremote, &Opt)
+
+	// Without an options file nothing changes
+	Reload(v)
+	assert.Equal(t, Opt.DirCacheTime, v.Opt.DirCacheTime)
+
+	tidy := writeOptionsFile(t, "--dir-cache-time 1m\n--vfs-bwlimit 1M\n--read-only\n")
+	defer tidy()
+	Reload(v)
+	assert.Equal(t, time.Minute, v.Opt.DirCacheTime)
+	assert.Equal(t, fs.SizeSuffix(1024*1024), v.Opt.BwLimit)
+
+	// --read-only can't be changed without a remount
+	assert.False(t, v.Opt.ReadOnly)
+}
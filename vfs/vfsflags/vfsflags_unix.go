@@ -3,17 +3,22 @@
 package vfsflags
 
 import (
+	"github.com/ncw/rclone/vfs"
 	"github.com/spf13/pflag"
 	"golang.org/x/sys/unix"
 )
 
-// add any extra platform specific flags
-func platformFlags(flags *pflag.FlagSet) {
-	flags.IntVarP(&Opt.Umask, "umask", "", Opt.Umask, "Override the permission bits set by the filesystem.")
+// set the defaults of any platform specific options
+func platformDefaults() {
 	Opt.Umask = unix.Umask(0) // read the umask
 	unix.Umask(Opt.Umask)     // set it back to what it was
 	Opt.UID = uint32(unix.Geteuid())
 	Opt.GID = uint32(unix.Getegid())
-	flags.Uint32VarP(&Opt.UID, "uid", "", Opt.UID, "Override the uid field set by the filesystem.")
-	flags.Uint32VarP(&Opt.GID, "gid", "", Opt.GID, "Override the gid field set by the filesystem.")
+}
+
+// add any extra platform specific flags
+func platformFlags(flags *pflag.FlagSet, opt *vfs.Options) {
+	flags.IntVarP(&opt.Umask, "umask", "", opt.Umask, "Override the permission bits set by the filesystem.")
+	flags.Uint32VarP(&opt.UID, "uid", "", opt.UID, "Override the uid field set by the filesystem.")
+	flags.Uint32VarP(&opt.GID, "gid", "", opt.GID, "Override the gid field set by the filesystem.")
 }
@@ -1,12 +1,15 @@
 package vfs
 
 import (
+	"bytes"
 	"io"
+	"net/http"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
 )
 
 // WriteFileHandle is an open for write handle on a File
@@ -21,6 +24,8 @@ type WriteFileHandle struct {
 	file        *File
 	writeCalled bool // set the first time Write() is called
 	offset      int64
+	hash        *fs.MultiHasher // hash of the data written if --vfs-write-back-sync
+	used        time.Time       // when the handle was last written to
 }
 
 // Check interfaces
@@ -35,12 +40,39 @@ func newWriteFileHandle(d *Dir, f *File, src fs.ObjectInfo) (*WriteFileHandle, e
 		remote: src.Remote(),
 		result: make(chan error, 1),
 		file:   f,
+		used:   time.Now(),
+	}
+	err := d.vfs.openFiles.add(fh)
+	if err != nil {
+		return nil, err
+	}
+	if d.vfs.Opt.WriteBackSync {
+		hashes := d.f.Hashes()
+		if hashes.Count() == 0 {
+			fs.Debugf(fh.remote, "Remote has no hashes so only checking size of upload")
+		}
+		fh.hash, err = fs.NewMultiHasherTypes(hashes)
+		if err != nil {
+			fs.Errorf(d.f, "newWriteFileHandle hash error: %v", err)
+		}
+	}
+	var options []fs.OpenOption
+	if d.vfs.Opt.ChunkSize > 0 {
+		options = append(options, &fs.ChunkOption{ChunkSize: int64(d.vfs.Opt.ChunkSize)})
 	}
 	var pipeReader *io.PipeReader
 	pipeReader, fh.pipeWriter = io.Pipe()
 	go func() {
+		var in io.ReadCloser = pipeReader
+		if !d.vfs.Opt.NoMimeSniff {
+			var mimeType string
+			mimeType, in = sniffMimeType(src.Remote(), pipeReader)
+			if mimeType != "" {
+				options = append(options, &fs.MimeTypeOption{MimeType: mimeType})
+			}
+		}
 		// NB Rcat deals with Stats.Transferring etc
-		o, err := fs.Rcat(d.f, src.Remote(), pipeReader, time.Now())
+		o, err := fs.Rcat(d.f, src.Remote(), in, time.Now(), options...)
 		if err != nil {
 			fs.Errorf(fh.remote, "WriteFileHandle.New Rcat failed: %v", err)
 		}
@@ -49,11 +81,65 @@ func newWriteFileHandle(d *Dir, f *File, src fs.ObjectInfo) (*WriteFileHandle, e
 		fh.o = o
 		fh.result <- err
 	}()
-	fh.file.addWriters(1)
+	fh.file.addWriter(fh)
 	fh.file.setSize(0)
+	d.vfs.handles.add(fh, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	d.vfs.countOpen()
 	return fh, nil
 }
 
+// sniffMimeType detects the mime type of the data in if the extension
+// of remote doesn't give one.  It returns the mime type, or "" if it
+// wasn't detected, and a reader which reads all the data including the
+// part read to detect it.
+func sniffMimeType(remote string, in io.ReadCloser) (string, io.ReadCloser) {
+	if fs.MimeTypeFromName(remote) != "application/octet-stream" {
+		return "", in
+	}
+	buf := make([]byte, 512)
+	// Any error is returned again by the next read of in
+	n, _ := io.ReadFull(in, buf)
+	buf = buf[:n]
+	mimeType := ""
+	if n > 0 {
+		mimeType = http.DetectContentType(buf)
+		if mimeType == "application/octet-stream" {
+			mimeType = ""
+		}
+	}
+	return mimeType, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), in), in}
+}
+
+// info describes the handle - satisfies the infoHandle interface
+func (fh *WriteFileHandle) info() HandleInfo {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	return HandleInfo{
+		Path:  fh.file.String(),
+		Bytes: fh.offset,
+		Dirty: fh.writeCalled && !fh.closed,
+		Used:  fh.used,
+	}
+}
+
+// closeIdle closes the handle, finishing the upload, if it hasn't been
+// written to for timeout - satisfies the infoHandle interface
+func (fh *WriteFileHandle) closeIdle(timeout time.Duration) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.closed || time.Since(fh.used) < timeout {
+		return
+	}
+	fs.Infof(fh.remote, "Closing write handle idle for more than %v and uploading it", timeout)
+	err := fh.close()
+	if err != nil {
+		fs.Errorf(fh.remote, "Failed to close idle write handle: %v", err)
+	}
+}
+
 // String converts it to printable
 func (fh *WriteFileHandle) String() string {
 	if fh == nil {
@@ -104,13 +190,19 @@ func (fh *WriteFileHandle) writeAt(p []byte, off int64) (n int, err error) {
 		return 0, ESPIPE
 	}
 	fh.writeCalled = true
+	fh.file.d.vfs.limitBandwidth(len(p))
 	n, err = fh.pipeWriter.Write(p)
+	fh.file.d.vfs.countWrite(n)
+	fh.used = time.Now()
 	fh.offset += int64(n)
 	fh.file.setSize(fh.offset)
 	if err != nil {
 		fs.Errorf(fh.remote, "WriteFileHandle.Write error: %v", err)
 		return 0, err
 	}
+	if fh.hash != nil {
+		_, _ = fh.hash.Write(p[:n])
+	}
 	// fs.Debugf(fh.remote, "WriteFileHandle.Write OK (%d bytes written)", n)
 	return n, nil
 }
@@ -136,6 +228,37 @@ func (fh *WriteFileHandle) Offset() (offset int64) {
 	return fh.offset
 }
 
+// Truncate changes the size of the file being written.
+//
+// As the file is streamed to the remote it can only be extended by
+// writing zeros to the end of it, so truncating it to less than has
+// been written already returns EPERM.  Truncating to 0 before anything
+// has been written (as done by open followed by ftruncate) succeeds as
+// the size is unchanged.
+func (fh *WriteFileHandle) Truncate(size int64) (err error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.closed {
+		return ECLOSED
+	}
+	if size < fh.offset {
+		fs.Errorf(fh.remote, "WriteFileHandle.Truncate can't shrink file being written from %d to %d", fh.offset, size)
+		return EPERM
+	}
+	zeros := make([]byte, 64*1024)
+	for fh.offset < size {
+		n := size - fh.offset
+		if n > int64(len(zeros)) {
+			n = int64(len(zeros))
+		}
+		_, err = fh.writeAt(zeros[:n], fh.offset)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // close the file handle returning EBADF if it has been
 // closed already.
 //
@@ -145,16 +268,55 @@ func (fh *WriteFileHandle) close() error {
 		return ECLOSED
 	}
 	fh.closed = true
-	fh.file.addWriters(-1)
+	fh.file.d.vfs.openFiles.remove(fh)
+	fh.file.d.vfs.handles.remove(fh)
+	fh.file.delWriter(fh)
 	writeCloseErr := fh.pipeWriter.Close()
 	err := <-fh.result
 	if err == nil {
+		fh.file.d.vfs.markOK()
 		fh.file.setObject(fh.o)
 		err = writeCloseErr
+		if err == nil {
+			err = fh.checkUpload()
+		}
+	} else {
+		fh.file.writeFailed()
 	}
 	return err
 }
 
+// evict returns false as the upload can't be closed until the file
+// is - satisfies the openFile interface
+func (fh *WriteFileHandle) evict() bool {
+	return false
+}
+
+// checkUpload checks the size and hashes of the uploaded object match
+// the data written if --vfs-write-back-sync is set
+func (fh *WriteFileHandle) checkUpload() error {
+	if fh.hash == nil || fh.o == nil {
+		return nil
+	}
+	if size := fh.o.Size(); size >= 0 && size != fh.offset {
+		return errors.Errorf("corrupted on upload: size differs %d vs %d", fh.offset, size)
+	}
+	for hashType, srcSum := range fh.hash.Sums() {
+		dstSum, err := fh.o.Hash(hashType)
+		if err != nil {
+			return errors.Wrap(err, "failed to read hash of upload")
+		}
+		if dstSum == "" {
+			fs.Debugf(fh.remote, "Not checking %v hash of upload as remote didn't return one", hashType)
+			continue
+		}
+		if !fs.HashEquals(srcSum, dstSum) {
+			return errors.Errorf("corrupted on upload: %v hash differ %q vs %q", hashType, srcSum, dstSum)
+		}
+	}
+	return nil
+}
+
 // Close closes the file
 func (fh *WriteFileHandle) Close() error {
 	fh.mu.Lock()
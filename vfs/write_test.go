@@ -1,7 +1,9 @@
 package vfs
 
 import (
+	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/ncw/rclone/fs"
@@ -117,6 +119,45 @@ func TestWriteFileHandleWriteAt(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1}, []string{}, fs.ModTimeNotSupported)
 }
 
+func TestWriteFileHandleTruncate(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs, fh := writeHandleCreate(t, r)
+
+	// Truncate to the current size does nothing
+	require.NoError(t, fh.Truncate(0))
+
+	// Grow the file
+	require.NoError(t, fh.Truncate(3))
+	assert.Equal(t, int64(3), fh.Offset())
+	assert.Equal(t, int64(3), fh.Node().Size())
+
+	// Write after growing
+	_, err := fh.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	// Can't shrink
+	assert.Equal(t, EPERM, fh.Truncate(2))
+
+	// Grow again by more than a buffer full
+	require.NoError(t, fh.Truncate(100*1024))
+	assert.Equal(t, int64(100*1024), fh.Offset())
+
+	require.NoError(t, fh.Close())
+	assert.Equal(t, ECLOSED, fh.Truncate(200*1024))
+
+	// Check the remote size matches
+	node, err := vfs.Stat("file1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100*1024), node.Size())
+	o, err := r.Fremote.NewObject("file1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100*1024), o.Size())
+	contents, err := readObject(o)
+	require.NoError(t, err)
+	assert.Equal(t, "\x00\x00\x00hello\x00", string(contents[:9]))
+}
+
 func TestWriteFileHandleFlush(t *testing.T) {
 	r := fstest.NewRun(t)
 	defer r.Finalise()
@@ -158,3 +199,268 @@ func TestWriteFileHandleRelease(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, fh.closed)
 }
+
+func TestWriteFileHandleCreateEmpty(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs, fh := writeHandleCreate(t, r)
+
+	// Check the file is visible before anything is uploaded
+	node, err := vfs.Stat("file1")
+	require.NoError(t, err)
+	assert.True(t, node.IsFile())
+	assert.Equal(t, int64(0), node.Size())
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	checkListing(t, root, []string{"file1,0,false"})
+
+	// Check it stays visible when the directory cache is flushed
+	root.ForgetAll()
+	checkListing(t, root, []string{"file1,0,false"})
+
+	// Close without writing anything
+	assert.NoError(t, fh.Close())
+	checkListing(t, root, []string{"file1,0,false"})
+	assert.NotNil(t, node.DirEntry())
+
+	// Check the empty file was persisted
+	file1 := fstest.NewItem("file1", "", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1}, []string{}, fs.ModTimeNotSupported)
+
+	// Check it is still there after re-reading the directory
+	root.ForgetAll()
+	checkListing(t, root, []string{"file1,0,false"})
+}
+
+// Test the editor save pattern of writing a temporary file then
+// renaming it over the target
+func TestWriteFileHandleRenameWhileWriting(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	vfs := New(r.Fremote, nil)
+	if r.Fremote.Features().Move == nil {
+		t.Skip("Skipping test as remote doesn't support Move")
+	}
+
+	file1 := r.WriteObject("dir/target", "old contents", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+	dir, err := vfs.Stat("dir")
+	require.NoError(t, err)
+
+	h, err := vfs.OpenFile("dir/target.tmp", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	_, err = h.Write([]byte("new contents!"))
+	require.NoError(t, err)
+
+	// Rename over the target before the upload has finished
+	require.NoError(t, vfs.Rename("dir/target.tmp", "dir/target"))
+	checkListing(t, dir.(*Dir), []string{"target,13,false"})
+	_, err = vfs.Stat("dir/target.tmp")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	fs.Stats.ResetCounters()
+	require.NoError(t, h.Close())
+
+	// Check the data was only uploaded once then moved
+	assert.Equal(t, int64(1), fs.Stats.GetTransfers())
+	file1 = fstest.NewItem("dir/target", "new contents!", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1}, []string{"dir"}, fs.ModTimeNotSupported)
+
+	node, err := vfs.Stat("dir/target")
+	require.NoError(t, err)
+	assert.Equal(t, "dir/target", node.DirEntry().Remote())
+	checkListing(t, dir.(*Dir), []string{"target,13,false"})
+	dir.(*Dir).ForgetAll()
+	checkListing(t, dir.(*Dir), []string{"target,13,false"})
+}
+
+// badHashFs is an fs.Fs whose uploaded objects report the wrong hash
+type badHashFs struct {
+	fs.Fs
+}
+
+// badHashObject is an fs.Object which reports the wrong hash
+type badHashObject struct {
+	fs.Object
+}
+
+// Hash returns a hash which doesn't match the contents
+func (o *badHashObject) Hash(hashType fs.HashType) (string, error) {
+	return "bad", nil
+}
+
+// Put uploads the object returning a badHashObject
+func (f *badHashFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	o, err := f.Fs.Put(in, src, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &badHashObject{Object: o}, nil
+}
+
+func TestWriteFileHandleWriteBackSync(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if r.Fremote.Hashes().Count() == 0 {
+		t.Skip("Skipping test as remote doesn't support hashes")
+	}
+
+	opt := DefaultOpt
+	opt.WriteBackSync = true
+
+	write := func(f fs.Fs, name string) error {
+		vfs := New(f, &opt)
+		h, err := vfs.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0777)
+		require.NoError(t, err)
+		_, err = h.Write([]byte("hello"))
+		require.NoError(t, err)
+		return h.Close()
+	}
+
+	// Good uploads close without error
+	require.NoError(t, write(r.Fremote, "good"))
+
+	// Uploads with bad hashes return an error
+	err := write(&badHashFs{Fs: r.Fremote}, "bad")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "corrupted on upload")
+
+	// Without the flag they are not checked
+	opt.WriteBackSync = false
+	require.NoError(t, write(&badHashFs{Fs: r.Fremote}, "unchecked"))
+}
+
+// optionsFs is an fs.Fs which records the options uploads are given
+type optionsFs struct {
+	fs.Fs
+	options []fs.OpenOption
+}
+
+// Put uploads the object recording the options
+func (f *optionsFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	f.options = options
+	return f.Fs.Put(in, src, options...)
+}
+
+func TestWriteFileHandleChunkSize(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	opt := DefaultOpt
+
+	// chunkSize returns the ChunkOption given to the upload of
+	// name or 0 if there wasn't one
+	chunkSize := func(name string) int64 {
+		f := &optionsFs{Fs: r.Fremote}
+		vfs := New(f, &opt)
+		h, err := vfs.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0777)
+		require.NoError(t, err)
+		_, err = h.Write([]byte("hello"))
+		require.NoError(t, err)
+		require.NoError(t, h.Close())
+		for _, option := range f.options {
+			if x, ok := option.(*fs.ChunkOption); ok {
+				return x.ChunkSize
+			}
+		}
+		return 0
+	}
+
+	assert.Equal(t, int64(0), chunkSize("default"))
+	opt.ChunkSize = 64 * 1024 * 1024
+	assert.Equal(t, int64(64*1024*1024), chunkSize("chunked"))
+}
+
+// streamFs counts the uploads made with Put and PutStream
+type streamFs struct {
+	fs.Fs
+	puts    int
+	streams int
+}
+
+// Put uploads the object counting it
+func (f *streamFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	f.puts++
+	return f.Fs.Put(in, src, options...)
+}
+
+// Features returns the features of the remote with PutStream counting
+// the uploads
+func (f *streamFs) Features() *fs.Features {
+	features := *f.Fs.Features()
+	putStream := features.PutStream
+	features.PutStream = func(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+		f.streams++
+		return putStream(in, src, options...)
+	}
+	return &features
+}
+
+func TestWriteFileHandleStreams(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	f := &streamFs{Fs: r.Fremote}
+	vfs := New(f, nil)
+
+	// Write several times --streaming-upload-cutoff of unknown size
+	h, err := vfs.OpenFile("file1", os.O_WRONLY|os.O_CREATE, 0777)
+	require.NoError(t, err)
+	block := make([]byte, 64*1024)
+	size := 0
+	for size <= 3*int(fs.Config.StreamingUploadCutoff) {
+		n, err := h.Write(block)
+		require.NoError(t, err)
+		size += n
+	}
+	require.NoError(t, h.Close())
+
+	// It should have been streamed to the remote as it was written
+	// rather than buffered then uploaded with Put
+	assert.Equal(t, 1, f.streams)
+	assert.Equal(t, 0, f.puts)
+	node, err := vfs.Stat("file1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(size), node.Size())
+}
+
+// mimeTypeFs records the mime type of the uploads made with Put
+type mimeTypeFs struct {
+	fs.Fs
+	mimeType string
+}
+
+// Put uploads the object recording its mime type
+func (f *mimeTypeFs) Put(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	f.mimeType = fs.MimeType(src)
+	return f.Fs.Put(in, src, options...)
+}
+
+func TestWriteFileHandleMimeType(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	opt := DefaultOpt
+	png := "\x89PNG\x0D\x0A\x1A\x0A" + strings.Repeat("\x00", 100)
+
+	// mimeType returns the mime type given to the upload of name
+	// with contents
+	mimeType := func(name, contents string) string {
+		f := &mimeTypeFs{Fs: r.Fremote}
+		vfs := New(f, &opt)
+		h, err := vfs.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0777)
+		require.NoError(t, err)
+		_, err = h.Write([]byte(contents))
+		require.NoError(t, err)
+		require.NoError(t, h.Close())
+		return f.mimeType
+	}
+
+	assert.Equal(t, "image/png", mimeType("image.png", "not really a png"))
+	assert.Equal(t, "image/png", mimeType("image", png))
+	assert.Equal(t, "text/plain; charset=utf-8", mimeType("text", "hello"))
+	assert.Equal(t, "application/octet-stream", mimeType("empty", ""))
+
+	opt.NoMimeSniff = true
+	assert.Equal(t, "image/png", mimeType("image2.png", png))
+	assert.Equal(t, "application/octet-stream", mimeType("image2", png))
+}
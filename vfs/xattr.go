@@ -0,0 +1,105 @@
+// Extended attributes stored as metadata on the remote
+
+package vfs
+
+import (
+	"sort"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/pkg/errors"
+)
+
+// metadata returns the object for the file and its metadata,
+// returning ENOTSUP if the remote can't store metadata
+func (f *File) metadata() (fs.Object, map[string]string, error) {
+	o, err := f.waitForValidObject()
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata, err := fs.Metadata(o)
+	if errors.Cause(err) == fs.ErrorMetadataNotSupported {
+		return nil, nil, ENOTSUP
+	}
+	if err != nil {
+		fs.Errorf(f, "Failed to read metadata: %v", err)
+		return nil, nil, err
+	}
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	return o, metadata, nil
+}
+
+// setMetadata replaces the metadata of o, returning ENOTSUP if the
+// remote can't store metadata
+func (f *File) setMetadata(o fs.Object, metadata map[string]string) error {
+	err := fs.SetMetadata(o, metadata)
+	if errors.Cause(err) == fs.ErrorMetadataNotSupported {
+		return ENOTSUP
+	}
+	if err != nil {
+		fs.Errorf(f, "Failed to set metadata: %v", err)
+	}
+	return err
+}
+
+// Getxattr returns the value of the extended attribute name
+//
+// Extended attributes are stored as metadata on the remote.  This
+// returns ENOTSUP if the remote can't store metadata, or ENOATTR if
+// the attribute isn't set.
+func (f *File) Getxattr(name string) ([]byte, error) {
+	_, metadata, err := f.metadata()
+	if err != nil {
+		return nil, err
+	}
+	value, ok := metadata[name]
+	if !ok {
+		return nil, ENOATTR
+	}
+	return []byte(value), nil
+}
+
+// Listxattr returns the sorted names of the extended attributes
+func (f *File) Listxattr() ([]string, error) {
+	_, metadata, err := f.metadata()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(metadata))
+	for name := range metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Setxattr sets the extended attribute name to value
+func (f *File) Setxattr(name string, value []byte) error {
+	if f.d.vfs.Opt.ReadOnly {
+		return EROFS
+	}
+	o, metadata, err := f.metadata()
+	if err != nil {
+		return err
+	}
+	metadata[name] = string(value)
+	return f.setMetadata(o, metadata)
+}
+
+// Removexattr removes the extended attribute name, returning ENOATTR
+// if it isn't set
+func (f *File) Removexattr(name string) error {
+	if f.d.vfs.Opt.ReadOnly {
+		return EROFS
+	}
+	o, metadata, err := f.metadata()
+	if err != nil {
+		return err
+	}
+	if _, ok := metadata[name]; !ok {
+		return ENOATTR
+	}
+	delete(metadata, name)
+	return f.setMetadata(o, metadata)
+}
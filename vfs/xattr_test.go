@@ -0,0 +1,102 @@
+package vfs
+
+import (
+	"testing"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileXattr(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteObject("file1", "file1 contents", t1)
+	vfs := New(r.Fremote, nil)
+
+	node, err := vfs.Stat("file1")
+	require.NoError(t, err)
+	file := node.(*File)
+
+	err = file.Setxattr("user.potato", []byte("jersey royal"))
+	if err == ENOTSUP {
+		t.Skip("remote doesn't support metadata")
+	}
+	require.NoError(t, err)
+	require.NoError(t, file.Setxattr("user.apple", []byte("braeburn")))
+
+	value, err := file.Getxattr("user.potato")
+	require.NoError(t, err)
+	assert.Equal(t, "jersey royal", string(value))
+	names, err := file.Listxattr()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user.apple", "user.potato"}, names)
+
+	// Check it was stored on the remote
+	o, err := r.Fremote.NewObject("file1")
+	require.NoError(t, err)
+	metadata, err := fs.Metadata(o)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"user.apple": "braeburn", "user.potato": "jersey royal"}, metadata)
+
+	require.NoError(t, file.Removexattr("user.potato"))
+	_, err = file.Getxattr("user.potato")
+	assert.Equal(t, ENOATTR, err)
+	assert.Equal(t, ENOATTR, file.Removexattr("user.potato"))
+	names, err = file.Listxattr()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user.apple"}, names)
+
+	// Read only
+	opt := DefaultOpt
+	opt.ReadOnly = true
+	vfs = New(r.Fremote, &opt)
+	node, err = vfs.Stat("file1")
+	require.NoError(t, err)
+	file = node.(*File)
+	value, err = file.Getxattr("user.apple")
+	require.NoError(t, err)
+	assert.Equal(t, "braeburn", string(value))
+	assert.Equal(t, EROFS, file.Setxattr("user.potato", []byte("maris piper")))
+	assert.Equal(t, EROFS, file.Removexattr("user.apple"))
+}
+
+// noMetadataFs is an Fs whose objects can't store metadata
+type noMetadataFs struct {
+	fs.Fs
+}
+
+// noMetadataObject is an Object which can't store metadata
+type noMetadataObject struct {
+	fs.Object
+}
+
+// List the directory wrapping the objects
+func (f *noMetadataFs) List(dir string) (entries fs.DirEntries, err error) {
+	entries, err = f.Fs.List(dir)
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = &noMetadataObject{Object: o}
+		}
+	}
+	return entries, err
+}
+
+func TestFileXattrNotSupported(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteObject("file1", "file1 contents", t1)
+	vfs := New(&noMetadataFs{Fs: r.Fremote}, nil)
+
+	node, err := vfs.Stat("file1")
+	require.NoError(t, err)
+	file := node.(*File)
+
+	_, err = file.Getxattr("user.potato")
+	assert.Equal(t, ENOTSUP, err)
+	_, err = file.Listxattr()
+	assert.Equal(t, ENOTSUP, err)
+	assert.Equal(t, ENOTSUP, file.Setxattr("user.potato", []byte("jersey royal")))
+	assert.Equal(t, ENOTSUP, file.Removexattr("user.potato"))
+}
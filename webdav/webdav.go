@@ -26,6 +26,7 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ncw/rclone/fs"
@@ -39,6 +40,16 @@ const (
 	minSleep      = 10 * time.Millisecond
 	maxSleep      = 2 * time.Second
 	decayConstant = 2 // bigger for slower decay, exponential
+
+	// partialUpdateType is the Content-Type for a sabredav partial
+	// update, see http://sabre.io/dav/http-patch/
+	partialUpdateType = "application/x-sabredav-partialupdate"
+)
+
+// Globals
+var (
+	// Flags
+	chunkSize = fs.SizeSuffix(10 * 1024 * 1024)
 )
 
 // Register with Fs
@@ -78,8 +89,25 @@ func init() {
 			Help:       "Password.",
 			Optional:   true,
 			IsPassword: true,
+		}, {
+			Name:     "ca_cert",
+			Help:     "CA certificate file (PEM) to verify the server with - leave blank to use --ca-cert or the system ones.",
+			Optional: true,
+		}, {
+			Name:     "client_cert",
+			Help:     "Client certificate file (PEM) for mutual TLS - leave blank to use --client-cert.",
+			Optional: true,
+		}, {
+			Name:     "client_key",
+			Help:     "Client private key file (PEM) for client_cert - leave blank to use --client-key.",
+			Optional: true,
+		}, {
+			Name:     "no_check_certificate",
+			Help:     "Set to true to not verify the server's certificate. Insecure. Leave blank to use --no-check-certificate.",
+			Optional: true,
 		}},
 	})
+	fs.VarP(&chunkSize, "webdav-chunk-size", "", "Above this size files will be chunked if the server supports partial updates - 0 to disable.")
 }
 
 // Fs represents a remote webdav
@@ -97,6 +125,8 @@ type Fs struct {
 	precision   time.Duration // mod time precision
 	canStream   bool          // set if can stream
 	useOCMtime  bool          // set if can use X-OC-Mtime
+	patchOnce   sync.Once     // used to check for partial update support
+	canPatch    bool          // set if server supports sabredav partial updates
 }
 
 // Object describes a webdav object
@@ -268,13 +298,17 @@ func NewFs(name, root string) (fs.Fs, error) {
 	if err != nil {
 		return nil, err
 	}
+	client, err := fs.Config.ClientForRemote(name)
+	if err != nil {
+		return nil, err
+	}
 
 	f := &Fs{
 		name:        name,
 		root:        root,
 		endpoint:    u,
 		endpointURL: u.String(),
-		srv:         rest.NewClient(fs.Config.Client()).SetRoot(u.String()).SetUserPass(user, pass),
+		srv:         rest.NewClient(client).SetRoot(u.String()).SetUserPass(user, pass),
 		pacer:       pacer.New().SetMinSleep(minSleep).SetMaxSleep(maxSleep).SetDecayConstant(decayConstant),
 		user:        user,
 		pass:        pass,
@@ -879,6 +913,15 @@ func (o *Object) Update(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOptio
 	}
 
 	size := src.Size()
+	if chunkSize > 0 && size > int64(chunkSize) && o.fs.supportsPartialUpdate() {
+		err = o.uploadChunked(in, src, size)
+		if err != nil {
+			return err
+		}
+		// read metadata from remote
+		o.hasMetaData = false
+		return o.readMetaData()
+	}
 	var resp *http.Response
 	opts := rest.Opts{
 		Method:        "PUT",
@@ -904,6 +947,115 @@ func (o *Object) Update(in io.Reader, src fs.ObjectInfo, options ...fs.OpenOptio
 	return o.readMetaData()
 }
 
+// supportsPartialUpdate returns true if the server advertises the
+// sabredav partial update extension in the DAV header of an OPTIONS
+// request.  The result is cached for the lifetime of the Fs.
+func (f *Fs) supportsPartialUpdate() bool {
+	f.patchOnce.Do(func() {
+		opts := rest.Opts{
+			Method:     "OPTIONS",
+			Path:       f.dirPath(""),
+			NoResponse: true,
+		}
+		var resp *http.Response
+		err := f.pacer.Call(func() (bool, error) {
+			var err error
+			resp, err = f.srv.Call(&opts)
+			return shouldRetry(resp, err)
+		})
+		if err != nil {
+			fs.Debugf(f, "Couldn't check for partial update support: %v", err)
+			return
+		}
+		for _, header := range resp.Header[http.CanonicalHeaderKey("DAV")] {
+			for _, class := range strings.Split(header, ",") {
+				if strings.EqualFold(strings.TrimSpace(class), "sabredav-partialupdate") {
+					f.canPatch = true
+				}
+			}
+		}
+		fs.Debugf(f, "Server supports partial updates: %v", f.canPatch)
+	})
+	return f.canPatch
+}
+
+// uploadChunk writes chunk into the object at offset start using a
+// sabredav partial update
+func (o *Object) uploadChunk(start int64, chunk io.ReadSeeker, chunkSize int64) error {
+	opts := rest.Opts{
+		Method:        "PATCH",
+		Path:          o.filePath(),
+		Body:          chunk,
+		NoResponse:    true,
+		ContentType:   partialUpdateType,
+		ContentLength: &chunkSize,
+		ExtraHeaders: map[string]string{
+			"X-Update-Range": fmt.Sprintf("bytes=%d-%d", start, start+chunkSize-1),
+		},
+	}
+	return o.fs.pacer.Call(func() (bool, error) {
+		_, _ = chunk.Seek(0, 0)
+		resp, err := o.fs.srv.Call(&opts)
+		return shouldRetry(resp, err)
+	})
+}
+
+// uploadChunked uploads the object by creating an empty file then
+// writing it in chunks with sabredav partial updates.  If the upload
+// fails the partial object is removed.
+func (o *Object) uploadChunked(in io.Reader, src fs.ObjectInfo, size int64) (err error) {
+	fs.Debugf(o, "Starting chunked upload")
+	var zero int64
+	opts := rest.Opts{
+		Method:        "PUT",
+		Path:          o.filePath(),
+		NoResponse:    true,
+		ContentLength: &zero,
+	}
+	if o.fs.useOCMtime {
+		opts.ExtraHeaders = map[string]string{
+			"X-OC-Mtime": fmt.Sprintf("%f", float64(src.ModTime().UnixNano())/1E9),
+		}
+	}
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err := o.fs.srv.Call(&opts)
+		return shouldRetry(resp, err)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create file for chunked upload")
+	}
+
+	// Remove the partial file if something went wrong
+	defer func() {
+		if err != nil {
+			fs.Debugf(o, "Removing failed chunked upload: %v", err)
+			removeErr := o.Remove()
+			if removeErr != nil {
+				fs.Logf(o, "Failed to remove failed chunked upload: %v", removeErr)
+			}
+		}
+	}()
+
+	// Upload the chunks
+	remaining := size
+	position := int64(0)
+	for remaining > 0 {
+		n := int64(chunkSize)
+		if remaining < n {
+			n = remaining
+		}
+		seg := fs.NewRepeatableReader(io.LimitReader(in, n))
+		fs.Debugf(o, "Uploading segment %d/%d size %d", position, size, n)
+		err = o.uploadChunk(position, seg, n)
+		if err != nil {
+			return errors.Wrap(err, "chunked upload failed")
+		}
+		remaining -= n
+		position += n
+	}
+	return nil
+}
+
 // Remove an object
 func (o *Object) Remove() error {
 	opts := rest.Opts{
@@ -0,0 +1,172 @@
+package webdav
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncw/rclone/fs"
+	"github.com/ncw/rclone/pacer"
+	"github.com/ncw/rclone/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// partialServer is a minimal webdav server which stores files in
+// memory and optionally supports sabredav partial updates
+type partialServer struct {
+	canPatch bool
+	mu       sync.Mutex
+	files    map[string][]byte
+	methods  []string
+	ranges   []string
+}
+
+func (s *partialServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods = append(s.methods, r.Method)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case "OPTIONS":
+		dav := "1, 2"
+		if s.canPatch {
+			dav += ", sabredav-partialupdate"
+		}
+		w.Header().Set("DAV", dav)
+	case "PUT":
+		s.files[r.URL.Path] = body
+		w.WriteHeader(http.StatusCreated)
+	case "PATCH":
+		data, ok := s.files[r.URL.Path]
+		if !s.canPatch || !ok {
+			http.Error(w, "bad PATCH", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Content-Type") != partialUpdateType {
+			http.Error(w, "bad Content-Type", http.StatusUnsupportedMediaType)
+			return
+		}
+		updateRange := r.Header.Get("X-Update-Range")
+		s.ranges = append(s.ranges, updateRange)
+		var start, end int
+		_, err := fmt.Sscanf(updateRange, "bytes=%d-%d", &start, &end)
+		if err != nil || end-start+1 != len(body) || start > len(data) {
+			http.Error(w, "bad X-Update-Range", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if end+1 > len(data) {
+			data = append(data, make([]byte, end+1-len(data))...)
+		}
+		copy(data[start:], body)
+		s.files[r.URL.Path] = data
+		w.WriteHeader(http.StatusNoContent)
+	case "PROPFIND":
+		data, ok := s.files[r.URL.Path]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:">
+ <d:response>
+  <d:href>%s</d:href>
+  <d:propstat>
+   <d:prop>
+    <d:getcontentlength>%d</d:getcontentlength>
+    <d:getlastmodified>%s</d:getlastmodified>
+    <d:resourcetype/>
+   </d:prop>
+   <d:status>HTTP/1.1 200 OK</d:status>
+  </d:propstat>
+ </d:response>
+</d:multistatus>`, r.URL.Path, len(data), time.Now().UTC().Format(http.TimeFormat))
+	default:
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+	}
+}
+
+// newPartialTestFs makes an Fs pointing at a partialServer
+func newPartialTestFs(t *testing.T, canPatch bool) (*Fs, *partialServer, func()) {
+	s := &partialServer{
+		canPatch: canPatch,
+		files:    make(map[string][]byte),
+	}
+	ts := httptest.NewServer(s)
+	u, err := url.Parse(ts.URL + "/")
+	require.NoError(t, err)
+	f := &Fs{
+		name:        "partial",
+		endpoint:    u,
+		endpointURL: u.String(),
+		srv:         rest.NewClient(http.DefaultClient).SetRoot(u.String()),
+		pacer:       pacer.New().SetMinSleep(minSleep).SetMaxSleep(maxSleep).SetDecayConstant(decayConstant).SetRetries(1),
+		precision:   fs.ModTimeNotSupported,
+	}
+	f.features = (&fs.Features{}).Fill(f)
+	f.srv.SetErrorHandler(errorHandler)
+	f.setQuirks("other")
+	return f, s, ts.Close
+}
+
+func TestUploadChunked(t *testing.T) {
+	oldChunkSize := chunkSize
+	chunkSize = 10
+	defer func() { chunkSize = oldChunkSize }()
+
+	contents := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	for _, test := range []struct {
+		what        string
+		canPatch    bool
+		size        int
+		wantMethods []string
+		wantRanges  []string
+	}{
+		{
+			what:        "partial update supported",
+			canPatch:    true,
+			size:        len(contents),
+			wantMethods: []string{"OPTIONS", "PUT", "PATCH", "PATCH", "PATCH", "PATCH", "PROPFIND"},
+			wantRanges:  []string{"bytes=0-9", "bytes=10-19", "bytes=20-29", "bytes=30-35"},
+		},
+		{
+			what:        "partial update not supported",
+			canPatch:    false,
+			size:        len(contents),
+			wantMethods: []string{"OPTIONS", "PUT", "PROPFIND"},
+		},
+		{
+			what:        "smaller than chunk size",
+			canPatch:    true,
+			size:        int(chunkSize),
+			wantMethods: []string{"PUT", "PROPFIND"},
+		},
+	} {
+		f, s, cleanup := newPartialTestFs(t, test.canPatch)
+		data := contents[:test.size]
+		o := &Object{
+			fs:     f,
+			remote: "file.bin",
+		}
+		src := fs.NewStaticObjectInfo("file.bin", time.Now(), int64(len(data)), true, nil, nil)
+		err := o.Update(bytes.NewBuffer(data), src)
+		require.NoError(t, err, test.what)
+		assert.Equal(t, string(data), string(s.files["/file.bin"]), test.what)
+		assert.Equal(t, int64(len(data)), o.Size(), test.what)
+		assert.Equal(t, test.wantMethods, s.methods, test.what)
+		assert.Equal(t, test.wantRanges, s.ranges, test.what)
+		cleanup()
+	}
+}